@@ -0,0 +1,5 @@
+data "mimirtool_alertmanager" "live" {}
+
+output "alertmanager_templates" {
+  value = keys(data.mimirtool_alertmanager.live.templates)
+}
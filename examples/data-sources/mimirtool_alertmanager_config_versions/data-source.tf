@@ -0,0 +1,7 @@
+data "mimirtool_alertmanager_config_versions" "current" {
+  include_content = true
+}
+
+output "alertmanager_config" {
+  value = data.mimirtool_alertmanager_config_versions.current.versions[0].config_yaml
+}
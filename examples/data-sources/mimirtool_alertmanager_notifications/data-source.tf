@@ -0,0 +1,21 @@
+data "mimirtool_alertmanager_notifications" "preview" {
+  receivers = ["team-x"]
+
+  alert {
+    labels = {
+      alertname = "HighErrorRate"
+      severity  = "critical"
+    }
+    annotations = {
+      summary = "More than 5% of the requests fail."
+    }
+  }
+
+  group_labels = {
+    alertname = "HighErrorRate"
+  }
+}
+
+output "slack_text" {
+  value = [for n in data.mimirtool_alertmanager_notifications.preview.notifications : n.fields["text"] if n.integration == "slack_configs"]
+}
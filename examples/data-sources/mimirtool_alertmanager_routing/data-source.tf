@@ -0,0 +1,22 @@
+data "mimirtool_alertmanager_routing" "proposed" {
+  config_yaml = file("${path.module}/alertmanager.yaml")
+
+  alert {
+    labels = {
+      alertname = "HighErrorRate"
+      team      = "payments"
+      severity  = "critical"
+    }
+  }
+
+  alert {
+    labels = {
+      alertname = "DiskFilling"
+      team      = "platform"
+    }
+  }
+}
+
+output "rerouted_alerts" {
+  value = [for route in data.mimirtool_alertmanager_routing.proposed.routes : route.labels.alertname if route.changed]
+}
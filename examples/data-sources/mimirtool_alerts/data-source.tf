@@ -0,0 +1,11 @@
+check "no_immediate_firing" {
+  data "mimirtool_alerts" "team" {
+    namespace = mimirtool_ruler_namespace.team.namespace
+    states    = ["firing"]
+  }
+
+  assert {
+    condition     = length(data.mimirtool_alerts.team.alerts) == 0
+    error_message = "Alerts firing right after the change: ${join(", ", distinct(data.mimirtool_alerts.team.alerts[*].name))}"
+  }
+}
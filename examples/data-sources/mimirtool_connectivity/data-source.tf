@@ -0,0 +1,11 @@
+data "mimirtool_connectivity" "check" {
+  check_alertmanager = true
+}
+
+output "mimir_reachable" {
+  value = data.mimirtool_connectivity.check.ruler_ok
+}
+
+output "mimir_error" {
+  value = data.mimirtool_connectivity.check.ruler_error
+}
@@ -0,0 +1,6 @@
+data "mimirtool_export" "tenant" {}
+
+resource "local_file" "adopted" {
+  filename = "${path.root}/adopted/mimir.tf"
+  content  = data.mimirtool_export.tenant.hcl
+}
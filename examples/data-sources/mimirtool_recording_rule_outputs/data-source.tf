@@ -0,0 +1,8 @@
+data "mimirtool_recording_rule_outputs" "demo" {
+  namespace = "demo"
+  lookback  = "10m"
+}
+
+output "demo_missing_metrics" {
+  value = [for metric in data.mimirtool_recording_rule_outputs.demo.metrics : metric.name if metric.status != "ok"]
+}
@@ -0,0 +1,14 @@
+data "mimirtool_rule_dependency_graph" "all" {
+  namespace {
+    name        = mimirtool_ruler_namespace.recording.namespace
+    config_yaml = mimirtool_ruler_namespace.recording.config_yaml
+  }
+  namespace {
+    name        = mimirtool_ruler_namespace.alerts.namespace
+    config_yaml = mimirtool_ruler_namespace.alerts.config_yaml
+  }
+}
+
+output "alerts_by_recorded_metric" {
+  value = { for m in data.mimirtool_rule_dependency_graph.all.recorded_metrics : m.metric => m.alerts }
+}
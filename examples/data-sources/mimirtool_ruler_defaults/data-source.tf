@@ -0,0 +1,5 @@
+data "mimirtool_ruler_defaults" "tenant" {}
+
+output "evaluation_interval" {
+  value = data.mimirtool_ruler_defaults.tenant.evaluation_interval
+}
@@ -0,0 +1,8 @@
+data "mimirtool_ruler_namespace" "shared" {
+  namespace = "shared-recording-rules"
+  tenant_id = "platform"
+}
+
+output "shared_rules_sha256" {
+  value = data.mimirtool_ruler_namespace.shared.sha256
+}
@@ -0,0 +1,5 @@
+data "mimirtool_ruler_namespace_counts" "tenant" {}
+
+output "rules_by_namespace" {
+  value = { for c in data.mimirtool_ruler_namespace_counts.tenant.counts : c.namespace => c.rules }
+}
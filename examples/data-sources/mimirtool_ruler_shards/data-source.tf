@@ -0,0 +1,7 @@
+data "mimirtool_ruler_shards" "demo" {
+  namespace = "demo"
+}
+
+output "demo_groups_rulers" {
+  value = { for group in data.mimirtool_ruler_shards.demo.groups : group.name => group.instance }
+}
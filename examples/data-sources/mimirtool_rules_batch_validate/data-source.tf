@@ -0,0 +1,7 @@
+data "mimirtool_rules_batch_validate" "repository" {
+  namespaces = {
+    for f in fileset("${path.module}/rules", "*.yaml") : trimsuffix(f, ".yaml") => file("${path.module}/rules/${f}")
+  }
+  strict_recording_rule_check = true
+  extended_validation         = true
+}
@@ -0,0 +1,9 @@
+data "mimirtool_tls_debug" "current" {}
+
+output "client_certificate_expiry" {
+  value = try(data.mimirtool_tls_debug.current.client_certificates[0].not_after, null)
+}
+
+output "tls_error" {
+  value = data.mimirtool_tls_debug.current.error
+}
@@ -0,0 +1,4 @@
+# The tenant, or the ID of the resource. A tenant other than the provider one
+# is imported as the tenant_id of the resource.
+terraform import mimirtool_alertmanager.demo alertmanager
+terraform import mimirtool_alertmanager.demo team-b
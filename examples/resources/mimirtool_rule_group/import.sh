@@ -0,0 +1,5 @@
+# The namespace and the group names, optionally prefixed with the tenant. A
+# tenant other than the provider one is imported as the tenant_id of the
+# resource.
+terraform import mimirtool_rule_group.mimir_api demo/mimir_api_1
+terraform import mimirtool_rule_group.mimir_api anonymous/demo/mimir_api_1
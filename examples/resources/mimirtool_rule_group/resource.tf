@@ -0,0 +1,11 @@
+resource "mimirtool_rule_group" "mimir_api" {
+  namespace   = "demo"
+  name        = "mimir_api_1"
+  config_yaml = <<EOT
+interval: 1m
+rules:
+- expr: histogram_quantile(0.99, sum(rate(cortex_request_duration_seconds_bucket[1m]))
+    by (le, cluster, job))
+  record: cluster_job:cortex_request_duration_seconds:99quantile
+EOT
+}
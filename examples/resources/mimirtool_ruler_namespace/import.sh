@@ -0,0 +1,8 @@
+# The namespace name, optionally prefixed with the tenant. A tenant other
+# than the provider one is imported as the tenant_id of the resource. The ID
+# is split at its first slash, as tenants can't hold slashes: prefix the names
+# of the namespaces holding slashes with the tenant, or with a slash for the
+# provider tenant.
+terraform import mimirtool_ruler_namespace.demo demo
+terraform import mimirtool_ruler_namespace.demo anonymous/demo
+terraform import mimirtool_ruler_namespace.demo /team-a/demo
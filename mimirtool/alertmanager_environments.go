@@ -0,0 +1,78 @@
+package mimirtool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// attributeGetter is implemented by both schema.ResourceData and
+// schema.ResourceDiff.
+type attributeGetter interface {
+	Get(key string) interface{}
+}
+
+// alertmanagerConfigYAML returns the configuration of the resource of d: its
+// config_yaml, or its base_config_yaml patched for its environment.
+func alertmanagerConfigYAML(d attributeGetter) (string, error) {
+	base := d.Get("base_config_yaml").(string)
+	if base == "" {
+		return d.Get("config_yaml").(string), nil
+	}
+	return renderAlertmanagerEnvironment(base, stringValueMap(d.Get("environment_patches").(map[string]interface{})), d.Get("environment").(string))
+}
+
+// renderAlertmanagerEnvironment returns base merged with the patch of
+// environment: the mappings of the patch are merged recursively into the ones
+// of base, its null values remove the keys and its other values, lists
+// included, replace the ones of base.
+func renderAlertmanagerEnvironment(base string, patches map[string]string, environment string) (string, error) {
+	patchYAML, ok := patches[environment]
+	if !ok {
+		known := make([]string, 0, len(patches))
+		for name := range patches {
+			known = append(known, name)
+		}
+		sort.Strings(known)
+		return "", fmt.Errorf("environment %q has no patch in environment_patches, expected one of: %s", environment, strings.Join(known, ", "))
+	}
+
+	var cfg map[string]interface{}
+	if err := yaml.Unmarshal([]byte(base), &cfg); err != nil {
+		return "", fmt.Errorf("invalid base_config_yaml: %w", err)
+	}
+	var patch map[string]interface{}
+	if err := yaml.Unmarshal([]byte(patchYAML), &patch); err != nil {
+		return "", fmt.Errorf("invalid patch of environment %q: %w", environment, err)
+	}
+	cfg = mergePatch(cfg, patch)
+	if len(cfg) == 0 {
+		return "", fmt.Errorf("the configuration of environment %q is empty", environment)
+	}
+	res, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+// mergePatch merges patch into dst, which is modified, and returns it.
+func mergePatch(dst, patch map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{}, len(patch))
+	}
+	for k, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(dst, k)
+		case map[string]interface{}:
+			existing, _ := dst[k].(map[string]interface{})
+			dst[k] = mergePatch(existing, v)
+		default:
+			dst[k] = v
+		}
+	}
+	return dst
+}
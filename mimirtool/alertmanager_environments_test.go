@@ -0,0 +1,103 @@
+package mimirtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const testAlertmanagerBaseConfig = `route:
+  receiver: team
+  group_wait: 30s
+receivers:
+  - name: team
+    email_configs:
+      - to: team@example.org
+inhibit_rules:
+  - source_matchers: [severity="critical"]
+    target_matchers: [severity="warning"]
+`
+
+func TestRenderAlertmanagerEnvironment(t *testing.T) {
+	patches := map[string]string{
+		"dev": "",
+		"prod": `route:
+  group_wait: 10s
+receivers:
+  - name: team
+    pagerduty_configs:
+      - routing_key: key
+inhibit_rules: null
+`,
+	}
+
+	dev, err := renderAlertmanagerEnvironment(testAlertmanagerBaseConfig, patches, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dev, "group_wait: 30s") || !strings.Contains(dev, "inhibit_rules") {
+		t.Fatalf("expected the base configuration, got:\n%s", dev)
+	}
+
+	prod, err := renderAlertmanagerEnvironment(testAlertmanagerBaseConfig, patches, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"receiver: team", "group_wait: 10s", "pagerduty_configs"} {
+		if !strings.Contains(prod, want) {
+			t.Errorf("expected %q in the patched configuration:\n%s", want, prod)
+		}
+	}
+	for _, unwanted := range []string{"email_configs", "inhibit_rules"} {
+		if strings.Contains(prod, unwanted) {
+			t.Errorf("expected %q to be replaced or removed:\n%s", unwanted, prod)
+		}
+	}
+
+	_, err = renderAlertmanagerEnvironment(testAlertmanagerBaseConfig, patches, "staging")
+	if err == nil || !strings.Contains(err.Error(), "expected one of: dev, prod") {
+		t.Fatalf("expected an unknown environment error, got %v", err)
+	}
+	_, err = renderAlertmanagerEnvironment(testAlertmanagerBaseConfig, map[string]string{"dev": "route: [oops"}, "dev")
+	if err == nil || !strings.Contains(err.Error(), `patch of environment "dev"`) {
+		t.Fatalf("expected an invalid patch error, got %v", err)
+	}
+}
+
+func TestAlertmanagerEnvironment(t *testing.T) {
+	// The base and the patches hold the credentials of the receivers.
+	for _, key := range []string{"base_config_yaml", "environment_patches"} {
+		if !resourceAlertManager().Schema[key].Sensitive {
+			t.Errorf("expected %s to be sensitive", key)
+		}
+	}
+
+	fake := newFakeMimirClient()
+	meta := &client{cli: fake}
+	d := schema.TestResourceDataRaw(t, resourceAlertManager().Schema, map[string]interface{}{
+		"base_config_yaml":    testAlertmanagerBaseConfig,
+		"environment_patches": map[string]interface{}{"dev": "", "prod": "route:\n  group_wait: 10s\n"},
+		"environment":         "prod",
+		"skip_validation":     []interface{}{validatorConfigSize},
+	})
+	if diags := alertmanagerCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	pushed, _, _ := fake.GetAlertmanagerConfig(context.Background())
+	if !strings.Contains(pushed, "group_wait: 10s") {
+		t.Fatalf("expected the configuration of prod to be pushed, got:\n%s", pushed)
+	}
+	if d.Get("config_yaml") != "" || d.Get("content_sha256") == "" {
+		t.Fatalf("expected only the hash of the patched configuration to be stored, got %q", d.Get("config_yaml"))
+	}
+
+	if diags := alertmanagerRead(context.Background(), d, meta); diags.HasError() || d.Get("base_config_yaml") != testAlertmanagerBaseConfig {
+		t.Fatalf("expected no drift, got %v", diags)
+	}
+	fake.CreateAlertmanagerConfig(context.Background(), testAlertmanagerBaseConfig, nil)
+	if diags := alertmanagerRead(context.Background(), d, meta); diags.HasError() || d.Get("base_config_yaml") != "" {
+		t.Fatalf("expected the drift to clear base_config_yaml, got %v", diags)
+	}
+}
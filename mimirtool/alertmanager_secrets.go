@@ -0,0 +1,109 @@
+package mimirtool
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretReferenceRegexp matches the ${<resolver>:<reference>} secret
+// references of the Alertmanager configurations.
+var secretReferenceRegexp = regexp.MustCompile(`\$\{([a-z][a-z0-9_]*):([^}]*)\}`)
+
+// secretResolver resolves the references of a kind of secret.
+type secretResolver interface {
+	resolve(reference string) (string, error)
+}
+
+// secretResolvers are the resolvers of secret references by name.
+var secretResolvers = map[string]secretResolver{
+	"env":  envSecretResolver{},
+	"file": fileSecretResolver{},
+}
+
+// envSecretResolver resolves ${env:NAME} to the value of the environment
+// variable NAME.
+type envSecretResolver struct{}
+
+func (envSecretResolver) resolve(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("the environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// fileSecretResolver resolves ${file:PATH} to the content of the file at
+// PATH, without its trailing newlines.
+type fileSecretResolver struct{}
+
+func (fileSecretResolver) resolve(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// resolveSecretReferences returns cfg with its secret references replaced by
+// the secrets they reference. The references are replaced in the scalars of
+// the YAML document, which is encoded again so that the secrets are quoted as
+// their content requires and stay strings. cfg is returned as is when it holds
+// no reference. The errors never hold the secrets.
+func resolveSecretReferences(cfg string) (string, error) {
+	if !secretReferenceRegexp.MatchString(cfg) {
+		return cfg, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(cfg), &doc); err != nil {
+		return "", fmt.Errorf("invalid alertmanager configuration: %w", err)
+	}
+	var errs []string
+	resolveNodeSecretReferences(&doc, &errs)
+	if len(errs) > 0 {
+		return "", fmt.Errorf("unable to resolve the secret references of the alertmanager configuration: %s", strings.Join(errs, "; "))
+	}
+	resolved, err := yaml.Marshal(&doc)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode the alertmanager configuration: %w", err)
+	}
+	return string(resolved), nil
+}
+
+// resolveNodeSecretReferences replaces the secret references of the scalars
+// of node, appending the references it cannot resolve to errs.
+func resolveNodeSecretReferences(node *yaml.Node, errs *[]string) {
+	if node.Kind != yaml.ScalarNode {
+		for _, child := range node.Content {
+			resolveNodeSecretReferences(child, errs)
+		}
+		return
+	}
+	resolved := secretReferenceRegexp.ReplaceAllStringFunc(node.Value, func(ref string) string {
+		m := secretReferenceRegexp.FindStringSubmatch(ref)
+		resolver, ok := secretResolvers[m[1]]
+		if !ok {
+			known := make([]string, 0, len(secretResolvers))
+			for name := range secretResolvers {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			*errs = append(*errs, fmt.Sprintf("%s: unknown resolver %q, expected one of: %s", ref, m[1], strings.Join(known, ", ")))
+			return ref
+		}
+		secret, err := resolver.resolve(m[2])
+		if err != nil {
+			*errs = append(*errs, fmt.Sprintf("%s: %s", ref, err))
+			return ref
+		}
+		return secret
+	})
+	if resolved != node.Value {
+		// A secret reading as a number or a boolean stays a string.
+		node.Value, node.Tag = resolved, "!!str"
+	}
+}
@@ -0,0 +1,86 @@
+package mimirtool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestResolveSecretReferences(t *testing.T) {
+	t.Setenv("MIMIRTOOL_TEST_SLACK_URL", "https://hooks.slack.com/services/secret")
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("routing-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := resolveSecretReferences("api_url: ${env:MIMIRTOOL_TEST_SLACK_URL}\nrouting_key: ${file:" + path + "}\ntext: '{{ .CommonLabels.alertname }}'\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != "api_url: https://hooks.slack.com/services/secret\nrouting_key: routing-key\ntext: '{{ .CommonLabels.alertname }}'\n" {
+		t.Errorf("unexpected resolved configuration %q", resolved)
+	}
+
+	// Secrets are substituted as YAML strings, whatever they hold.
+	for _, secret := range []string{"pass: #word", "'quoted\"", "true", "8080", "*alias", "line\nbreak"} {
+		t.Setenv("MIMIRTOOL_TEST_SECRET", secret)
+		resolved, err := resolveSecretReferences("password: ${env:MIMIRTOOL_TEST_SECRET}\nprefixed: 'x-${env:MIMIRTOOL_TEST_SECRET}'\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]interface{}
+		if err := yaml.Unmarshal([]byte(resolved), &got); err != nil {
+			t.Fatalf("%q: invalid resolved configuration %q: %s", secret, resolved, err)
+		}
+		if got["password"] != secret || got["prefixed"] != "x-"+secret {
+			t.Errorf("%q: unexpected resolved configuration %q", secret, resolved)
+		}
+	}
+
+	_, err = resolveSecretReferences("a: ${env:MIMIRTOOL_TEST_UNSET}\nb: ${vault:secret/data#key}\n")
+	if err == nil || !strings.Contains(err.Error(), "MIMIRTOOL_TEST_UNSET is not set") || !strings.Contains(err.Error(), `unknown resolver "vault", expected one of: env, file`) {
+		t.Errorf("expected both references to be reported, got %v", err)
+	}
+}
+
+func TestAlertmanagerSecretReferences(t *testing.T) {
+	t.Setenv("MIMIRTOOL_TEST_WEBHOOK_URL", "https://hooks.example.org/secret")
+	template := `route:
+  receiver: webhook
+receivers:
+  - name: webhook
+    webhook_configs:
+      - url: ${env:MIMIRTOOL_TEST_WEBHOOK_URL}
+`
+	d := schema.TestResourceDataRaw(t, resourceAlertManager().Schema, map[string]interface{}{
+		"config_yaml":               template,
+		"resolve_secret_references": true,
+	})
+	fake := newFakeMimirClient()
+	if diags := alertmanagerCreate(context.Background(), d, &client{cli: fake}); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if !strings.Contains(fake.alertmanagerConfig, "url: https://hooks.example.org/secret") {
+		t.Errorf("expected the resolved configuration to be pushed, got %s", fake.alertmanagerConfig)
+	}
+	if d.Get("config_yaml") != template {
+		t.Errorf("expected the state to keep the references, got %s", d.Get("config_yaml"))
+	}
+	if d.Get("content_sha256") != alertmanagerContentHash(template, nil) {
+		t.Errorf("expected the references to be hashed rather than the secrets, got %s", d.Get("content_sha256"))
+	}
+
+	// A secret changed since the push is pushed again.
+	t.Setenv("MIMIRTOOL_TEST_WEBHOOK_URL", "https://hooks.example.org/rotated")
+	if diags := alertmanagerRead(context.Background(), d, &client{cli: fake}); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("config_yaml") != "" {
+		t.Errorf("expected the configuration to be cleared for the next plan, got %s", d.Get("config_yaml"))
+	}
+}
@@ -0,0 +1,64 @@
+package mimirtool
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// readTemplateFiles returns the content of the template files, by template
+// name.
+func readTemplateFiles(files map[string]string) (map[string]string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	templates := make(map[string]string, len(files))
+	for _, name := range names {
+		content, err := os.ReadFile(files[name])
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("the file %s of template %q does not exist, paths are relative to the directory Terraform runs in, use path.module to refer to the module", files[name], name)
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to read the file of template %q: %w", name, err)
+		}
+		templates[name] = string(content)
+	}
+	return templates, nil
+}
+
+// templateHashes returns the SHA-256 of templates, by name.
+func templateHashes(templates map[string]string) map[string]string {
+	hashes := make(map[string]string, len(templates))
+	for name, content := range templates {
+		hashes[name] = hash(content)
+	}
+	return hashes
+}
+
+// alertmanagerTemplates returns the templates of the resource of d: the ones
+// of templates_config_yaml along with the content of the ones of
+// template_files.
+func alertmanagerTemplates(d attributeGetter) (map[string]string, error) {
+	templates := stringValueMap(d.Get("templates_config_yaml").(map[string]interface{}))
+	paths := stringValueMap(d.Get("template_files").(map[string]interface{}))
+	// Mimir stores the templates as files named after them.
+	for attribute, names := range map[string]map[string]string{"templates_config_yaml": templates, "template_files": paths} {
+		if _, ok := names[""]; ok {
+			return nil, fmt.Errorf("%s: the names of the templates must not be empty", attribute)
+		}
+	}
+	files, err := readTemplateFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	for name, content := range files {
+		if _, ok := templates[name]; ok {
+			return nil, fmt.Errorf("template %q is set by both templates_config_yaml and template_files", name)
+		}
+		templates[name] = content
+	}
+	return templates, nil
+}
@@ -0,0 +1,61 @@
+package mimirtool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAlertmanagerTemplateFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "team.tmpl")
+	if err := os.WriteFile(path, []byte(`{{ define "team" }}Team{{ end }}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeMimirClient()
+	meta := &client{cli: fake}
+	d := schema.TestResourceDataRaw(t, resourceAlertManager().Schema, map[string]interface{}{
+		"config_yaml":           testAlertmanagerBaseConfig,
+		"templates_config_yaml": map[string]interface{}{"inline.tmpl": `{{ define "inline" }}Inline{{ end }}`},
+		"template_files":        map[string]interface{}{"team.tmpl": path},
+		"skip_validation":       []interface{}{validatorConfigSize},
+	})
+	if diags := alertmanagerCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	_, pushed, _ := fake.GetAlertmanagerConfig(context.Background())
+	if pushed["team.tmpl"] != `{{ define "team" }}Team{{ end }}` || pushed["inline.tmpl"] == "" {
+		t.Fatalf("expected the templates of both the files and the configuration, got %v", pushed)
+	}
+	if templates := d.Get("templates_config_yaml").(map[string]interface{}); len(templates) != 1 {
+		t.Errorf("expected the templates of files to be left out of templates_config_yaml, got %v", templates)
+	}
+	if hashes := d.Get("template_files_sha256").(map[string]interface{}); hashes["team.tmpl"] != hash(pushed["team.tmpl"]) {
+		t.Errorf("expected the hash of the template file, got %v", hashes)
+	}
+
+	// A file changed after the plan is not pushed.
+	if err := os.WriteFile(path, []byte(`{{ define "team" }}Changed{{ end }}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if diags := alertmanagerCreate(context.Background(), d, meta); !diags.HasError() || !strings.Contains(diags[0].Summary, "changed since the plan") {
+		t.Fatalf("expected the change to be refused, got %v", diags)
+	}
+
+	_, err := readTemplateFiles(map[string]string{"missing.tmpl": filepath.Join(dir, "missing.tmpl")})
+	if err == nil || !strings.Contains(err.Error(), `of template "missing.tmpl" does not exist`) {
+		t.Fatalf("expected a missing file error, got %v", err)
+	}
+	d.Set("templates_config_yaml", map[string]interface{}{"team.tmpl": "inline"})
+	if _, err := alertmanagerTemplates(d); err == nil || !strings.Contains(err.Error(), "set by both") {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+	d.Set("templates_config_yaml", map[string]interface{}{"": "inline"})
+	if _, err := alertmanagerTemplates(d); err == nil || !strings.Contains(err.Error(), "templates_config_yaml: the names of the templates must not be empty") {
+		t.Fatalf("expected an empty name to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package mimirtool
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"gopkg.in/yaml.v3"
+)
+
+// receiverCredential describes the fields an integration needs to notify:
+// one of fields must be set in the integration configuration, or one of
+// globals in the global section.
+type receiverCredential struct {
+	fields  []string
+	globals []string
+}
+
+// receiverCredentials lists the credentials required by the common
+// integrations, keyed by their configuration list in a receiver.
+var receiverCredentials = map[string][]receiverCredential{
+	"slack_configs": {
+		{fields: []string{"api_url", "api_url_file"}, globals: []string{"slack_api_url", "slack_api_url_file"}},
+	},
+	"pagerduty_configs": {
+		{fields: []string{"routing_key", "routing_key_file", "service_key", "service_key_file"}},
+	},
+	"webhook_configs": {
+		{fields: []string{"url", "url_file"}},
+	},
+	"email_configs": {
+		{fields: []string{"to"}},
+		{fields: []string{"smarthost"}, globals: []string{"smtp_smarthost"}},
+		{fields: []string{"from"}, globals: []string{"smtp_from"}},
+	},
+	"opsgenie_configs": {
+		{fields: []string{"api_key", "api_key_file"}, globals: []string{"opsgenie_api_key", "opsgenie_api_key_file"}},
+	},
+}
+
+type alertmanagerReceivers struct {
+	Global    map[string]any `yaml:"global"`
+	Receivers []struct {
+		Name         string                      `yaml:"name"`
+		Integrations map[string][]map[string]any `yaml:",inline"`
+	} `yaml:"receivers"`
+}
+
+// checkAlertmanagerReceivers warns about the receivers whose integrations
+// miss the credentials they need, which makes Alertmanager silently drop
+// their notifications. Configurations which cannot be parsed are left to
+// Mimir to reject.
+func checkAlertmanagerReceivers(config string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	var cfg alertmanagerReceivers
+	if err := yaml.Unmarshal([]byte(config), &cfg); err != nil {
+		return nil
+	}
+
+	for _, receiver := range cfg.Receivers {
+		for integration, configs := range receiver.Integrations {
+			for _, credential := range receiverCredentials[integration] {
+				if hasAnyValue(cfg.Global, credential.globals) {
+					continue
+				}
+				for _, c := range configs {
+					if hasAnyValue(c, credential.fields) {
+						continue
+					}
+					detail := fmt.Sprintf("Receiver %q has `%s` without `%s`", receiver.Name, integration, strings.Join(credential.fields, "` or `"))
+					if len(credential.globals) > 0 {
+						detail += fmt.Sprintf(" and the global section doesn't set `%s`", strings.Join(credential.globals, "` or `"))
+					}
+					diags = append(diags, diag.Diagnostic{
+						Severity:      diag.Warning,
+						Summary:       "Receiver is missing credentials.",
+						Detail:        detail + ", its notifications will be dropped.",
+						AttributePath: cty.GetAttrPath("config_yaml"),
+					})
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// checkAlertmanagerRootRoute fails when the root route of the configuration
+// doesn't send to a receiver of the configuration, which Alertmanager
+// rejects with a terse error once the plan is applied. Configurations which
+// cannot be parsed are left to Mimir to reject.
+func checkAlertmanagerRootRoute(config string) error {
+	var cfg struct {
+		Route *struct {
+			Receiver string `yaml:"receiver"`
+		} `yaml:"route"`
+		Receivers []struct {
+			Name string `yaml:"name"`
+		} `yaml:"receivers"`
+	}
+	if err := yaml.Unmarshal([]byte(config), &cfg); err != nil {
+		return nil
+	}
+
+	switch {
+	case cfg.Route == nil:
+		return errors.New("invalid root route: the configuration has no `route`, Alertmanager requires a root route sending to a receiver by default")
+	case cfg.Route.Receiver == "":
+		return errors.New("invalid root route: the root `route` doesn't set `receiver`, Alertmanager requires it as the receiver of the alerts no child route matches. Add e.g. a `blackhole` receiver without integrations to drop them")
+	}
+	for _, receiver := range cfg.Receivers {
+		if receiver.Name == cfg.Route.Receiver {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid root route: the root `route` sends to receiver %q which is not defined in `receivers`", cfg.Route.Receiver)
+}
+
+func hasAnyValue(m map[string]any, keys []string) bool {
+	for _, key := range keys {
+		if v, ok := m[key]; ok && v != nil && v != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package mimirtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestValidateAlertmanagerReceivers(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config   string
+		warnings []string
+	}{
+		"complete": {
+			config: `
+global:
+  slack_api_url: https://hooks.slack.com/services/x
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.org
+receivers:
+  - name: slack
+    slack_configs:
+      - channel: '#alerts'
+  - name: email
+    email_configs:
+      - to: oncall@example.org
+  - name: pagerduty
+    pagerduty_configs:
+      - routing_key: key
+  - name: webhook
+    webhook_configs:
+      - url: http://example.org
+  - name: opsgenie
+    opsgenie_configs:
+      - api_key: key
+  - name: blackhole
+`,
+		},
+		"missing": {
+			config: `
+receivers:
+  - name: slack
+    slack_configs:
+      - channel: '#alerts'
+  - name: email
+    email_configs:
+      - to: oncall@example.org
+        smarthost: localhost:25
+  - name: pagerduty
+    pagerduty_configs:
+      - severity: critical
+  - name: webhook
+    webhook_configs:
+      - send_resolved: true
+  - name: opsgenie
+    opsgenie_configs:
+      - priority: P1
+`,
+			warnings: []string{
+				`"slack" has ` + "`slack_configs`" + ` without ` + "`api_url`",
+				`"email" has ` + "`email_configs`" + ` without ` + "`from`",
+				`"pagerduty" has ` + "`pagerduty_configs`",
+				`"webhook" has ` + "`webhook_configs`",
+				`"opsgenie" has ` + "`opsgenie_configs`",
+			},
+		},
+		"invalid": {
+			config: "receivers: [",
+		},
+	} {
+		diags := checkAlertmanagerReceivers(tc.config)
+		if len(diags) != len(tc.warnings) {
+			t.Fatalf("%s: expected %d warnings, got %v", name, len(tc.warnings), diags)
+		}
+		for _, want := range tc.warnings {
+			found := false
+			for _, d := range diags {
+				found = found || strings.Contains(d.Detail, want)
+			}
+			if !found {
+				t.Errorf("%s: expected a warning containing %q, got %v", name, want, diags)
+			}
+		}
+	}
+}
+
+func TestAlertmanagerPlannedWarnings(t *testing.T) {
+	config := "route:\n  receiver: slack\nreceivers:\n  - name: slack\n    slack_configs:\n      - channel: '#alerts'\n"
+	for name, tc := range map[string]struct {
+		skip     []interface{}
+		warnings string
+	}{
+		"enabled": {warnings: "1"},
+		"skipped": {skip: []interface{}{validatorReceiverCredentials}, warnings: "0"},
+	} {
+		raw := map[string]interface{}{"config_yaml": config}
+		if tc.skip != nil {
+			raw["skip_validation"] = tc.skip
+		}
+		diff, err := resourceAlertManager().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(raw), &client{})
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		// An empty list is not part of the diff.
+		if count := diff.Attributes["planned_warnings.#"]; (count == nil && tc.warnings != "0") || (count != nil && count.New != tc.warnings) {
+			t.Fatalf("%s: expected %s planned warnings, got %v", name, tc.warnings, count)
+		}
+		if warning := diff.Attributes["planned_warnings.0"]; tc.warnings == "1" && (warning == nil || !strings.Contains(warning.New, "`slack_configs` without `api_url`")) {
+			t.Errorf("%s: expected the warning about the Slack credentials, got %v", name, warning)
+		}
+	}
+}
+
+func TestValidateAlertmanagerRootRoute(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config string
+		err    string
+	}{
+		"valid": {
+			config: testAlertmanagerBaseConfig,
+		},
+		"no route": {
+			config: "receivers:\n  - name: team\n",
+			err:    "has no `route`",
+		},
+		"no receiver": {
+			config: "route:\n  group_wait: 30s\nreceivers:\n  - name: team\n",
+			err:    "doesn't set `receiver`",
+		},
+		"undefined receiver": {
+			config: "route:\n  receiver: tema\nreceivers:\n  - name: team\n",
+			err:    `receiver "tema" which is not defined`,
+		},
+		"unparsable": {
+			config: "route: [",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := checkAlertmanagerRootRoute(tc.config)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package mimirtool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiGet reads an endpoint of Mimir which the mimirtool client doesn't
+// support, authenticating the same way it does. Errors are classified as the
+// ones of the client.
+func (c *client) apiGet(ctx context.Context, resource string, path string, header http.Header) ([]byte, error) {
+	return c.apiRequest(ctx, resource, http.MethodGet, path, header, nil)
+}
+
+// apiRequest is apiGet for any method, requests other than GET are writes and
+// go through the write queue.
+func (c *client) apiRequest(ctx context.Context, resource string, method string, path string, header http.Header, payload []byte) ([]byte, error) {
+	cli, err := c.tenantClient(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := c.httpClient(resource, c.tenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRequestSize(fmt.Sprintf("%s: the body of %s %s", resource, method, path), len(payload), c.config.maxRequestBytes); err != nil {
+		return nil, err
+	}
+	kind := callKind(resource)
+	ctx, cancel := c.timeouts.context(ctx, kind)
+	defer cancel()
+	var body []byte
+	operation := method + " " + path
+	do := func() error {
+		attemptCtx, cancel := c.timeouts.attemptContext(ctx, kind)
+		defer cancel()
+		body, err = c.doAPIRequest(attemptCtx, httpClient, resource, method, path, header, payload)
+		return c.timeouts.describeAttempt(ctx, attemptCtx, kind, operation, err)
+	}
+	err = retry(ctx, retryPolicyFrom(ctx, c.retry), &c.stats, operation, func() error {
+		if api, ok := cli.(*apiClient); ok && method != http.MethodGet {
+			return api.writes.do(ctx, operation, do)
+		}
+		return do()
+	})
+	return body, c.timeouts.describe(ctx, kind, operation, err)
+}
+
+// doAPIRequest sends the request once through httpClient, the one of the
+// tenant of ctx.
+func (c *client) doAPIRequest(ctx context.Context, httpClient *http.Client, resource string, method string, path string, header http.Header, payload []byte) ([]byte, error) {
+	// The tenant may have its own credentials.
+	cfg := c.config.forTenant(c.tenant(ctx))
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(cfg.Address, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", resource, err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	for k, v := range cfg.ExtraHeaders {
+		req.Header.Set(k, v)
+	}
+	if cfg.ID != "" {
+		req.Header.Set("X-Scope-OrgID", cfg.ID)
+	}
+	switch authMechanism(cfg) {
+	case authBasic:
+		user := cfg.User
+		if user == "" {
+			user = cfg.ID
+		}
+		req.SetBasicAuth(user, cfg.Key)
+	case authBearer:
+		// A token file is handled by the transport.
+		if cfg.AuthToken != "" {
+			req.Header.Set("Authorization", "Bearer "+cfg.AuthToken)
+		}
+	}
+
+	if method == http.MethodGet {
+		c.stats.add(ctx, countGets, 1)
+	} else {
+		c.stats.add(ctx, countSets, 1)
+		c.stats.add(ctx, countBytesPushed, int64(len(payload)))
+	}
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	c.stats.add(ctx, countAPITime, int64(time.Since(start)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Worded as the mimirtool client so that classifyError handles both.
+		err = classifyError(fmt.Errorf("server returned HTTP status: %s, body: %q", resp.Status, body))
+		if errors.Is(err, ErrUnauthorized) {
+			err = describeAuthError(err, authMechanism(cfg), cfg.ID != "")
+		}
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,55 @@
+package mimirtool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAPIRequestTenantCredentials(t *testing.T) {
+	var mu sync.Mutex
+	auth := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth[r.Header.Get("X-Scope-OrgID")] = r.Header.Get("Authorization")
+		mu.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("provider-token"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The Mimir clients are injected, the raw requests must still go through
+	// clients of their own tenant.
+	p := newProvider("dev", func(clientConfig) (mimirClientInterface, error) {
+		return newFakeMimirClient(), nil
+	})()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"address":            server.URL,
+		"tenant_id":          "team-a",
+		"auth_token_file":    tokenFile,
+		"tenant_credentials": []interface{}{map[string]interface{}{"tenant_id": "team-b", "auth_token": "token-b"}},
+	})
+	if diags := p.Configure(context.Background(), config); diags.HasError() {
+		t.Fatal(diags)
+	}
+	c := p.Meta().(*client)
+	for _, tenant := range []string{"", "team-b", "team-c"} {
+		if _, err := c.apiGet(withTenant(context.Background(), tenant), "mimirtool_ruler_namespace", "/api/v1/rules", nil); err != nil {
+			t.Fatalf("tenant %q: %s", tenant, err)
+		}
+	}
+	for tenant, want := range map[string]string{"team-a": "Bearer provider-token", "team-b": "Bearer token-b", "team-c": "Bearer provider-token"} {
+		if auth[tenant] != want {
+			t.Errorf("tenant %q: expected the authorization %q, got %q", tenant, want, auth[tenant])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package mimirtool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// apiWarnings collects the warnings returned by Mimir along with successful
+// responses during an operation, once each.
+type apiWarnings struct {
+	mu       sync.Mutex
+	seen     map[string]bool
+	warnings []string
+}
+
+type apiWarningsKey struct{}
+
+// withAPIWarnings returns ctx along with the collector of the warnings of the
+// calls made with it.
+func withAPIWarnings(ctx context.Context) (context.Context, *apiWarnings) {
+	w := &apiWarnings{seen: map[string]bool{}}
+	return context.WithValue(ctx, apiWarningsKey{}, w), w
+}
+
+func (w *apiWarnings) add(warnings ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, warning := range warnings {
+		if warning != "" && !w.seen[warning] {
+			w.seen[warning] = true
+			w.warnings = append(w.warnings, warning)
+		}
+	}
+}
+
+// diagnostics returns the warnings collected.
+func (w *apiWarnings) diagnostics() diag.Diagnostics {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var diags diag.Diagnostics
+	for _, warning := range w.warnings {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Grafana Mimir returned a warning.",
+			Detail:   warning,
+		})
+	}
+	return diags
+}
+
+// warningTransport collects the warnings of the JSON responses of Mimir, the
+// Prometheus API envelope having a "warnings" list, for the operation of the
+// request context.
+type warningTransport struct {
+	base http.RoundTripper
+}
+
+func (t *warningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	w, ok := req.Context().Value(apiWarningsKey{}).(*apiWarnings)
+	if !ok || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	w.add(responseWarnings(body)...)
+	return resp, nil
+}
+
+// responseWarnings returns the warnings of a response body, none when it is
+// not a JSON object.
+func responseWarnings(body []byte) []string {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil
+	}
+	var envelope struct {
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil
+	}
+	return envelope.Warnings
+}
+
+// surfaceAPIWarnings makes every operation of r report the warnings returned
+// by Mimir during it, unless the provider suppress_api_warnings is set.
+func surfaceAPIWarnings(r *schema.Resource) {
+	wrap := func(f func(context.Context, *schema.ResourceData, any) diag.Diagnostics) func(context.Context, *schema.ResourceData, any) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+			ctx, warnings := withAPIWarnings(ctx)
+			diags := f(ctx, d, meta)
+			c, ok := meta.(*client)
+			if !ok {
+				return diags
+			}
+			if c.suppressAPIWarnings {
+				for _, warning := range warnings.diagnostics() {
+					tflog.Debug(ctx, "Grafana Mimir warning suppressed", map[string]interface{}{
+						"warning": warning.Detail,
+					})
+				}
+				return diags
+			}
+			return append(diags, warnings.diagnostics()...)
+		}
+	}
+	r.CreateContext = wrap(r.CreateContext)
+	r.ReadContext = wrap(r.ReadContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+}
@@ -0,0 +1,134 @@
+package mimirtool
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestWarningTransport(t *testing.T) {
+	body := `{"status":"success","data":{},"warnings":["first","second"]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: &warningTransport{base: http.DefaultTransport}}
+	get := func(ctx context.Context) string {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		read, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(read)
+	}
+
+	// Without collector, the response is left as is.
+	if got := get(context.Background()); got != body {
+		t.Fatalf("unexpected body %q", got)
+	}
+
+	ctx, warnings := withAPIWarnings(context.Background())
+	if got := get(ctx); got != body {
+		t.Fatalf("expected the body to be readable once inspected, got %q", got)
+	}
+	get(ctx)
+	body = "groups: []\n"
+	get(ctx)
+
+	diags := warnings.diagnostics()
+	if len(diags) != 2 || diags[0].Detail != "first" || diags[1].Detail != "second" {
+		t.Fatalf("expected the warnings once each, got %v", diags)
+	}
+	for _, d := range diags {
+		if d.Severity != diag.Warning {
+			t.Fatalf("expected warnings, got %v", d)
+		}
+	}
+}
+
+func TestAPIWarningsDiagnostics(t *testing.T) {
+	responses := map[string]string{
+		"/prometheus/api/v1/rules": `{"status":"success","data":{"groups":[{"name":"group","file":"demo","rules":[]}]},"warnings":["rules warning"]}`,
+		"/api/v1/user_limits":      `{"alertmanager_max_config_size_bytes":0,"warnings":["limits warning"]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(responses[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	resources := New("test")().ResourcesMap
+	operations := []struct {
+		name string
+		run  func(meta *client) diag.Diagnostics
+		want string
+	}{
+		{
+			name: "mimirtool_ruler_namespace",
+			run: func(meta *client) diag.Diagnostics {
+				r := resources["mimirtool_ruler_namespace"]
+				d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+					"namespace":   "demo",
+					"config_yaml": "groups:\n  - name: group\n    rules: []\n",
+				})
+				d.SetId(hash("demo"))
+				d.Set("remote_hash", "cached")
+				return r.ReadContext(context.Background(), d, meta)
+			},
+			want: "rules warning",
+		},
+		{
+			name: "mimirtool_alertmanager",
+			run: func(meta *client) diag.Diagnostics {
+				r := resources["mimirtool_alertmanager"]
+				d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+					"config_yaml": testAccResourceAlertmanagerYaml,
+				})
+				return r.CreateContext(context.Background(), d, meta)
+			},
+			want: "limits warning",
+		},
+	}
+
+	for _, op := range operations {
+		t.Run(op.name, func(t *testing.T) {
+			meta := &client{cli: newFakeMimirClient(), fastRefresh: true}
+			meta.config.Address = server.URL
+			meta.config.prometheusHTTPPrefix = "/prometheus"
+
+			diags := op.run(meta)
+			if diags.HasError() {
+				t.Fatal(diags)
+			}
+			var got []string
+			for _, d := range diags {
+				if d.Severity == diag.Warning && d.Summary == "Grafana Mimir returned a warning." {
+					got = append(got, d.Detail)
+				}
+			}
+			if len(got) != 1 || got[0] != op.want {
+				t.Fatalf("expected the %q warning, got %v", op.want, got)
+			}
+
+			meta.suppressAPIWarnings = true
+			for _, d := range op.run(meta) {
+				if d.Summary == "Grafana Mimir returned a warning." {
+					t.Fatalf("expected the warnings to be suppressed, got %v", d)
+				}
+			}
+		})
+	}
+}
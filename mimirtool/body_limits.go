@@ -0,0 +1,71 @@
+package mimirtool
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// defaultMaxBodyBytes is the default of max_request_bytes and
+// max_response_bytes.
+const defaultMaxBodyBytes = 100 << 20
+
+// bodyTooLargeError reports a request or a response going past the provider
+// limits.
+type bodyTooLargeError string
+
+func (e bodyTooLargeError) Error() string {
+	return string(e)
+}
+
+// checkRequestSize fails when what, of size bytes, is larger than max, 0
+// meaning no limit.
+func checkRequestSize(what string, size int, max int64) error {
+	if max > 0 && int64(size) > max {
+		return bodyTooLargeError(fmt.Sprintf("%s is %d bytes, more than the %d bytes allowed by the provider `max_request_bytes`", what, size, max))
+	}
+	return nil
+}
+
+// responseLimitTransport fails the responses larger than max bytes, rather
+// than reading them whole.
+type responseLimitTransport struct {
+	base http.RoundTripper
+	max  int64
+}
+
+func (t *responseLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || t.max <= 0 {
+		return resp, err
+	}
+	if resp.ContentLength > t.max {
+		resp.Body.Close()
+		return nil, bodyTooLargeError(fmt.Sprintf("the response to %s %s is %d bytes, more than the %d bytes allowed by the provider `max_response_bytes`", req.Method, req.URL.Redacted(), resp.ContentLength, t.max))
+	}
+	resp.Body = &limitedBody{
+		ReadCloser: resp.Body,
+		limited:    io.LimitReader(resp.Body, t.max+1),
+		max:        t.max,
+		request:    req.Method + " " + req.URL.Redacted(),
+	}
+	return resp, nil
+}
+
+// limitedBody fails the reads going past the limit of a response body.
+type limitedBody struct {
+	io.ReadCloser
+	limited io.Reader
+	max     int64
+	read    int64
+	request string
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.limited.Read(p)
+	b.read += int64(n)
+	if b.read > b.max {
+		return 0, bodyTooLargeError(fmt.Sprintf("the response to %s is more than the %d bytes allowed by the provider `max_response_bytes`", b.request, b.max))
+	}
+	return n, err
+}
@@ -0,0 +1,70 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResponseLimitTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/endless" {
+			// Without Content-Length, as a streamed body.
+			for i := 0; i < 100; i++ {
+				w.Write([]byte(strings.Repeat("#", 100)))
+				w.(http.Flusher).Flush()
+			}
+			return
+		}
+		w.Write([]byte(strings.Repeat("#", 2000)))
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: &responseLimitTransport{base: http.DefaultTransport, max: 1000}}
+	_, err := httpClient.Get(server.URL + "/sized")
+	if !errors.As(err, new(bodyTooLargeError)) || !strings.Contains(err.Error(), "is 2000 bytes, more than the 1000 bytes") {
+		t.Fatalf("expected the response to be refused, got %v", err)
+	}
+	if retryable(context.Background(), err, nil) {
+		t.Fatal("expected the error not to be retried")
+	}
+
+	resp, err := httpClient.Get(server.URL + "/endless")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	if !errors.As(err, new(bodyTooLargeError)) || !strings.Contains(err.Error(), "more than the 1000 bytes") {
+		t.Fatalf("expected the read to fail, got %v", err)
+	}
+}
+
+func TestRequestSizeLimit(t *testing.T) {
+	fake := newFakeMimirClient()
+	meta := &client{cli: fake}
+	meta.config.maxRequestBytes = 50
+	d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{
+		"namespace":   "demo",
+		"config_yaml": "groups:\n  - name: group\n    rules:\n      - record: up:sum\n        expr: sum(up)\n",
+	})
+	diags := rulerNamespaceCreate(context.Background(), d, meta)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, `the configuration of namespace "demo" is 80 bytes, more than the 50 bytes allowed`) {
+		t.Fatalf("expected the configuration to be refused, got %v", diags)
+	}
+	if len(fake.namespaces) != 0 {
+		t.Fatal("expected nothing to be pushed")
+	}
+
+	cli := newAPIClient(fake, apiClientOptions{maxRequestBytes: 50})
+	err := cli.CreateAlertmanagerConfig(context.Background(), strings.Repeat("#", 51), nil)
+	if !errors.As(err, new(bodyTooLargeError)) {
+		t.Fatalf("expected the alertmanager configuration to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package mimirtool
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// maxChangeSummaryNames bounds the names listed by a change summary, the
+// other ones are only counted.
+const maxChangeSummaryNames = 50
+
+// changeSummary is the JSON document of the planned changes attributes.
+type changeSummary struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+	// Omitted counts the names left out to bound the size of the summary.
+	Omitted int `json:"omitted"`
+}
+
+// changeSummaryDescription documents a planned changes attribute listing the
+// changed objects.
+func changeSummaryDescription(objects string) string {
+	return fmt.Sprintf("JSON summary of the %s added, removed and modified by the planned change of `config_yaml`, for the consumers of `terraform show -json`. It holds the sorted `added`, `removed` and `modified` name lists, bounded to %d names overall, and `omitted`, the number of names left out. Empty when no semantic change is planned.", objects, maxChangeSummaryNames)
+}
+
+// planChangeSummary sets attribute to the summary of the changes between the
+// contents of the old and new config_yaml, as computed by contents.
+func planChangeSummary(d *schema.ResourceDiff, attribute string, contents func(configYAML string) map[string]string) error {
+	if !d.HasChange("config_yaml") {
+		return nil
+	}
+	if !d.NewValueKnown("config_yaml") {
+		return d.SetNewComputed(attribute)
+	}
+	old, new := d.GetChange("config_yaml")
+	return d.SetNew(attribute, newChangeSummary(contents(old.(string)), contents(new.(string))))
+}
+
+// newChangeSummary compares the objects of old and new, by name to their
+// canonical content, and returns the JSON summary of the differences, empty
+// when there is none.
+func newChangeSummary(old, new map[string]string) string {
+	var summary changeSummary
+	for name, content := range new {
+		if previous, ok := old[name]; !ok {
+			summary.Added = append(summary.Added, name)
+		} else if previous != content {
+			summary.Modified = append(summary.Modified, name)
+		}
+	}
+	for name := range old {
+		if _, ok := new[name]; !ok {
+			summary.Removed = append(summary.Removed, name)
+		}
+	}
+	if len(summary.Added)+len(summary.Removed)+len(summary.Modified) == 0 {
+		return ""
+	}
+
+	budget := maxChangeSummaryNames
+	for _, names := range []*[]string{&summary.Added, &summary.Removed, &summary.Modified} {
+		sort.Strings(*names)
+		if len(*names) > budget {
+			summary.Omitted += len(*names) - budget
+			*names = (*names)[:budget]
+		}
+		budget -= len(*names)
+		if *names == nil {
+			*names = []string{}
+		}
+	}
+	res, _ := json.Marshal(summary)
+	return string(res)
+}
+
+// ruleGroupContents returns the canonical content of the groups of a
+// namespace by name.
+func ruleGroupContents(configYAML string) map[string]string {
+	parsed := parseNamespace(configYAML)
+	namespace, err := parsed.decodedNamespace()
+	if err != nil {
+		return nil
+	}
+	fields := parsed.groupFields()
+	res := make(map[string]string, len(namespace.Groups))
+	for _, group := range namespace.Groups {
+		content, _ := groupYAMLWithFields(group, fields[group.Name])
+		res[group.Name] = string(content)
+	}
+	return res
+}
+
+// receiverContents returns the canonical content of the receivers of an
+// alertmanager configuration by name.
+func receiverContents(configYAML string) map[string]string {
+	var cfg struct {
+		Receivers []map[string]any `yaml:"receivers"`
+	}
+	if err := yaml.Unmarshal([]byte(configYAML), &cfg); err != nil {
+		return nil
+	}
+	res := make(map[string]string, len(cfg.Receivers))
+	for _, receiver := range cfg.Receivers {
+		name, _ := receiver["name"].(string)
+		// Maps are marshalled with sorted keys.
+		content, _ := yaml.Marshal(receiver)
+		res[name] = string(content)
+	}
+	return res
+}
+
+// diffContextLines is the number of unchanged lines shown around the changes
+// of a unified diff.
+const diffContextLines = 3
+
+// planContentDiff sets attribute to the unified diff between the canonical
+// contents of the old and new config_yaml, as computed by canonical.
+func planContentDiff(d *schema.ResourceDiff, attribute string, canonical func(configYAML string) string) error {
+	if !d.HasChange("config_yaml") {
+		return nil
+	}
+	if !d.NewValueKnown("config_yaml") {
+		return d.SetNewComputed(attribute)
+	}
+	old, new := d.GetChange("config_yaml")
+	previous := ""
+	// There is nothing to compare to on creation.
+	if d.Id() != "" {
+		previous = canonical(old.(string))
+	}
+	return d.SetNew(attribute, unifiedDiff(previous, canonical(new.(string))))
+}
+
+// unifiedDiff returns the hunks of the line diff between old and new, with
+// diffContextLines lines of context, empty when they are the same.
+func unifiedDiff(old, new string) string {
+	if old == new {
+		return ""
+	}
+	var lines []string
+	if diff := lineDiff(old, new); diff != "" {
+		lines = strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	}
+	// An empty side is a single empty line to lineDiff.
+	if old == "" {
+		lines = slices.DeleteFunc(lines, func(l string) bool { return l == "- " })
+	}
+	if new == "" {
+		lines = slices.DeleteFunc(lines, func(l string) bool { return l == "+ " })
+	}
+
+	var b strings.Builder
+	oldLine, newLine := 1, 1
+	for start := 0; start < len(lines); {
+		// Skip the unchanged lines before the next change.
+		change := start
+		for change < len(lines) && strings.HasPrefix(lines[change], " ") {
+			change++
+		}
+		if change == len(lines) {
+			break
+		}
+		from := max(start, change-diffContextLines)
+		oldLine += from - start
+		newLine += from - start
+		// Extend the hunk while the changes are close enough to share context.
+		end := change
+		for i := change; i < len(lines); i++ {
+			if !strings.HasPrefix(lines[i], " ") {
+				end = i + 1
+			} else if i-end >= 2*diffContextLines {
+				break
+			}
+		}
+		end = min(len(lines), end+diffContextLines)
+
+		oldCount, newCount := 0, 0
+		for _, l := range lines[from:end] {
+			if !strings.HasPrefix(l, "+") {
+				oldCount++
+			}
+			if !strings.HasPrefix(l, "-") {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ %s %s @@\n", hunkRange("-", oldLine, oldCount), hunkRange("+", newLine, newCount))
+		for _, l := range lines[from:end] {
+			// lineDiff prefixes are two characters wide.
+			b.WriteString(l[:1] + l[2:] + "\n")
+		}
+		oldLine += oldCount
+		newLine += newCount
+		start = end
+	}
+	return b.String()
+}
+
+// hunkRange returns the range of a side of a hunk, e.g. "-3,4".
+func hunkRange(side string, line, count int) string {
+	if count == 0 {
+		// Empty ranges refer to the line before.
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%s%d", side, line)
+	}
+	return fmt.Sprintf("%s%d,%d", side, line, count)
+}
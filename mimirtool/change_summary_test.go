@@ -0,0 +1,102 @@
+package mimirtool
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRuleGroupChangeSummary(t *testing.T) {
+	old := normalizeNamespaceYAML(`groups:
+  - name: kept
+    rules:
+      - record: job:up:sum
+        expr: sum by (job) (up)
+  - name: changed
+    rules:
+      - record: job:up:max
+        expr: max by (job) (up)
+  - name: removed
+    rules: []
+`)
+	new := normalizeNamespaceYAML(`groups:
+  - name: kept
+    rules:
+      - record: job:up:sum
+        expr: sum by (job) (up)
+  - name: changed
+    interval: 5m
+    rules:
+      - record: job:up:max
+        expr: max by (job) (up)
+  - name: added
+    rules: []
+`)
+	want := `{"added":["added"],"removed":["removed"],"modified":["changed"],"omitted":0}`
+	if got := newChangeSummary(ruleGroupContents(old), ruleGroupContents(new)); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if got := newChangeSummary(ruleGroupContents(old), ruleGroupContents(old)); got != "" {
+		t.Fatalf("expected an empty summary without change, got %s", got)
+	}
+}
+
+func TestReceiverChangeSummary(t *testing.T) {
+	old := `receivers:
+  - name: kept
+  - name: changed
+    webhook_configs:
+      - url: http://example.org
+`
+	new := `receivers:
+  - name: changed
+    webhook_configs:
+      - url: http://example.com
+  - name: kept
+`
+	want := `{"added":[],"removed":[],"modified":["changed"],"omitted":0}`
+	if got := newChangeSummary(receiverContents(old), receiverContents(new)); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestChangeSummaryBounded(t *testing.T) {
+	new := map[string]string{}
+	for i := 0; i < maxChangeSummaryNames+10; i++ {
+		new[fmt.Sprintf("group-%03d", i)] = ""
+	}
+	got := newChangeSummary(nil, new)
+	if !strings.Contains(got, `"omitted":10`) || strings.Contains(got, fmt.Sprintf("group-%03d", maxChangeSummaryNames)) {
+		t.Fatalf("expected the summary to be bounded, got %s", got)
+	}
+	if newChangeSummary(nil, new) != got {
+		t.Fatal("expected the summary to be deterministic")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	want := `@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`
+	if got := unifiedDiff(old, new); got != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, got)
+	}
+	if got := unifiedDiff("", "a\nb\n"); got != "@@ -0,0 +1,2 @@\n+a\n+b\n" {
+		t.Fatalf("expected only additions, got:\n%s", got)
+	}
+	if got := unifiedDiff(old, old); got != "" {
+		t.Fatalf("expected no diff, got:\n%s", got)
+	}
+}
@@ -0,0 +1,269 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// apiClientOptions are the provider settings applied by apiClient.
+type apiClientOptions struct {
+	// maxConcurrentWrites bounds concurrent write calls, 0 means unlimited.
+	maxConcurrentWrites int
+	// maxWritesPerSecond paces write calls, 0 means unlimited.
+	maxWritesPerSecond int
+	// writes dispatches the write calls, it is shared by the clients of a
+	// provider instance. One is built from the two settings above when nil.
+	writes *writeQueue
+	// tenant is the tenant ID sent to Mimir, used to report tenant errors.
+	tenant string
+	// auth is the authentication mechanism in use, used to report auth errors.
+	auth string
+	// tokenExpiry is the expiry of the static auth token, zero when unknown.
+	tokenExpiry time.Time
+	// verifyTenant reads back every write to make sure it landed under tenant.
+	verifyTenant bool
+	// retry is the retry policy of the operations which don't set one in
+	// their context.
+	retry retryPolicy
+	// timeouts bound the calls.
+	timeouts callTimeouts
+	// maxRequestBytes bounds the content written, 0 means no limit.
+	maxRequestBytes int64
+	// stats accumulates the calls made, a private one is used when nil.
+	stats *apiStats
+}
+
+// apiStats counts the calls made to Mimir, either by all the operations of a
+// provider instance or by a single one.
+type apiStats struct {
+	lists       atomic.Int64
+	gets        atomic.Int64
+	sets        atomic.Int64
+	deletes     atomic.Int64
+	retries     atomic.Int64
+	bytesPushed atomic.Int64
+	// apiTime is the time spent waiting on Mimir, in nanoseconds.
+	apiTime atomic.Int64
+}
+
+// apiCounter selects a counter of apiStats.
+type apiCounter func(*apiStats) *atomic.Int64
+
+var (
+	countLists       apiCounter = func(s *apiStats) *atomic.Int64 { return &s.lists }
+	countGets        apiCounter = func(s *apiStats) *atomic.Int64 { return &s.gets }
+	countSets        apiCounter = func(s *apiStats) *atomic.Int64 { return &s.sets }
+	countDeletes     apiCounter = func(s *apiStats) *atomic.Int64 { return &s.deletes }
+	countRetries     apiCounter = func(s *apiStats) *atomic.Int64 { return &s.retries }
+	countBytesPushed apiCounter = func(s *apiStats) *atomic.Int64 { return &s.bytesPushed }
+	countAPITime     apiCounter = func(s *apiStats) *atomic.Int64 { return &s.apiTime }
+)
+
+type operationStatsKey struct{}
+
+// withOperationStats returns ctx along with the stats counting the calls of
+// its resource operation only.
+func withOperationStats(ctx context.Context) (context.Context, *apiStats) {
+	stats := &apiStats{}
+	return context.WithValue(ctx, operationStatsKey{}, stats), stats
+}
+
+// add adds n to counter of s and to the one of the operation of ctx.
+func (s *apiStats) add(ctx context.Context, counter apiCounter, n int64) {
+	counter(s).Add(n)
+	if operation, ok := ctx.Value(operationStatsKey{}).(*apiStats); ok && operation != s {
+		counter(operation).Add(n)
+	}
+}
+
+// log emits the summary of the calls counted by s.
+func (s *apiStats) log(ctx context.Context) {
+	tflog.Info(ctx, "Grafana Mimir API calls summary", map[string]interface{}{
+		"lists":        s.lists.Load(),
+		"gets":         s.gets.Load(),
+		"sets":         s.sets.Load(),
+		"deletes":      s.deletes.Load(),
+		"retries":      s.retries.Load(),
+		"bytes_pushed": s.bytesPushed.Load(),
+		"api_time":     time.Duration(s.apiTime.Load()).String(),
+	})
+}
+
+// apiClient wraps the mimirtool client with the provider's own policies, so
+// resources don't have to care about them.
+type apiClient struct {
+	mimirClientInterface
+	opts apiClientOptions
+
+	// writes dispatches all the write calls.
+	writes *writeQueue
+}
+
+func newAPIClient(cli mimirClientInterface, opts apiClientOptions) *apiClient {
+	if opts.stats == nil {
+		opts.stats = &apiStats{}
+	}
+	if opts.writes == nil {
+		opts.writes = newWriteQueue(opts.maxConcurrentWrites, opts.maxWritesPerSecond)
+	}
+	return &apiClient{
+		mimirClientInterface: cli,
+		opts:                 opts,
+		writes:               opts.writes,
+	}
+}
+
+// call runs f, the call operation of kind, with the retry policy of ctx and
+// the deadline of kind.
+func (c *apiClient) call(ctx context.Context, kind string, operation string, counter apiCounter, f func(ctx context.Context) error) error {
+	ctx, cancel := c.opts.timeouts.context(ctx, kind)
+	defer cancel()
+	err := retry(ctx, retryPolicyFrom(ctx, c.opts.retry), c.opts.stats, operation, func() error {
+		return c.attempt(ctx, kind, operation, counter, f)
+	})
+	return c.opts.timeouts.describe(ctx, kind, operation, err)
+}
+
+// attempt runs f, an attempt of the call operation of kind, once within the
+// request deadline, counting it with counter and the time spent in it.
+func (c *apiClient) attempt(ctx context.Context, kind string, operation string, counter apiCounter, f func(ctx context.Context) error) error {
+	c.opts.stats.add(ctx, counter, 1)
+	attemptCtx, cancel := c.opts.timeouts.attemptContext(ctx, kind)
+	defer cancel()
+	start := time.Now()
+	err := f(attemptCtx)
+	c.opts.stats.add(ctx, countAPITime, int64(time.Since(start)))
+	return c.opts.timeouts.describeAttempt(ctx, attemptCtx, kind, operation, c.wrapError(err))
+}
+
+// write runs a write operation through the write queue, which is left during
+// the backoff of the retries.
+func (c *apiClient) write(ctx context.Context, kind string, operation string, counter apiCounter, f func(ctx context.Context) error) error {
+	ctx, cancel := c.opts.timeouts.context(ctx, kind)
+	defer cancel()
+	err := retry(ctx, retryPolicyFrom(ctx, c.opts.retry), c.opts.stats, operation, func() error {
+		return c.writes.do(ctx, operation, func() error {
+			return c.attempt(ctx, kind, operation, counter, f)
+		})
+	})
+	return c.opts.timeouts.describe(ctx, kind, operation, err)
+}
+
+// wrapError turns errors whose cause is known into actionable ones.
+func (c *apiClient) wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	err = classifyError(err)
+	if errors.Is(err, ErrUnauthorized) {
+		if !c.opts.tokenExpiry.IsZero() && time.Now().After(c.opts.tokenExpiry) {
+			return fmt.Errorf("the provider `auth_token` expired at %s during the run, use `auth_token_file` to have it refreshed: %w", c.opts.tokenExpiry.Format(time.RFC3339), err)
+		}
+		return describeAuthError(err, c.opts.auth, c.opts.tenant != "")
+	}
+	return err
+}
+
+// errTenantMismatch reports a write which cannot be read back under the
+// configured tenant.
+func (c *apiClient) errTenantMismatch(object string) error {
+	return fmt.Errorf("%s was written but cannot be read back for tenant %q, the gateway may have rewritten or ignored the tenant header", object, c.opts.tenant)
+}
+
+func (c *apiClient) ListRules(ctx context.Context, namespace string) (rules map[string][]rwrulefmt.RuleGroup, err error) {
+	err = c.call(ctx, callRuler, "ListRules", countLists, func(ctx context.Context) error {
+		rules, err = c.mimirClientInterface.ListRules(ctx, namespace)
+		return err
+	})
+	return rules, err
+}
+
+func (c *apiClient) GetRuleGroup(ctx context.Context, namespace string, groupName string) (group *rwrulefmt.RuleGroup, err error) {
+	err = c.call(ctx, callRuler, "GetRuleGroup", countGets, func(ctx context.Context) error {
+		group, err = c.mimirClientInterface.GetRuleGroup(ctx, namespace, groupName)
+		return err
+	})
+	return group, err
+}
+
+func (c *apiClient) GetAlertmanagerConfig(ctx context.Context) (cfg string, templates map[string]string, err error) {
+	err = c.call(ctx, callAlertmanager, "GetAlertmanagerConfig", countGets, func(ctx context.Context) error {
+		cfg, templates, err = c.mimirClientInterface.GetAlertmanagerConfig(ctx)
+		return err
+	})
+	return cfg, templates, err
+}
+
+func (c *apiClient) DeleteRuleGroup(ctx context.Context, namespace string, groupName string) error {
+	return c.write(ctx, callRuler, "DeleteRuleGroup", countDeletes, func(ctx context.Context) error {
+		return c.mimirClientInterface.DeleteRuleGroup(ctx, namespace, groupName)
+	})
+}
+
+func (c *apiClient) DeleteNamespace(ctx context.Context, namespace string) error {
+	return c.write(ctx, callRuler, "DeleteNamespace", countDeletes, func(ctx context.Context) error {
+		return c.mimirClientInterface.DeleteNamespace(ctx, namespace)
+	})
+}
+
+func (c *apiClient) CreateRuleGroup(ctx context.Context, namespace string, rg rwrulefmt.RuleGroup) error {
+	// The client sends the group as YAML, measure it the same way.
+	if body, err := yaml.Marshal(rg); err == nil {
+		if err := checkRequestSize(fmt.Sprintf("rule group %q", rg.Name), len(body), c.opts.maxRequestBytes); err != nil {
+			return err
+		}
+		c.opts.stats.add(ctx, countBytesPushed, int64(len(body)))
+	}
+	err := c.write(ctx, callRuler, "CreateRuleGroup", countSets, func(ctx context.Context) error {
+		return c.mimirClientInterface.CreateRuleGroup(ctx, namespace, rg)
+	})
+	if err != nil || !c.opts.verifyTenant {
+		return err
+	}
+
+	remote, err := c.ListRules(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(remote[namespace], func(g rwrulefmt.RuleGroup) bool { return g.Name == rg.Name }) {
+		return c.errTenantMismatch(fmt.Sprintf("rule group %q of namespace %q", rg.Name, namespace))
+	}
+	return nil
+}
+
+func (c *apiClient) CreateAlertmanagerConfig(ctx context.Context, cfg string, templates map[string]string) error {
+	size := len(cfg)
+	for _, template := range templates {
+		size += len(template)
+	}
+	if err := checkRequestSize("the alertmanager configuration along with its templates", size, c.opts.maxRequestBytes); err != nil {
+		return err
+	}
+	c.opts.stats.add(ctx, countBytesPushed, int64(size))
+	err := c.write(ctx, callAlertmanager, "CreateAlertmanagerConfig", countSets, func(ctx context.Context) error {
+		return c.mimirClientInterface.CreateAlertmanagerConfig(ctx, cfg, templates)
+	})
+	if err != nil || !c.opts.verifyTenant {
+		return err
+	}
+
+	remote, _, err := c.GetAlertmanagerConfig(ctx)
+	if errors.Is(err, ErrNotFound) || (err == nil && remote == "") {
+		return c.errTenantMismatch("the alertmanager configuration")
+	}
+	return err
+}
+
+func (c *apiClient) DeleteAlermanagerConfig(ctx context.Context) error {
+	return c.write(ctx, callAlertmanager, "DeleteAlermanagerConfig", countDeletes, func(ctx context.Context) error {
+		return c.mimirClientInterface.DeleteAlermanagerConfig(ctx)
+	})
+}
@@ -0,0 +1,80 @@
+package mimirtool
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/sirupsen/logrus"
+)
+
+// clientLogSubsystem is the tflog subsystem of the logs of the mimirtool
+// client, its level can be set apart with TF_LOG_PROVIDER_MIMIRTOOL_CLIENT.
+const clientLogSubsystem = "mimirtool.client"
+
+// clientLogHook forwards the logs of the mimirtool client, emitted through the
+// logrus standard logger, to the tflog subsystem.
+type clientLogHook struct {
+	mu sync.RWMutex
+	// ctx carries the subsystem logger. The client doesn't pass the context of
+	// the operation along its logs, so it is the one of the provider
+	// configuration.
+	ctx context.Context
+}
+
+var (
+	clientLogs            = &clientLogHook{}
+	installClientLogsOnce sync.Once
+)
+
+// routeClientLogs sends the logs of the mimirtool client to the tflog
+// subsystem of ctx, rather than to stderr where Terraform doesn't filter them.
+// Their messages and fields are masked where they match patterns.
+func routeClientLogs(ctx context.Context, patterns []*regexp.Regexp) {
+	installClientLogsOnce.Do(func() {
+		logrus.AddHook(clientLogs)
+		logrus.SetOutput(io.Discard)
+		// Filtering is left to tflog.
+		logrus.SetLevel(logrus.TraceLevel)
+	})
+	clientLogs.mu.Lock()
+	defer clientLogs.mu.Unlock()
+	ctx = tflog.NewSubsystem(ctx, clientLogSubsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_MIMIRTOOL_CLIENT"))
+	clientLogs.ctx = tflog.SubsystemMaskLogRegexes(ctx, clientLogSubsystem, patterns...)
+}
+
+func (h *clientLogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *clientLogHook) Fire(entry *logrus.Entry) error {
+	h.mu.RLock()
+	ctx := h.ctx
+	h.mu.RUnlock()
+	if ctx == nil {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(entry.Data))
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		fields[k] = v
+	}
+	switch entry.Level {
+	case logrus.TraceLevel:
+		tflog.SubsystemTrace(ctx, clientLogSubsystem, entry.Message, fields)
+	case logrus.DebugLevel:
+		tflog.SubsystemDebug(ctx, clientLogSubsystem, entry.Message, fields)
+	case logrus.InfoLevel:
+		tflog.SubsystemInfo(ctx, clientLogSubsystem, entry.Message, fields)
+	case logrus.WarnLevel:
+		tflog.SubsystemWarn(ctx, clientLogSubsystem, entry.Message, fields)
+	default:
+		tflog.SubsystemError(ctx, clientLogSubsystem, entry.Message, fields)
+	}
+	return nil
+}
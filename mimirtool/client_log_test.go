@@ -0,0 +1,44 @@
+package mimirtool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRouteClientLogs(t *testing.T) {
+	var output bytes.Buffer
+	routeClientLogs(tflogtest.RootLogger(context.Background(), &output), defaultSensitivePatterns)
+	defer func() {
+		clientLogs.mu.Lock()
+		clientLogs.ctx = nil
+		clientLogs.mu.Unlock()
+	}()
+
+	logrus.WithFields(logrus.Fields{"method": "GET", "err": errors.New("boom")}).Debugln("sending request to Grafana Mimir API")
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one log entry, got %v", entries)
+	}
+	entry := entries[0]
+	if entry["@module"] != "provider."+clientLogSubsystem || entry["@level"] != "debug" || entry["err"] != "boom" ||
+		!strings.HasPrefix(entry["@message"].(string), "sending request") {
+		t.Fatalf("unexpected log entry: %v", entry)
+	}
+
+	// The client logs are masked as the provider ones are.
+	output.Reset()
+	logrus.WithField("body", "api_key: leaked").Warnln("request failed, api_key: leaked")
+	if strings.Contains(output.String(), "leaked") || !strings.Contains(output.String(), "request failed") {
+		t.Fatalf("expected the client log to be masked, got %s", output.String())
+	}
+}
@@ -0,0 +1,310 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+)
+
+// fakeMimirClient is an in-memory mimirClientInterface used by unit tests.
+type fakeMimirClient struct {
+	mu         sync.Mutex
+	namespaces map[string][]rwrulefmt.RuleGroup
+	// alertmanagerConfig and templates hold the alertmanager configuration.
+	alertmanagerConfig string
+	templates          map[string]string
+
+	// onWrite, when set, is called at the start of every write call.
+	onWrite func()
+	// dropWrites discards rule groups writes, as if they landed in another tenant.
+	dropWrites bool
+	// err, when set, is returned by every call.
+	err error
+	// groupErrs fails the writes of the rule groups by name.
+	groupErrs map[string]error
+	// staleReads is the number of ListRules calls answering an empty tenant,
+	// as a ruler replica which hasn't synced yet.
+	staleReads int
+}
+
+func newFakeMimirClient() *fakeMimirClient {
+	return &fakeMimirClient{namespaces: map[string][]rwrulefmt.RuleGroup{}}
+}
+
+func (f *fakeMimirClient) write() {
+	if f.onWrite != nil {
+		f.onWrite()
+	}
+}
+
+func (f *fakeMimirClient) DeleteRuleGroup(ctx context.Context, namespace string, groupName string) error {
+	f.write()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	groups := f.namespaces[namespace][:0]
+	for _, g := range f.namespaces[namespace] {
+		if g.Name != groupName {
+			groups = append(groups, g)
+		}
+	}
+	f.namespaces[namespace] = groups
+	return nil
+}
+
+func (f *fakeMimirClient) ListRules(ctx context.Context, namespace string) (map[string][]rwrulefmt.RuleGroup, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	res := map[string][]rwrulefmt.RuleGroup{}
+	if f.staleReads > 0 {
+		f.staleReads--
+		return res, nil
+	}
+	for ns, groups := range f.namespaces {
+		if namespace == "" || ns == namespace {
+			// Copied as a fresh response would be, reads may modify it.
+			res[ns] = copyRuleNamespace(rules.RuleNamespace{Groups: groups}).Groups
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeMimirClient) GetRuleGroup(ctx context.Context, namespace string, groupName string) (*rwrulefmt.RuleGroup, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, g := range f.namespaces[namespace] {
+		if g.Name == groupName {
+			group := copyRuleNamespace(rules.RuleNamespace{Groups: []rwrulefmt.RuleGroup{g}}).Groups[0]
+			return &group, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (f *fakeMimirClient) DeleteNamespace(ctx context.Context, namespace string) error {
+	f.write()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.namespaces, namespace)
+	return nil
+}
+
+func (f *fakeMimirClient) CreateRuleGroup(ctx context.Context, namespace string, rg rwrulefmt.RuleGroup) error {
+	f.write()
+	// As the request of the real client would.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.err != nil || f.dropWrites {
+		return f.err
+	}
+	if err := f.groupErrs[rg.Name]; err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, g := range f.namespaces[namespace] {
+		if g.Name == rg.Name {
+			f.namespaces[namespace][i] = rg
+			return nil
+		}
+	}
+	f.namespaces[namespace] = append(f.namespaces[namespace], rg)
+	return nil
+}
+
+func (f *fakeMimirClient) CreateAlertmanagerConfig(ctx context.Context, cfg string, templates map[string]string) error {
+	f.write()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.alertmanagerConfig, f.templates = cfg, templates
+	return nil
+}
+
+func (f *fakeMimirClient) GetAlertmanagerConfig(ctx context.Context) (string, map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.alertmanagerConfig == "" {
+		return "", nil, ErrNotFound
+	}
+	return f.alertmanagerConfig, f.templates, nil
+}
+
+func (f *fakeMimirClient) DeleteAlermanagerConfig(ctx context.Context) error {
+	f.write()
+	return nil
+}
+
+func TestAPIClientLimitsConcurrentWrites(t *testing.T) {
+	var current, peak int32
+	fake := newFakeMimirClient()
+	fake.onWrite = func() {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+	}
+
+	c := newAPIClient(fake, apiClientOptions{maxConcurrentWrites: 2})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.DeleteNamespace(context.Background(), "demo"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Fatalf("expected at most 2 concurrent writes, got %d", peak)
+	}
+}
+
+func TestAPIClientsShareWriteQueue(t *testing.T) {
+	var current, peak int32
+	fake := newFakeMimirClient()
+	fake.onWrite = func() {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+	}
+
+	writes := newWriteQueue(2, 0)
+	clients := []*apiClient{
+		newAPIClient(fake, apiClientOptions{tenant: "team-a", writes: writes}),
+		newAPIClient(fake, apiClientOptions{tenant: "team-b", writes: writes}),
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(c *apiClient) {
+			defer wg.Done()
+			if err := c.DeleteNamespace(context.Background(), "demo"); err != nil {
+				t.Error(err)
+			}
+		}(clients[i%2])
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Fatalf("expected at most 2 concurrent writes across the tenants, got %d", peak)
+	}
+}
+
+func TestAPIClientVerifyTenant(t *testing.T) {
+	group := rwrulefmt.RuleGroup{}
+	group.Name = "demo"
+
+	c := newAPIClient(newFakeMimirClient(), apiClientOptions{tenant: "team-a", verifyTenant: true})
+	if err := c.CreateRuleGroup(context.Background(), "demo", group); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	fake := newFakeMimirClient()
+	fake.dropWrites = true
+	c = newAPIClient(fake, apiClientOptions{tenant: "team-a", verifyTenant: true})
+	err := c.CreateRuleGroup(context.Background(), "demo", group)
+	if err == nil || !strings.Contains(err.Error(), `tenant "team-a"`) {
+		t.Fatalf("expected a tenant mismatch error, got: %v", err)
+	}
+}
+
+func TestAPIClientMissingTenant(t *testing.T) {
+	fake := newFakeMimirClient()
+	fake.err = errors.New(`server returned HTTP status: 401 Unauthorized, body: "no org id\n"`)
+
+	_, err := newAPIClient(fake, apiClientOptions{}).ListRules(context.Background(), "demo")
+	if err == nil || !strings.Contains(err.Error(), "tenant_id") {
+		t.Fatalf("expected an error pointing at tenant_id, got: %v", err)
+	}
+}
+
+func TestAPIClientStats(t *testing.T) {
+	stats := &apiStats{}
+	c := newAPIClient(newFakeMimirClient(), apiClientOptions{stats: stats})
+
+	group := rwrulefmt.RuleGroup{}
+	group.Name = "demo"
+	ctx := context.Background()
+	if err := c.CreateRuleGroup(ctx, "demo", group); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListRules(ctx, "demo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CreateAlertmanagerConfig(ctx, "route: {}", map[string]string{"t": "tmpl"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteNamespace(ctx, "demo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.sets.Load() != 2 || stats.lists.Load() != 1 || stats.deletes.Load() != 1 || stats.gets.Load() != 0 {
+		t.Fatalf("unexpected counters: sets=%d lists=%d deletes=%d gets=%d",
+			stats.sets.Load(), stats.lists.Load(), stats.deletes.Load(), stats.gets.Load())
+	}
+	if stats.bytesPushed.Load() <= int64(len("route: {}")+len("tmpl")) {
+		t.Fatalf("expected the rule group and alertmanager configuration to be counted, got %d bytes", stats.bytesPushed.Load())
+	}
+}
+
+func TestAPIClientOperationStats(t *testing.T) {
+	stats := &apiStats{}
+	c := newAPIClient(newFakeMimirClient(), apiClientOptions{stats: stats})
+
+	first, firstStats := withOperationStats(context.Background())
+	second, secondStats := withOperationStats(context.Background())
+	if _, err := c.ListRules(first, "demo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteNamespace(second, "demo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if firstStats.lists.Load() != 1 || firstStats.deletes.Load() != 0 {
+		t.Fatalf("expected the first operation to count its list only, got lists=%d deletes=%d", firstStats.lists.Load(), firstStats.deletes.Load())
+	}
+	if secondStats.lists.Load() != 0 || secondStats.deletes.Load() != 1 {
+		t.Fatalf("expected the second operation to count its delete only, got lists=%d deletes=%d", secondStats.lists.Load(), secondStats.deletes.Load())
+	}
+	if stats.lists.Load() != 1 || stats.deletes.Load() != 1 {
+		t.Fatalf("expected the provider to count both calls, got lists=%d deletes=%d", stats.lists.Load(), stats.deletes.Load())
+	}
+}
+
+func TestAPIClientExpiredToken(t *testing.T) {
+	fake := newFakeMimirClient()
+	fake.err = errors.New(`server returned HTTP status: 401 Unauthorized, body: "token expired\n"`)
+	expiry := time.Now().Add(-time.Minute)
+
+	_, err := newAPIClient(fake, apiClientOptions{auth: authBearer, tokenExpiry: expiry}).ListRules(context.Background(), "demo")
+	if err == nil || !strings.Contains(err.Error(), "expired at "+expiry.Format(time.RFC3339)) {
+		t.Fatalf("expected an error telling when the token expired, got: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package mimirtool
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// clockSkewErrorRegexp matches the errors of tokens rejected because of their
+// time claims, as worded by the usual JWT libraries. The validity wordings
+// must be about the token, so that e.g. an invalid tenant doesn't match.
+var clockSkewErrorRegexp = regexp.MustCompile(`(?i)\b(token|jwt)\b.{0,20}?\b(not (yet )?valid( yet)?|not active|used before issued|issued in the future|(is |has )?expired)|\b(exp|nbf|iat)\b['"]? claim|signature has expired`)
+
+// clockSkewErrorBytes is how much of the body of the authentication errors is
+// matched against clockSkewErrorRegexp.
+const clockSkewErrorBytes = 4096
+
+// clockSkewErrorExpired matches the errors of expired tokens among the ones of
+// clockSkewErrorRegexp.
+var clockSkewErrorExpired = regexp.MustCompile(`(?i)expir`)
+
+// clockSkewTransport retries once, after waiting for tolerance, the requests
+// whose token is rejected because of its time claims, which happens when the
+// clocks of the runner and the token issuer drift apart. source, when set, is
+// refreshed before retrying requests rejected for an expired token.
+type clockSkewTransport struct {
+	base      http.RoundTripper
+	tolerance time.Duration
+	source    *cachedTokenSource
+	// after is time.After, overridden by tests.
+	after func(time.Duration) <-chan time.Time
+}
+
+func (t *clockSkewTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return resp, err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, clockSkewErrorBytes))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = prefixedBody{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	if !clockSkewErrorRegexp.Match(body) {
+		return resp, nil
+	}
+
+	expired := clockSkewErrorExpired.Match(body)
+	tflog.Warn(req.Context(), "Token rejected for its time claims, retrying once within the clock skew tolerance", map[string]interface{}{
+		"tolerance": t.tolerance.String(),
+		"expired":   expired,
+	})
+	if expired && t.source != nil {
+		t.source.invalidate()
+	}
+	var wait <-chan time.Time
+	if t.after != nil {
+		wait = t.after(t.tolerance)
+	} else {
+		timer := time.NewTimer(t.tolerance)
+		defer timer.Stop()
+		wait = timer.C
+	}
+	select {
+	case <-wait:
+	case <-req.Context().Done():
+		resp.Body.Close()
+		return nil, req.Context().Err()
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+	return t.base.RoundTrip(retry)
+}
+
+// prefixedBody is a response body whose first bytes were read beforehand.
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
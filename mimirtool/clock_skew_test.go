@@ -0,0 +1,153 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClockSkewTransport(t *testing.T) {
+	var calls int
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch {
+		case r.URL.Path == "/denied":
+			http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		case r.URL.Path == "/large":
+			http.Error(w, strings.Repeat("x", 2*clockSkewErrorBytes)+" token is not valid yet", http.StatusForbidden)
+		case calls == 1 || r.URL.Path == "/skewed":
+			http.Error(w, "authentication failed: token is not valid yet", http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	source := &cachedTokenSource{now: time.Now, fetch: func() (string, time.Time, error) {
+		return "token", time.Time{}, nil
+	}}
+	var slept []time.Duration
+	client := &http.Client{Transport: &clockSkewTransport{
+		base:      &tokenTransport{base: http.DefaultTransport, source: source},
+		tolerance: 30 * time.Second,
+		source:    source,
+		after: func(d time.Duration) <-chan time.Time {
+			slept = append(slept, d)
+			elapsed := make(chan time.Time, 1)
+			elapsed <- time.Now()
+			return elapsed
+		},
+	}}
+
+	resp, err := client.Post(server.URL+"/rules", "application/yaml", strings.NewReader("groups: []"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 2 || bodies[1] != "groups: []" {
+		t.Fatalf("expected the request to be retried with its body, got %d after %d calls, bodies %q", resp.StatusCode, calls, bodies)
+	}
+	if len(slept) != 1 || slept[0] != 30*time.Second {
+		t.Errorf("expected a wait of the tolerance, got %v", slept)
+	}
+
+	// Retried once only, and not for other authentication errors.
+	for path, want := range map[string]int{"/skewed": 2, "/denied": 1} {
+		calls = 0
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized || calls != want {
+			t.Errorf("%s: expected %d calls, got %d with status %d", path, want, calls, resp.StatusCode)
+		}
+	}
+
+	// Only the start of the body is matched, the caller still reads it whole.
+	calls = 0
+	resp, err = client.Get(server.URL + "/large")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if calls != 1 || len(body) <= 2*clockSkewErrorBytes {
+		t.Errorf("expected a single call returning the whole body, got %d calls and %d bytes", calls, len(body))
+	}
+}
+
+func TestClockSkewErrorRegexp(t *testing.T) {
+	for message, want := range map[string]bool{
+		"token is not valid yet":                           true,
+		"token has invalid claims: token is expired":       true,
+		"Token used before issued":                         true,
+		"jwt expired":                                      true,
+		"jwt not active":                                   true,
+		"The token is not yet valid (iat)":                 true,
+		"Signature has expired":                            true,
+		`invalid "exp" claim`:                              true,
+		"the X-Scope-OrgID header is not valid":            false,
+		"invalid credentials":                              false,
+		"no org id: the tenant is not valid for this user": false,
+		"token is malformed":                               false,
+	} {
+		if got := clockSkewErrorRegexp.MatchString(message); got != want {
+			t.Errorf("%q: expected a match to be %v, got %v", message, want, got)
+		}
+	}
+}
+
+func TestClockSkewTransportCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "authentication failed: token is not valid yet", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &http.Client{Transport: &clockSkewTransport{
+		base:      http.DefaultTransport,
+		tolerance: time.Hour,
+		after: func(time.Duration) <-chan time.Time {
+			cancel()
+			return nil
+		},
+	}}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the wait to end with the request, got %v", err)
+	}
+}
+
+func TestCachedTokenSourceLeeway(t *testing.T) {
+	now := time.Now()
+	fetches := 0
+	source := &cachedTokenSource{now: func() time.Time { return now }, leeway: time.Minute, fetch: func() (string, time.Time, error) {
+		fetches++
+		return "token", now.Add(90 * time.Second), nil
+	}}
+	for i := 0; i < 2; i++ {
+		if _, err := source.Token(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Expiring within the refresh margin and the leeway, the token is
+	// fetched each time.
+	if fetches != 2 {
+		t.Errorf("expected the token to be refreshed within the leeway, got %d fetches", fetches)
+	}
+
+	source.leeway = 0
+	source.Token()
+	source.invalidate()
+	source.Token()
+	if fetches != 3 {
+		t.Errorf("expected an invalidated token to be fetched again, got %d fetches", fetches)
+	}
+}
@@ -1,13 +1,73 @@
 package mimirtool
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const (
+	// missingResourceRecreate removes a resource not found on refresh from the
+	// state, so that the next apply recreates it.
+	missingResourceRecreate = "recreate"
+	// missingResourceError fails the refresh of a resource not found.
+	missingResourceError = "error"
 )
 
+func missingResourceBehaviorSchema() *schema.Schema {
+	return &schema.Schema{
+		Description:  "What to do when the resource is not found in Grafana Mimir on refresh: `recreate` removes it from the state so that it gets recreated, `error` fails the plan.",
+		Type:         schema.TypeString,
+		Optional:     true,
+		Default:      missingResourceRecreate,
+		ValidateFunc: validation.StringInSlice([]string{missingResourceRecreate, missingResourceError}, false),
+	}
+}
+
+// handleMissingResource applies the missing_resource_behavior of a resource not
+// found in Mimir on refresh.
+func handleMissingResource(ctx context.Context, d *schema.ResourceData, object string) diag.Diagnostics {
+	// The attribute is not set yet when importing, keep the default behavior.
+	if d.Get("missing_resource_behavior").(string) == missingResourceError {
+		return diag.FromErr(fmt.Errorf("%s was not found in Grafana Mimir, it may have been deleted out of band", object))
+	}
+	tflog.Info(ctx, "Resource not found in Grafana Mimir, removing it from the state", map[string]interface{}{
+		"object": object,
+	})
+	d.SetId("")
+	return nil
+}
+
+// setImportDefaults sets the attributes of s having a default to it on the
+// imported resource of d. They are not read from Mimir, without them the
+// first plan would set them even though the configuration leaves them unset.
+func setImportDefaults(d *schema.ResourceData, s map[string]*schema.Schema) {
+	for k, v := range s {
+		if v.Default != nil {
+			d.Set(k, v.Default)
+		}
+	}
+}
+
+// hash returns the SHA-256 of s. It is streamed through a small buffer, as s
+// may be a namespace of tens of megabytes.
 func hash(s string) string {
-	sha := sha256.Sum256([]byte(s))
-	return hex.EncodeToString(sha[:])
+	h := sha256.New()
+	var buf [32 << 10]byte
+	for len(s) > 0 {
+		n := copy(buf[:], s)
+		h.Write(buf[:n])
+		s = s[n:]
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 func stringValueMap(src map[string]interface{}) map[string]string {
@@ -19,3 +79,54 @@ func stringValueMap(src map[string]interface{}) map[string]string {
 	}
 	return dst
 }
+
+// readAfterWriteTimeout bounds how long a write is waited for to become
+// visible before reading it back.
+var readAfterWriteTimeout = 10 * time.Second
+
+// waitUntil polls visible with a growing interval until it returns true or
+// timeout elapses, it reports whether visible returned true.
+func waitUntil(ctx context.Context, timeout time.Duration, visible func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	interval := 100 * time.Millisecond
+	for {
+		if visible() {
+			return true
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return false
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return false
+		}
+		if interval < time.Second {
+			interval *= 2
+		}
+	}
+}
+
+func expandStringList(src []interface{}) []string {
+	dst := make([]string, 0, len(src))
+	for _, val := range src {
+		if val, ok := val.(string); ok {
+			dst = append(dst, val)
+		}
+	}
+	return dst
+}
+
+func validateDuration(value any, k cty.Path) diag.Diagnostics {
+	if _, err := time.ParseDuration(value.(string)); err != nil {
+		return diag.Diagnostics{
+			diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid duration.",
+				Detail:        err.Error(),
+				AttributePath: k,
+			},
+		}
+	}
+	return nil
+}
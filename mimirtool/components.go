@@ -0,0 +1,121 @@
+package mimirtool
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// mimirComponents are the components of Mimir whose API the provider uses,
+// along with their routes.
+var mimirComponents = []struct {
+	name   string
+	routes []string
+}{
+	{"ruler", []string{"/config/v1/rules", "/api/v1/rules"}},
+	{"Alertmanager", []string{"/api/v1/alerts", "/alertmanager"}},
+}
+
+// componentError reports an API of Mimir which is not exposed at the address
+// of the provider.
+type componentError struct {
+	component string
+	// url is the URL tried and prefix the part of its path before the route
+	// of the component.
+	url    string
+	prefix string
+	// reachable tells whether Mimir answers at the address, in which case the
+	// component is not enabled rather than the prefix wrong.
+	reachable bool
+	// detail is the response of Mimir, when it tells why.
+	detail string
+}
+
+func (e *componentError) Error() string {
+	if !e.reachable {
+		return fmt.Sprintf("no Grafana Mimir API was found at %s: check the path prefix %q, made of the path of the provider `address` and, for the ruler, of `prometheus_http_prefix`", e.url, e.prefix)
+	}
+	msg := fmt.Sprintf("the target Mimir does not expose the %s API, is the component enabled? %s was not found with the path prefix %q while Mimir answers at the address", e.component, e.url, e.prefix)
+	if e.detail != "" {
+		msg += ": " + e.detail
+	}
+	return msg
+}
+
+// componentTransport turns the responses telling that the API of a component
+// doesn't exist, which the mimirtool client reports as any missing object,
+// into componentError errors.
+type componentTransport struct {
+	base http.RoundTripper
+	// basePath is the path of the address of Mimir.
+	basePath string
+}
+
+func (t *componentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusNotFound && resp.StatusCode != http.StatusPreconditionFailed) {
+		return resp, err
+	}
+	component, prefix := t.component(req.URL.Path)
+	if component == "" {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(strings.NewReader(string(body)))
+	detail := strings.TrimSpace(string(body))
+	e := &componentError{component: component, url: req.URL.Redacted(), prefix: prefix}
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed && strings.Contains(detail, "the Alertmanager is not configured"):
+		e.reachable, e.detail = true, detail
+	case resp.StatusCode == http.StatusNotFound && isRouteNotFound(detail):
+		e.reachable = t.reachable(req)
+	default:
+		// An object of the API which doesn't exist.
+		return resp, nil
+	}
+	return nil, e
+}
+
+// component returns the component of Mimir serving path, along with the
+// prefix of its route.
+func (t *componentTransport) component(path string) (string, string) {
+	for _, c := range mimirComponents {
+		for _, route := range c.routes {
+			if i := strings.Index(path, route); i >= 0 {
+				return c.name, path[:i]
+			}
+		}
+	}
+	return "", ""
+}
+
+// isRouteNotFound tells whether the body of a 404 response is the one of a
+// router which doesn't know the path, the one of Mimir or the HTML page of a
+// proxy, rather than an error of the API.
+func isRouteNotFound(body string) bool {
+	return body == "404 page not found" || strings.Contains(strings.ToLower(body), "<html")
+}
+
+// reachable tells whether Mimir answers at the address of req, probing the
+// readiness endpoint every component exposes.
+func (t *componentTransport) reachable(req *http.Request) bool {
+	probe, err := http.NewRequestWithContext(req.Context(), http.MethodGet, req.URL.Scheme+"://"+req.URL.Host+strings.TrimSuffix(t.basePath, "/")+"/ready", nil)
+	if err != nil {
+		return false
+	}
+	probe.Header = req.Header.Clone()
+	resp, err := t.base.RoundTrip(probe)
+	if err != nil {
+		return false
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	// Components which are not ready yet answer 503.
+	return resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusServiceUnavailable
+}
@@ -0,0 +1,96 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newMimirFixture returns a Mimir serving under prefix, without the routes of
+// the disabled components. Its existing objects are listed by objects.
+func newMimirFixture(prefix string, disabled string, objects map[string]string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(prefix+"/ready", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ready"))
+	})
+	handle := func(w http.ResponseWriter, r *http.Request) {
+		if body, ok := objects[strings.TrimPrefix(r.URL.Path, prefix)]; ok {
+			w.Write([]byte(body))
+			return
+		}
+		http.Error(w, "no rule groups found", http.StatusNotFound)
+	}
+	if disabled != "ruler" {
+		mux.HandleFunc(prefix+"/prometheus/config/v1/rules/", handle)
+	}
+	if disabled != "Alertmanager" {
+		mux.HandleFunc(prefix+"/api/v1/alerts", func(w http.ResponseWriter, r *http.Request) {
+			if body, ok := objects["/api/v1/alerts"]; ok {
+				w.Write([]byte(body))
+				return
+			}
+			http.Error(w, "alertmanager storage object not found", http.StatusNotFound)
+		})
+		mux.HandleFunc(prefix+"/alertmanager/", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "the Alertmanager is not configured", http.StatusPreconditionFailed)
+		})
+	}
+	return httptest.NewServer(mux)
+}
+
+func TestComponentTransport(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// prefix is the one Mimir serves under, address the one configured.
+		prefix, address string
+		disabled        string
+		path            string
+		// component is the one reported missing, if any, and reachable
+		// whether the address is right.
+		component string
+		reachable bool
+		prefixed  string
+		// status is the one of the response otherwise.
+		status int
+	}{
+		{name: "ruler disabled", disabled: "ruler", path: "/prometheus/config/v1/rules/demo", component: "ruler", reachable: true, prefixed: "/prometheus"},
+		{name: "alertmanager disabled", disabled: "Alertmanager", path: "/api/v1/alerts", component: "Alertmanager", reachable: true},
+		{name: "alertmanager not configured", path: "/alertmanager/api/v2/status", component: "Alertmanager", reachable: true},
+		{name: "ruler prefix wrong", prefix: "/mimir", address: "/observability", path: "/prometheus/config/v1/rules/demo", component: "ruler", prefixed: "/observability/prometheus"},
+		{name: "alertmanager prefix wrong", prefix: "/mimir", address: "/observability", path: "/api/v1/alerts", component: "Alertmanager", prefixed: "/observability"},
+		{name: "namespace missing", path: "/prometheus/config/v1/rules/demo", status: http.StatusNotFound},
+		{name: "alertmanager configuration missing", path: "/api/v1/alerts", status: http.StatusNotFound},
+		{name: "namespace found", path: "/prometheus/config/v1/rules/found", status: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newMimirFixture(tc.prefix, tc.disabled, map[string]string{"/prometheus/config/v1/rules/found": "demo: []"})
+			defer server.Close()
+
+			httpClient := &http.Client{Transport: &componentTransport{base: http.DefaultTransport, basePath: tc.address}}
+			resp, err := httpClient.Get(server.URL + tc.address + tc.path)
+			var missing *componentError
+			if tc.component == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != tc.status {
+					t.Fatalf("expected the response of the API to be kept, got %s", resp.Status)
+				}
+				return
+			}
+			if !errors.As(err, &missing) {
+				t.Fatalf("expected a component error, got %v", err)
+			}
+			if missing.component != tc.component || missing.reachable != tc.reachable || missing.prefix != tc.prefixed {
+				t.Fatalf("expected the %s to be reported with reachable %t and prefix %q, got %+v", tc.component, tc.reachable, tc.prefixed, missing)
+			}
+			if !strings.Contains(err.Error(), server.URL+tc.address+tc.path) || retryable(context.Background(), err, nil) {
+				t.Fatalf("expected the URL tried in a permanent error, got %s", err)
+			}
+		})
+	}
+}
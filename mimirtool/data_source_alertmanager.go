@@ -0,0 +1,55 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceAlertmanager() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Reads the Alertmanager configuration of the provider tenant, e.g. one edited through the API outside of Terraform, without managing it. The attributes are empty when no configuration is set.
+`,
+
+		ReadContext: alertmanagerDataSourceRead,
+
+		Schema: map[string]*schema.Schema{
+			"config_yaml": {
+				Description: "The Alertmanager configuration. Sensitive, as it may hold the credentials of the receivers.",
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"templates": {
+				Description: "The templates of the configuration, by name.",
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func alertmanagerDataSourceRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	client, err := c.mimirClient("mimirtool_alertmanager")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	tenant := c.config.ID
+	if tenant == "" {
+		tenant = "anonymous"
+	}
+	d.SetId(hash(c.config.Address + "/" + tenant))
+
+	cfg, templates, err := client.GetAlertmanagerConfig(ctx)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return diag.FromErr(err)
+	}
+	d.Set("config_yaml", cfg)
+	d.Set("templates", templates)
+	return nil
+}
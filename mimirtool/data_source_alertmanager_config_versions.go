@@ -0,0 +1,97 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceAlertmanagerConfigVersions() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Lists the versions of the Alertmanager configuration of the tenant, e.g. to diff them against the current one when auditing a change.
+
+Grafana Mimir doesn't retain the previous versions of the configuration nor its upload time: ` + "`versions`" + ` only holds the current one, without ` + "`created_at`" + `, and ` + "`versioned`" + ` is ` + "`false`" + `. Keep the configurations in version control for their history.
+`,
+
+		ReadContext: alertmanagerConfigVersionsRead,
+
+		Schema: map[string]*schema.Schema{
+			"include_content": {
+				Description: "Export the content of the versions, rather than only their hash.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"versioned": {
+				Description: "Whether Grafana Mimir retains the previous versions of the configuration.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"versions": {
+				Description: "The versions of the configuration, the most recent first. Empty when no configuration is set.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"created_at": {
+							Description: "The RFC 3339 upload time of the version, empty when not known.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"content_sha256": {
+							Description: "SHA-256 of the configuration and its templates, as the `content_sha256` of `mimirtool_alertmanager`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"config_yaml": {
+							Description: "The configuration, with `include_content`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"templates_config_yaml": {
+							Description: "The templates of the configuration, with `include_content`.",
+							Type:        schema.TypeMap,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func alertmanagerConfigVersionsRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	client, err := c.mimirClient("mimirtool_alertmanager_config_versions")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	tenant := c.config.ID
+	if tenant == "" {
+		tenant = "anonymous"
+	}
+	d.SetId(hash(c.config.Address + "/" + tenant))
+	d.Set("versioned", false)
+
+	versions := []interface{}{}
+	cfg, templates, err := client.GetAlertmanagerConfig(ctx)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return diag.FromErr(err)
+	}
+	if err == nil {
+		version := map[string]interface{}{
+			"content_sha256": alertmanagerContentHash(cfg, templates),
+		}
+		if d.Get("include_content").(bool) {
+			version["config_yaml"] = cfg
+			version["templates_config_yaml"] = templates
+		}
+		versions = append(versions, version)
+	}
+	d.Set("versions", versions)
+	return nil
+}
@@ -0,0 +1,38 @@
+package mimirtool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAlertmanagerConfigVersionsRead(t *testing.T) {
+	fake := newFakeMimirClient()
+	meta := &client{cli: fake}
+
+	d := schema.TestResourceDataRaw(t, dataSourceAlertmanagerConfigVersions().Schema, map[string]interface{}{})
+	if diags := alertmanagerConfigVersionsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("versions.#") != 0 {
+		t.Fatalf("expected no version without configuration, got %v", d.Get("versions"))
+	}
+
+	templates := map[string]string{"default.tmpl": `{{ define "title" }}Alert{{ end }}`}
+	fake.CreateAlertmanagerConfig(context.Background(), "route:\n  receiver: default\n", templates)
+	for _, includeContent := range []bool{false, true} {
+		d := schema.TestResourceDataRaw(t, dataSourceAlertmanagerConfigVersions().Schema, map[string]interface{}{
+			"include_content": includeContent,
+		})
+		if diags := alertmanagerConfigVersionsRead(context.Background(), d, meta); diags.HasError() {
+			t.Fatal(diags)
+		}
+		if d.Get("versions.#") != 1 || d.Get("versions.0.content_sha256") != alertmanagerContentHash("route:\n  receiver: default\n", templates) {
+			t.Fatalf("expected the current version, got %v", d.Get("versions"))
+		}
+		if got := d.Get("versions.0.config_yaml") != ""; got != includeContent {
+			t.Fatalf("include_content=%t: unexpected content %q", includeContent, d.Get("versions.0.config_yaml"))
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+)
+
+// sampleAlertName is the alertname of the sample alerts which don't set one.
+const sampleAlertName = "SampleAlert"
+
+// defaultNotificationFields lists the templated fields Alertmanager fills in
+// when the integrations leave them unset, with their default value.
+var defaultNotificationFields = map[string]map[string]string{
+	"slack_configs": {
+		"title":    `{{ template "slack.default.title" . }}`,
+		"text":     `{{ template "slack.default.text" . }}`,
+		"fallback": `{{ template "slack.default.fallback" . }}`,
+	},
+	"email_configs": {
+		"headers.Subject": `{{ template "email.default.subject" . }}`,
+		"html":            `{{ template "email.default.html" . }}`,
+	},
+	"pagerduty_configs": {
+		"description": `{{ template "pagerduty.default.description" . }}`,
+		"client":      `{{ template "pagerduty.default.client" . }}`,
+		"client_url":  `{{ template "pagerduty.default.clientURL" . }}`,
+	},
+	"opsgenie_configs": {
+		"message":     `{{ template "opsgenie.default.message" . }}`,
+		"description": `{{ template "opsgenie.default.description" . }}`,
+		"source":      `{{ template "opsgenie.default.source" . }}`,
+	},
+	"msteams_configs": {
+		"title":   `{{ template "msteams.default.title" . }}`,
+		"summary": `{{ template "msteams.default.summary" . }}`,
+		"text":    `{{ template "msteams.default.text" . }}`,
+	},
+	"telegram_configs": {
+		"message": `{{ template "telegram.default.message" . }}`,
+	},
+	"discord_configs": {
+		"title":   `{{ template "discord.default.title" . }}`,
+		"message": `{{ template "discord.default.message" . }}`,
+	},
+	"pushover_configs": {
+		"title":   `{{ template "pushover.default.title" . }}`,
+		"message": `{{ template "pushover.default.message" . }}`,
+		"url":     `{{ template "pushover.default.url" . }}`,
+	},
+	"webex_configs": {
+		"message": `{{ template "webex.default.message" . }}`,
+	},
+	"sns_configs": {
+		"subject": `{{ template "sns.default.subject" . }}`,
+		"message": `{{ template "sns.default.message" . }}`,
+	},
+	"victorops_configs": {
+		"entity_display_name": `{{ template "victorops.default.entity_display_name" . }}`,
+		"state_message":       `{{ template "victorops.default.state_message" . }}`,
+	},
+}
+
+// htmlNotificationFields lists the fields Alertmanager renders as HTML, by
+// integration.
+var htmlNotificationFields = map[string]string{
+	"email_configs": "html",
+}
+
+func dataSourceAlertmanagerNotifications() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Renders the notifications the receivers of the Alertmanager configuration of the tenant send for sample alerts, e.g. to review a change of the templates without firing an alert.
+
+The templates are rendered with the template engine of Alertmanager along with its default templates, the fields left unset by the integrations holding their default value. Only the templated fields are rendered: the fields holding a template and the ones Alertmanager fills in with a default template, e.g. the ` + "`title`" + ` and ` + "`text`" + ` of Slack. The body of webhooks, the JSON of the alerts, is not rendered.
+`,
+
+		ReadContext: alertmanagerNotificationsRead,
+
+		Schema: map[string]*schema.Schema{
+			"alert": {
+				Description: "The sample alerts of the notifications, all in the same group. Defaults to a single firing alert named `" + sampleAlertName + "`.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"status": {
+							Description:  "The status of the alert, `firing` or `resolved`.",
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      string(model.AlertFiring),
+							ValidateFunc: validation.StringInSlice([]string{string(model.AlertFiring), string(model.AlertResolved)}, false),
+						},
+						"labels": {
+							Description: "The labels of the alert. `alertname` defaults to `" + sampleAlertName + "`.",
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"annotations": {
+							Description: "The annotations of the alert.",
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"starts_at": {
+							Description:  "The RFC 3339 time the alert started firing at. Defaults to the time of the read.",
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.IsRFC3339Time,
+						},
+						"generator_url": {
+							Description: "The URL of the rule which fired the alert.",
+							Type:        schema.TypeString,
+							Optional:    true,
+						},
+					},
+				},
+			},
+			"group_labels": {
+				Description: "The labels of the group of the alerts, as set by the `group_by` of the route. Defaults to none.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"receivers": {
+				Description: "The receivers to render the notifications of. Defaults to all of them.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"external_url": {
+				Description:  "The URL of Alertmanager the templates link to, e.g. with `.ExternalURL`. Defaults to the one of the Alertmanager of Grafana Mimir at the provider address.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"notifications": {
+				Description: "The notifications, by receiver in the order of the configuration then by integration.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"receiver": {
+							Description: "The name of the receiver.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"integration": {
+							Description: "The integration of the receiver, e.g. `slack_configs`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"index": {
+							Description: "The index of the integration among the ones of the receiver of the same type.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						"fields": {
+							Description: "The rendered fields, keyed by their path in the integration configuration, e.g. `headers.Subject`.",
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func alertmanagerNotificationsRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	client, err := c.mimirClient("mimirtool_alertmanager_notifications")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	tenant := c.config.ID
+	if tenant == "" {
+		tenant = "anonymous"
+	}
+	d.SetId(hash(c.config.Address + "/" + tenant))
+
+	config, templates, err := client.GetAlertmanagerConfig(ctx)
+	if errors.Is(err, ErrNotFound) {
+		return diag.Errorf("the tenant has no Alertmanager configuration to render the notifications of")
+	} else if err != nil {
+		return diag.FromErr(err)
+	}
+	var cfg struct {
+		Receivers []struct {
+			Name         string                      `yaml:"name"`
+			Integrations map[string][]map[string]any `yaml:",inline"`
+		} `yaml:"receivers"`
+	}
+	if err := yaml.Unmarshal([]byte(config), &cfg); err != nil {
+		return diag.Errorf("unable to parse the Alertmanager configuration: %s", err)
+	}
+
+	tmpl, err := loadAlertmanagerTemplates(templates)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	externalURL := d.Get("external_url").(string)
+	if externalURL == "" {
+		externalURL = strings.TrimSuffix(c.config.Address, "/") + "/alertmanager"
+	}
+	if tmpl.ExternalURL, err = url.Parse(externalURL); err != nil {
+		return diag.Errorf("invalid external URL %q: %s", externalURL, err)
+	}
+	alerts, err := expandSampleAlerts(d.Get("alert").([]interface{}), time.Now())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	groupLabels := model.LabelSet{}
+	for name, value := range d.Get("group_labels").(map[string]interface{}) {
+		groupLabels[model.LabelName(name)] = model.LabelValue(value.(string))
+	}
+	selected := map[string]bool{}
+	for _, receiver := range d.Get("receivers").(*schema.Set).List() {
+		selected[receiver.(string)] = false
+	}
+
+	notifications := []interface{}{}
+	for _, receiver := range cfg.Receivers {
+		if len(selected) > 0 {
+			if _, ok := selected[receiver.Name]; !ok {
+				continue
+			}
+			selected[receiver.Name] = true
+		}
+		data := tmpl.Data(receiver.Name, groupLabels, alerts...)
+		integrations := make([]string, 0, len(receiver.Integrations))
+		for integration := range receiver.Integrations {
+			integrations = append(integrations, integration)
+		}
+		sort.Strings(integrations)
+		for _, integration := range integrations {
+			for i, integrationConfig := range receiver.Integrations[integration] {
+				fields, err := renderNotificationFields(tmpl, data, integration, integrationConfig)
+				if err != nil {
+					return diag.Errorf("unable to render %s #%d of receiver %q: %s", integration, i, receiver.Name, err)
+				}
+				notifications = append(notifications, map[string]interface{}{
+					"receiver":    receiver.Name,
+					"integration": integration,
+					"index":       i,
+					"fields":      fields,
+				})
+			}
+		}
+	}
+	var missing []string
+	for receiver, found := range selected {
+		if !found {
+			missing = append(missing, receiver)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return diag.Errorf("the Alertmanager configuration has no receiver named %s", strings.Join(missing, ", "))
+	}
+	d.Set("notifications", notifications)
+	return nil
+}
+
+// loadAlertmanagerTemplates returns the default templates of Alertmanager
+// along with templates, loaded from files as Grafana Mimir does.
+func loadAlertmanagerTemplates(templates map[string]string) (*template.Template, error) {
+	dir, err := os.MkdirTemp("", "mimirtool-templates")
+	if err != nil {
+		return nil, fmt.Errorf("unable to write the templates: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	paths := make([]string, 0, len(templates))
+	for name, content := range templates {
+		if name != filepath.Base(name) {
+			return nil, fmt.Errorf("invalid template name %q", name)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			return nil, fmt.Errorf("unable to write the template %q: %w", name, err)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	tmpl, err := template.FromGlobs(paths)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the templates: %w", err)
+	}
+	return tmpl, nil
+}
+
+// expandSampleAlerts returns the alerts of the alert blocks, a single firing
+// one when there is none.
+func expandSampleAlerts(blocks []interface{}, now time.Time) ([]*types.Alert, error) {
+	if len(blocks) == 0 {
+		blocks = []interface{}{map[string]interface{}{"status": string(model.AlertFiring)}}
+	}
+	alerts := make([]*types.Alert, 0, len(blocks))
+	for _, block := range blocks {
+		b, _ := block.(map[string]interface{})
+		alert := &types.Alert{Alert: model.Alert{
+			Labels:      model.LabelSet{model.AlertNameLabel: sampleAlertName},
+			Annotations: model.LabelSet{},
+			StartsAt:    now,
+		}, UpdatedAt: now}
+		for name, value := range stringValueMap(mapOrEmpty(b["labels"])) {
+			alert.Labels[model.LabelName(name)] = model.LabelValue(value)
+		}
+		for name, value := range stringValueMap(mapOrEmpty(b["annotations"])) {
+			alert.Annotations[model.LabelName(name)] = model.LabelValue(value)
+		}
+		if startsAt, _ := b["starts_at"].(string); startsAt != "" {
+			t, err := time.Parse(time.RFC3339, startsAt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid starts_at %q: %w", startsAt, err)
+			}
+			alert.StartsAt = t
+		}
+		if b["status"] == string(model.AlertResolved) {
+			alert.EndsAt = now
+		}
+		alert.GeneratorURL, _ = b["generator_url"].(string)
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// mapOrEmpty returns v as a map, empty when it's not one.
+func mapOrEmpty(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// renderNotificationFields renders the templated fields of the integration
+// configuration, along with the ones it leaves to their default template.
+func renderNotificationFields(tmpl *template.Template, data *template.Data, integration string, config map[string]any) (map[string]interface{}, error) {
+	fields := map[string]string{}
+	flattenTemplatedFields("", config, fields)
+	for path, value := range defaultNotificationFields[integration] {
+		if _, ok := lookupField(config, path); !ok {
+			fields[path] = value
+		}
+	}
+	rendered := make(map[string]interface{}, len(fields))
+	for path, text := range fields {
+		var out string
+		var err error
+		if htmlNotificationFields[integration] == path {
+			out, err = tmpl.ExecuteHTMLString(text, data)
+		} else {
+			out, err = tmpl.ExecuteTextString(text, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", path, err)
+		}
+		rendered[path] = out
+	}
+	return rendered, nil
+}
+
+// flattenTemplatedFields adds the string fields of config holding a template
+// to fields, keyed by their dotted path under prefix.
+func flattenTemplatedFields(prefix string, config map[string]any, fields map[string]string) {
+	for key, value := range config {
+		switch v := value.(type) {
+		case string:
+			if strings.Contains(v, "{{") {
+				fields[prefix+key] = v
+			}
+		case map[string]any:
+			flattenTemplatedFields(prefix+key+".", v, fields)
+		}
+	}
+}
+
+// lookupField returns the value at the dotted path of config.
+func lookupField(config map[string]any, path string) (any, bool) {
+	key, rest, nested := strings.Cut(path, ".")
+	value, ok := config[key]
+	if !ok || !nested {
+		return value, ok
+	}
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return lookupField(m, rest)
+}
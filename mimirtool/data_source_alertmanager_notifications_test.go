@@ -0,0 +1,82 @@
+package mimirtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAlertmanagerNotificationsRead(t *testing.T) {
+	fake := newFakeMimirClient()
+	meta := &client{cli: fake}
+	meta.config.Address = "https://mimir.example.com"
+
+	d := schema.TestResourceDataRaw(t, dataSourceAlertmanagerNotifications().Schema, map[string]interface{}{})
+	if diags := alertmanagerNotificationsRead(context.Background(), d, meta); !diags.HasError() {
+		t.Fatal("expected an error without configuration")
+	}
+
+	fake.CreateAlertmanagerConfig(context.Background(), `
+route:
+  receiver: team-x
+receivers:
+  - name: team-x
+    slack_configs:
+      - api_url: https://hooks.slack.com/services/secret
+        text: '{{ template "team.text" . }}'
+        footer: static
+  - name: team-y
+    email_configs:
+      - to: team-y@example.com
+        headers:
+          Reply-To: '{{ .CommonLabels.alertname }}@example.com'
+`, map[string]string{
+		"team.tmpl": `{{ define "team.text" }}{{ range .Alerts }}{{ .Labels.alertname }}/{{ .Status }}: {{ .Annotations.summary }} {{ end }}{{ .ExternalURL }}{{ end }}`,
+	})
+
+	d = schema.TestResourceDataRaw(t, dataSourceAlertmanagerNotifications().Schema, map[string]interface{}{})
+	if diags := alertmanagerNotificationsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("notifications.#") != 2 {
+		t.Fatalf("expected a notification by integration, got %v", d.Get("notifications"))
+	}
+	slack := d.Get("notifications.0.fields").(map[string]interface{})
+	if got := slack["text"]; got != "SampleAlert/firing:  https://mimir.example.com/alertmanager" {
+		t.Errorf("expected the default sample alert, got %q", got)
+	}
+	if _, ok := slack["title"]; !ok {
+		t.Errorf("expected the default title to be rendered, got %v", slack)
+	}
+	if _, ok := slack["api_url"]; ok {
+		t.Errorf("expected the fields without template to be left out, got %v", slack)
+	}
+	email := d.Get("notifications.1.fields").(map[string]interface{})
+	if email["headers.Reply-To"] != "SampleAlert@example.com" || email["headers.Subject"] == nil {
+		t.Errorf("expected the headers to be rendered, got %v", email)
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceAlertmanagerNotifications().Schema, map[string]interface{}{
+		"receivers":    []interface{}{"team-x"},
+		"external_url": "https://alerts.example.com",
+		"alert": []interface{}{
+			map[string]interface{}{"labels": map[string]interface{}{"alertname": "HighErrorRate"}, "annotations": map[string]interface{}{"summary": "errors"}},
+			map[string]interface{}{"status": "resolved", "labels": map[string]interface{}{"alertname": "HighLatency"}},
+		},
+	})
+	if diags := alertmanagerNotificationsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if got := d.Get("notifications.0.fields.text"); d.Get("notifications.#") != 1 || got != "HighErrorRate/firing: errors HighLatency/resolved:  https://alerts.example.com" {
+		t.Errorf("expected the sample alerts, got %v", d.Get("notifications"))
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceAlertmanagerNotifications().Schema, map[string]interface{}{
+		"receivers": []interface{}{"team-z"},
+	})
+	if diags := alertmanagerNotificationsRead(context.Background(), d, meta); !diags.HasError() || !strings.Contains(diags[0].Summary, `no receiver named team-z`) {
+		t.Fatalf("expected an unknown receiver error, got %v", diags)
+	}
+}
@@ -0,0 +1,159 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/common/model"
+)
+
+func dataSourceAlertmanagerRouting() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Compares how the current Alertmanager configuration of the tenant and a proposed one route sample alerts, e.g. to catch the alerts a change of the routing tree sends elsewhere at plan time.
+
+The alerts are routed with the routing tree of Alertmanager, as Grafana Mimir does: an alert goes to the receivers of the deepest routes matching it, several of them when routes ` + "`continue`" + `. Inhibitions, silences and time intervals are not taken into account.
+`,
+
+		ReadContext: alertmanagerRoutingRead,
+
+		Schema: map[string]*schema.Schema{
+			"config_yaml": {
+				Description: "The proposed Alertmanager configuration.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"alert": {
+				Description: "The sample alerts to route.",
+				Type:        schema.TypeList,
+				Required:    true,
+				MinItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"labels": {
+							Description: "The labels of the alert.",
+							Type:        schema.TypeMap,
+							Required:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"changed": {
+				Description: "Whether the proposed configuration routes any of the alerts differently.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"routes": {
+				Description: "The receivers of the alerts, in the order of `alert`.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"labels": {
+							Description: "The labels of the alert.",
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"current_receivers": {
+							Description: "The receivers of the alert with the current configuration, empty when the tenant has none.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"proposed_receivers": {
+							Description: "The receivers of the alert with `config_yaml`.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"changed": {
+							Description: "Whether the receivers of the alert differ.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func alertmanagerRoutingRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	client, err := c.mimirClient("mimirtool_alertmanager_routing")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	tenant := c.config.ID
+	if tenant == "" {
+		tenant = "anonymous"
+	}
+	d.SetId(hash(c.config.Address + "/" + tenant))
+
+	proposed, err := alertmanagerRoutingTree(d.Get("config_yaml").(string))
+	if err != nil {
+		return diag.Errorf("invalid proposed configuration: %s", err)
+	}
+	var current *dispatch.Route
+	currentConfig, _, err := client.GetAlertmanagerConfig(ctx)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return diag.FromErr(err)
+	}
+	if err == nil {
+		if current, err = alertmanagerRoutingTree(currentConfig); err != nil {
+			return diag.Errorf("invalid current configuration: %s", err)
+		}
+	}
+
+	changed := false
+	routes := []interface{}{}
+	for _, block := range d.Get("alert").([]interface{}) {
+		labels := stringValueMap(mapOrEmpty(block.(map[string]interface{})["labels"]))
+		labelSet := make(model.LabelSet, len(labels))
+		for name, value := range labels {
+			labelSet[model.LabelName(name)] = model.LabelValue(value)
+		}
+		before, after := routeReceivers(current, labelSet), routeReceivers(proposed, labelSet)
+		alertChanged := !reflect.DeepEqual(before, after)
+		changed = changed || alertChanged
+		routes = append(routes, map[string]interface{}{
+			"labels":             labels,
+			"current_receivers":  before,
+			"proposed_receivers": after,
+			"changed":            alertChanged,
+		})
+	}
+	d.Set("routes", routes)
+	d.Set("changed", changed)
+	return nil
+}
+
+// alertmanagerRoutingTree returns the routing tree of the Alertmanager
+// configuration cfg.
+func alertmanagerRoutingTree(cfg string) (*dispatch.Route, error) {
+	parsed, err := config.Load(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return dispatch.NewRoute(parsed.Route, nil), nil
+}
+
+// routeReceivers returns the receivers route sends an alert of labels to,
+// none when route is nil.
+func routeReceivers(route *dispatch.Route, labels model.LabelSet) []string {
+	receivers := []string{}
+	if route == nil {
+		return receivers
+	}
+	for _, match := range route.Match(labels) {
+		receivers = append(receivers, match.RouteOpts.Receiver)
+	}
+	return receivers
+}
@@ -0,0 +1,45 @@
+package mimirtool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAlertmanagerRoutingRead(t *testing.T) {
+	fake := newFakeMimirClient()
+	meta := &client{cli: fake}
+	raw := map[string]interface{}{
+		"config_yaml": "route:\n  receiver: platform\nreceivers:\n  - name: platform\n",
+		"alert": []interface{}{
+			map[string]interface{}{"labels": map[string]interface{}{"alertname": "Down", "team": "platform"}},
+		},
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceAlertmanagerRouting().Schema, raw)
+	if diags := alertmanagerRoutingRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("routes.0.current_receivers.#") != 0 || d.Get("routes.0.proposed_receivers.0") != "platform" || !d.Get("changed").(bool) {
+		t.Fatalf("expected a change from no configuration, got %v", d.Get("routes"))
+	}
+
+	fake.CreateAlertmanagerConfig(context.Background(), "route:\n  receiver: default\nreceivers:\n  - name: default\n", nil)
+	d = schema.TestResourceDataRaw(t, dataSourceAlertmanagerRouting().Schema, raw)
+	if diags := alertmanagerRoutingRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("routes.0.current_receivers.0") != "default" || d.Get("routes.0.labels").(map[string]interface{})["team"] != "platform" || !d.Get("routes.0.changed").(bool) {
+		t.Fatalf("expected the alert to move from default to platform, got %v", d.Get("routes"))
+	}
+
+	fake.CreateAlertmanagerConfig(context.Background(), raw["config_yaml"].(string), nil)
+	d = schema.TestResourceDataRaw(t, dataSourceAlertmanagerRouting().Schema, raw)
+	if diags := alertmanagerRoutingRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("changed").(bool) {
+		t.Fatalf("expected no change with the same configuration, got %v", d.Get("routes"))
+	}
+}
@@ -0,0 +1,31 @@
+package mimirtool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAlertmanagerDataSourceRead(t *testing.T) {
+	fake := newFakeMimirClient()
+	meta := &client{cli: fake}
+
+	d := schema.TestResourceDataRaw(t, dataSourceAlertmanager().Schema, map[string]interface{}{})
+	if diags := alertmanagerDataSourceRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("config_yaml") != "" || len(d.Get("templates").(map[string]interface{})) != 0 {
+		t.Fatalf("expected empty attributes without configuration, got %q and %v", d.Get("config_yaml"), d.Get("templates"))
+	}
+
+	templates := map[string]string{"default.tmpl": `{{ define "title" }}Alert{{ end }}`}
+	fake.CreateAlertmanagerConfig(context.Background(), "route:\n  receiver: default\n", templates)
+	d = schema.TestResourceDataRaw(t, dataSourceAlertmanager().Schema, map[string]interface{}{})
+	if diags := alertmanagerDataSourceRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("config_yaml") != "route:\n  receiver: default\n" || d.Get("templates").(map[string]interface{})["default.tmpl"] != templates["default.tmpl"] {
+		t.Fatalf("unexpected configuration %q and templates %v", d.Get("config_yaml"), d.Get("templates"))
+	}
+}
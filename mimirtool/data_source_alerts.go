@@ -0,0 +1,170 @@
+package mimirtool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// States of the active alerts of the rules API.
+const (
+	alertStateFiring  = "firing"
+	alertStatePending = "pending"
+)
+
+func dataSourceAlerts() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Lists the alerts currently active in the ruler of the tenant, as reported by the [Prometheus alerts API](https://grafana.com/docs/mimir/latest/references/http-api/#get-alerts), e.g. to check in a ` + "`check`" + ` block that a rule change doesn't make alerts fire right away.
+
+The rulers report the alerts as of their last evaluation, rules just written are only evaluated after the rulers synced them and their group interval elapsed.
+`,
+
+		ReadContext: alertsRead,
+
+		Schema: map[string]*schema.Schema{
+			"namespace": {
+				Description: "Only list the alerts named after the alerting rules of this namespace. The alerts API doesn't report the namespace of the alerts, rules of other namespaces with the same name match too.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"states": {
+				Description: "The states of the alerts to list, `firing` and `pending`. Defaults to both.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice([]string{alertStateFiring, alertStatePending}, false),
+				},
+			},
+			"alerts": {
+				Description: "The active alerts, sorted by name then labels. Empty when none is active.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Description: "The name of the alert, its `alertname` label.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"state": {
+							Description: "`firing`, or `pending` while its `for` duration has not elapsed.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"labels": {
+							Description: "The labels of the alert.",
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"annotations": {
+							Description: "The annotations of the alert.",
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"active_at": {
+							Description: "The RFC 3339 time the alert became active at.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"value": {
+							Description: "The value of the expression of the rule for the alert at its last evaluation.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// activeAlert is an alert of the Prometheus alerts API.
+type activeAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	State       string            `json:"state"`
+	ActiveAt    string            `json:"activeAt"`
+	Value       string            `json:"value"`
+}
+
+func alertsRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	namespace := d.Get("namespace").(string)
+	d.SetId(hash(c.config.ID + "/" + namespace))
+
+	var names map[string]bool
+	if namespace != "" {
+		client, err := c.mimirClient("mimirtool_alerts")
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		remote, err := client.ListRules(ctx, namespace)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return diag.FromErr(err)
+		}
+		names = map[string]bool{}
+		for _, group := range remote[namespace] {
+			for _, rule := range group.Rules {
+				if rule.Alert.Value != "" {
+					names[rule.Alert.Value] = true
+				}
+			}
+		}
+	}
+	states := d.Get("states").(*schema.Set)
+
+	body, err := c.apiGet(ctx, "mimirtool_alerts", c.config.prometheusHTTPPrefix+"/api/v1/alerts", nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var res struct {
+		Data struct {
+			// Alerts is null rather than empty for some versions when no
+			// alert is active.
+			Alerts []activeAlert `json:"alerts"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return diag.FromErr(fmt.Errorf("unexpected alerts response: %w", err))
+	}
+
+	active := make([]activeAlert, 0, len(res.Data.Alerts))
+	for _, alert := range res.Data.Alerts {
+		if names != nil && !names[alert.Labels["alertname"]] {
+			continue
+		}
+		if states.Len() > 0 && !states.Contains(alert.State) {
+			continue
+		}
+		active = append(active, alert)
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		if a, b := active[i].Labels["alertname"], active[j].Labels["alertname"]; a != b {
+			return a < b
+		}
+		return fmt.Sprint(active[i].Labels) < fmt.Sprint(active[j].Labels)
+	})
+	alerts := make([]interface{}, 0, len(active))
+	for _, alert := range active {
+		alerts = append(alerts, map[string]interface{}{
+			"name":        alert.Labels["alertname"],
+			"state":       alert.State,
+			"labels":      alert.Labels,
+			"annotations": alert.Annotations,
+			"active_at":   alert.ActiveAt,
+			"value":       alert.Value,
+		})
+	}
+	d.Set("alerts", alerts)
+	return nil
+}
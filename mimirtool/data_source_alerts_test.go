@@ -0,0 +1,76 @@
+package mimirtool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mimirtool "github.com/grafana/mimir/pkg/mimirtool/client"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAlertsRead(t *testing.T) {
+	response := `{"status":"success","data":{"alerts":[
+		{"labels":{"alertname":"Other","severity":"page"},"annotations":{},"state":"firing","activeAt":"2024-10-01T08:00:00Z","value":"1e+00"},
+		{"labels":{"alertname":"Down","job":"b"},"annotations":{"summary":"b is down"},"state":"pending","activeAt":"2024-10-01T08:01:00Z","value":"0e+00"},
+		{"labels":{"alertname":"Down","job":"a"},"annotations":{"summary":"a is down"},"state":"firing","activeAt":"2024-10-01T08:00:00Z","value":"0e+00"}
+	]}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prometheus/api/v1/alerts" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-Scope-OrgID") != "team-a" {
+			t.Errorf("expected the tenant to be sent, got %q", r.Header.Get("X-Scope-OrgID"))
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	var group rwrulefmt.RuleGroup
+	if err := yaml.Unmarshal([]byte(`
+name: demo
+rules:
+  - alert: Down
+    expr: up == 0
+`), &group); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeMimirClient()
+	fake.CreateRuleGroup(context.Background(), "demo", group)
+	meta := &client{cli: fake, config: clientConfig{
+		Config:               mimirtool.Config{Address: server.URL, ID: "team-a"},
+		prometheusHTTPPrefix: "/prometheus",
+	}}
+
+	d := schema.TestResourceDataRaw(t, dataSourceAlerts().Schema, map[string]interface{}{})
+	if diags := alertsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("alerts.#") != 3 || d.Get("alerts.0.labels.job") != "a" || d.Get("alerts.2.name") != "Other" {
+		t.Fatalf("expected all the alerts sorted, got %v", d.Get("alerts"))
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceAlerts().Schema, map[string]interface{}{
+		"namespace": "demo",
+		"states":    []interface{}{"firing"},
+	})
+	if diags := alertsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("alerts.#") != 1 || d.Get("alerts.0.annotations.summary") != "a is down" || d.Get("alerts.0.active_at") != "2024-10-01T08:00:00Z" {
+		t.Fatalf("expected the firing alerts of the namespace, got %v", d.Get("alerts"))
+	}
+
+	response = `{"status":"success","data":{"alerts":null}}`
+	d = schema.TestResourceDataRaw(t, dataSourceAlerts().Schema, map[string]interface{}{"namespace": "missing"})
+	if diags := alertsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("alerts.#") != 0 {
+		t.Fatalf("expected no alert, got %v", d.Get("alerts"))
+	}
+}
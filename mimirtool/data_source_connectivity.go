@@ -0,0 +1,100 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceConnectivity() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Checks that the provider configuration allows to reach Grafana Mimir. Every check is read-only and failures are reported through the attributes rather than failing the plan, so it can be used to troubleshoot a setup in isolation from real resources.
+`,
+
+		ReadContext: connectivityRead,
+
+		Schema: map[string]*schema.Schema{
+			"check_alertmanager": {
+				Description: "Also read the alertmanager configuration of the tenant.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"client_ok": {
+				Description: "Whether a client could be built from the provider configuration, which includes loading the TLS files.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"client_error": {
+				Description: "The error raised while building the client, if any.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"ruler_ok": {
+				Description: "Whether the rules of the tenant could be listed, which requires the connection, TLS handshake and authentication to succeed.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"ruler_error": {
+				Description: "The error raised while listing the rules, if any.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"alertmanager_ok": {
+				Description: "Whether the alertmanager configuration could be read. A tenant without configuration counts as a success. Always `false` unless `check_alertmanager` is set.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"alertmanager_error": {
+				Description: "The error raised while reading the alertmanager configuration, if any.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func connectivityRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	d.SetId(hash(c.config.Address + "/" + c.config.ID))
+
+	client, err := c.mimirClient("mimirtool_connectivity")
+	setCheckResult(d, "client", err)
+	if err != nil {
+		setCheckResult(d, "ruler", errors.New("skipped as no client could be built"))
+		setCheckResult(d, "alertmanager", errors.New("skipped as no client could be built"))
+		return nil
+	}
+
+	_, err = client.ListRules(ctx, "")
+	// A tenant without any rule is answered with a 404 by some Mimir versions.
+	if errors.Is(err, ErrNotFound) {
+		err = nil
+	}
+	setCheckResult(d, "ruler", err)
+
+	if !d.Get("check_alertmanager").(bool) {
+		d.Set("alertmanager_ok", false)
+		d.Set("alertmanager_error", "")
+		return nil
+	}
+	_, _, err = client.GetAlertmanagerConfig(ctx)
+	if errors.Is(err, ErrNotFound) {
+		err = nil
+	}
+	setCheckResult(d, "alertmanager", err)
+	return nil
+}
+
+// setCheckResult sets the <check>_ok and <check>_error attributes from err.
+func setCheckResult(d *schema.ResourceData, check string, err error) {
+	d.Set(check+"_ok", err == nil)
+	if err != nil {
+		d.Set(check+"_error", err.Error())
+	} else {
+		d.Set(check+"_error", "")
+	}
+}
@@ -0,0 +1,33 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestConnectivityRead(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, dataSourceConnectivity().Schema, map[string]interface{}{
+		"check_alertmanager": true,
+	})
+	if diags := connectivityRead(context.Background(), d, &client{cli: newFakeMimirClient()}); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	for _, check := range []string{"client_ok", "ruler_ok", "alertmanager_ok"} {
+		if !d.Get(check).(bool) {
+			t.Errorf("expected %s to be true", check)
+		}
+	}
+
+	fake := newFakeMimirClient()
+	fake.err = errors.New("server returned HTTP status: 401 Unauthorized")
+	d = schema.TestResourceDataRaw(t, dataSourceConnectivity().Schema, map[string]interface{}{})
+	if diags := connectivityRead(context.Background(), d, &client{cli: fake}); diags.HasError() {
+		t.Fatalf("failed checks should not fail the read: %v", diags)
+	}
+	if d.Get("ruler_ok").(bool) || d.Get("ruler_error").(string) == "" {
+		t.Errorf("expected the ruler check to fail, got ok=%v error=%q", d.Get("ruler_ok"), d.Get("ruler_error"))
+	}
+}
@@ -0,0 +1,201 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceExport() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Exports the ruler namespaces and the Alertmanager configuration of the tenant as Terraform configuration, to adopt the provider for an existing deployment or to rebuild a lost configuration. ` + "`hcl`" + ` holds a ` + "`mimirtool_ruler_namespace`" + ` and a ` + "`mimirtool_alertmanager`" + ` resource block for each object along with the ` + "[`import` block](https://developer.hashicorp.com/terraform/language/import)" + ` adopting it, written to a ` + "`.tf`" + ` file it plans no change once imported. The content is the one held by Grafana Mimir, in the canonical form the resources store.
+`,
+
+		ReadContext: exportRead,
+
+		Schema: map[string]*schema.Schema{
+			"include_alertmanager": {
+				Description: "Whether to export the Alertmanager configuration, when the tenant has one.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+			},
+			"namespaces": {
+				Description: "The names of the exported namespaces.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"hcl": {
+				Description: "The resource and `import` blocks reproducing the exported objects.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func exportRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	client, err := c.mimirClient("mimirtool_export")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(hash(c.config.ID))
+
+	remote, err := client.ListRules(ctx, "")
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return diag.FromErr(err)
+	}
+	namespaces := make([]string, 0, len(remote))
+	for namespace, groups := range remote {
+		if len(groups) > 0 {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	var b strings.Builder
+	b.WriteString("# Exported from Grafana Mimir")
+	if c.config.ID != "" {
+		fmt.Fprintf(&b, ", tenant %s", hclString(c.config.ID))
+	}
+	b.WriteString(".\n")
+	labels := map[string]bool{}
+	for _, namespace := range namespaces {
+		label := hclLabel(namespace, labels)
+		writeImportBlock(&b, "mimirtool_ruler_namespace."+label, rulerNamespaceImportID(c, namespace))
+		fmt.Fprintf(&b, "\nresource \"mimirtool_ruler_namespace\" %q {\n", label)
+		fmt.Fprintf(&b, "  namespace   = %s\n", hclString(namespace))
+		fmt.Fprintf(&b, "  config_yaml = %s\n", hclText(normalizeRuleNamespace(rules.RuleNamespace{Groups: remote[namespace]})))
+		b.WriteString("}\n")
+	}
+
+	if d.Get("include_alertmanager").(bool) {
+		config, templates, err := client.GetAlertmanagerConfig(ctx)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return diag.FromErr(err)
+		}
+		if err == nil {
+			writeImportBlock(&b, "mimirtool_alertmanager.this", "alertmanager")
+			b.WriteString("\nresource \"mimirtool_alertmanager\" \"this\" {\n")
+			fmt.Fprintf(&b, "  config_yaml = %s\n", hclText(config))
+			if len(templates) > 0 {
+				names := make([]string, 0, len(templates))
+				for name := range templates {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				b.WriteString("\n  templates_config_yaml = {\n")
+				for _, name := range names {
+					fmt.Fprintf(&b, "    %s = %s\n", hclString(name), hclText(templates[name]))
+				}
+				b.WriteString("  }\n")
+			}
+			b.WriteString("}\n")
+		}
+	}
+
+	d.Set("namespaces", namespaces)
+	d.Set("hcl", b.String())
+	return nil
+}
+
+// rulerNamespaceImportID returns the import ID of namespace of the provider
+// tenant, as parsed by parseRulerNamespaceImportID.
+func rulerNamespaceImportID(c *client, namespace string) string {
+	if c.config.ID != "" {
+		return c.config.ID + "/" + namespace
+	}
+	if strings.Contains(namespace, "/") {
+		return "/" + namespace
+	}
+	return namespace
+}
+
+// writeImportBlock writes the import block of the resource at address.
+func writeImportBlock(b *strings.Builder, address string, id string) {
+	fmt.Fprintf(b, "\nimport {\n  to = %s\n  id = %s\n}\n", address, hclString(id))
+}
+
+// hclLabel returns a resource name made of name, unique among used.
+func hclLabel(name string, used map[string]bool) string {
+	label := []rune(strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-') {
+			return r
+		}
+		return '_'
+	}, name))
+	if len(label) == 0 || !(unicode.IsLetter(label[0]) || label[0] == '_') {
+		label = append([]rune("_"), label...)
+	}
+	unique := string(label)
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", string(label), i)
+	}
+	used[unique] = true
+	return unique
+}
+
+// hclString returns s as a quoted HCL string, escaping the template
+// sequences so that it is taken literally.
+func hclString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&b, `\u%04x`, r)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// hclText returns s as an HCL heredoc when it is made of lines, a quoted
+// string otherwise, escaping the template sequences.
+func hclText(s string) string {
+	if !strings.HasSuffix(s, "\n") || strings.ContainsAny(s, "\r") {
+		return hclString(s)
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	delimiter := "EOT"
+	for i := 2; endsHeredoc(lines, delimiter); i++ {
+		delimiter = fmt.Sprintf("EOT%d", i)
+	}
+	escaped := strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+	return "<<" + delimiter + "\n" + escaped + delimiter
+}
+
+// endsHeredoc tells whether one of lines would end a heredoc delimited by
+// delimiter.
+func endsHeredoc(lines []string, delimiter string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) == delimiter {
+			return true
+		}
+	}
+	return false
+}
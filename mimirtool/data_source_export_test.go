@@ -0,0 +1,105 @@
+package mimirtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	mimirtool "github.com/grafana/mimir/pkg/mimirtool/client"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestExportRead(t *testing.T) {
+	fake := newFakeMimirClient()
+	group := rwrulefmt.RuleGroup{}
+	group.Name = "group"
+	for _, namespace := range []string{"team-a/api", "1st", "team_a_api"} {
+		if err := fake.CreateRuleGroup(context.Background(), namespace, group); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fake.CreateAlertmanagerConfig(context.Background(), "route:\n  receiver: ${team}\n", map[string]string{"team.tmpl": `{{ define "team" }}EOT{{ end }}`, "page.tmpl": "EOT\n"})
+	meta := &client{cli: fake, config: clientConfig{Config: mimirtool.Config{ID: "team-a"}}}
+
+	d := schema.TestResourceDataRaw(t, dataSourceExport().Schema, map[string]interface{}{})
+	if diags := exportRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	hcl := d.Get("hcl").(string)
+	for _, want := range []string{
+		"# Exported from Grafana Mimir, tenant \"team-a\".\n",
+		"import {\n  to = mimirtool_ruler_namespace._1st\n  id = \"team-a/1st\"\n}\n",
+		"resource \"mimirtool_ruler_namespace\" \"team-a_api\" {\n  namespace   = \"team-a/api\"\n  config_yaml = <<EOT\n",
+		"resource \"mimirtool_ruler_namespace\" \"team_a_api\" {",
+		"import {\n  to = mimirtool_alertmanager.this\n  id = \"alertmanager\"\n}\n",
+		"  config_yaml = <<EOT\nroute:\n  receiver: $${team}\nEOT\n",
+		"    \"page.tmpl\" = <<EOT2\nEOT\nEOT2\n",
+		"    \"team.tmpl\" = \"{{ define \\\"team\\\" }}EOT{{ end }}\"\n",
+	} {
+		if !strings.Contains(hcl, want) {
+			t.Errorf("expected %q in the export:\n%s", want, hcl)
+		}
+	}
+	if namespaces := d.Get("namespaces").([]interface{}); len(namespaces) != 3 || namespaces[0] != "1st" {
+		t.Errorf("expected the sorted namespaces, got %v", namespaces)
+	}
+
+	// The imported namespace holds the exported content and the defaults of
+	// the attributes the export leaves unset.
+	imported := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{})
+	imported.SetId("team-a/1st")
+	if _, err := rulerNamespaceImport(context.Background(), imported, meta); err != nil {
+		t.Fatal(err)
+	}
+	if diags := rulerNamespaceRead(context.Background(), imported, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if !strings.Contains(hcl, hclText(imported.Get("config_yaml").(string))) {
+		t.Errorf("expected the export to hold the imported content:\n%s", imported.Get("config_yaml"))
+	}
+	if imported.State().Attributes["validate"] != "true" || imported.State().Attributes["duplicate_alert_names"] != duplicateAlertNamesWarn {
+		t.Errorf("expected the defaults to be set on import, got %v", imported.State().Attributes)
+	}
+}
+
+func TestExportNamespaceImportIDs(t *testing.T) {
+	for _, tenant := range []string{"", "team-a"} {
+		meta := &client{config: clientConfig{Config: mimirtool.Config{ID: tenant}}}
+		for _, namespace := range []string{"api", "team-b/api", "a/b/c"} {
+			d := resourceRulerNamespace().TestResourceData()
+			d.SetId(rulerNamespaceImportID(meta, namespace))
+			imported, err := rulerNamespaceImport(context.Background(), d, meta)
+			if err != nil {
+				t.Fatalf("%q of tenant %q: %s", namespace, tenant, err)
+			}
+			if got := imported[0]; got.Get("namespace") != namespace || got.Get("tenant_id") != "" {
+				t.Errorf("%q of tenant %q: imported as namespace %q of tenant %q", namespace, tenant, got.Get("namespace"), got.Get("tenant_id"))
+			}
+		}
+	}
+
+	// The ID of another tenant is split at its first slash.
+	d := resourceRulerNamespace().TestResourceData()
+	d.SetId("team-b/a/b")
+	imported, err := rulerNamespaceImport(context.Background(), d, &client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := imported[0]; got.Get("namespace") != "a/b" || got.Get("tenant_id") != "team-b" {
+		t.Errorf("expected namespace a/b of tenant team-b, got namespace %q of tenant %q", got.Get("namespace"), got.Get("tenant_id"))
+	}
+}
+
+func TestHCLString(t *testing.T) {
+	for s, want := range map[string]string{
+		`plain`:            `"plain"`,
+		"a \"quoted\"\n\\": `"a \"quoted\"\n\\"`,
+		"${var} %{if} $$":  `"$${var} %%{if} $$"`,
+		"\x00":             `"\u0000"`,
+	} {
+		if got := hclString(s); got != want {
+			t.Errorf("hclString(%q) = %s, expected %s", s, got, want)
+		}
+	}
+}
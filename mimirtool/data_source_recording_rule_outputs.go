@@ -0,0 +1,177 @@
+package mimirtool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	recordingRuleOutputOK     = "ok"
+	recordingRuleOutputNoData = "no_data"
+)
+
+func dataSourceRecordingRuleOutputs() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Reports whether the metrics recorded by the recording rules of a namespace currently have data, to check that the rules are actually populating them. The metrics are queried through the [Prometheus API](https://grafana.com/docs/mimir/latest/references/http-api/#querier--query-frontend) of the tenant.
+`,
+
+		ReadContext: recordingRuleOutputsRead,
+
+		Schema: map[string]*schema.Schema{
+			"namespace": {
+				Description: "The namespace whose recording rules to check.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"lookback": {
+				Description:      "How far back to look for samples of the recorded metrics, as a duration (e.g. `15m`). It should be larger than the evaluation interval of the groups.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "15m",
+				ValidateDiagFunc: validateDuration,
+			},
+			"metrics": {
+				Description: "The metrics recorded by the namespace, in the order of the rules.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Description: "The name of the recorded metric.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"group": {
+							Description: "The group of the recording rule.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"type": {
+							Description: "The type of the metric from its metadata, `unknown` when it has none, which is usual for recorded metrics.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"series": {
+							Description: "The number of series of the metric having samples within `lookback`.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						"status": {
+							Description: "`ok` when the metric has data, `no_data` when it has none, e.g. because the rule hasn't been evaluated yet or its expression returns nothing.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func recordingRuleOutputsRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	client, err := c.mimirClient("mimirtool_recording_rule_outputs")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace := d.Get("namespace").(string)
+	lookback, _ := time.ParseDuration(d.Get("lookback").(string))
+	d.SetId(hash(c.config.ID + "/" + namespace))
+
+	remote, err := client.ListRules(ctx, namespace)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	now := time.Now()
+	var metrics []interface{}
+	for _, group := range remote[namespace] {
+		for _, rule := range group.Rules {
+			name := rule.Record.Value
+			if name == "" {
+				continue
+			}
+			series, err := recordedSeries(ctx, c, name, lookback, now)
+			if err != nil {
+				return diag.Errorf("unable to query the recorded metric %q: %s", name, err)
+			}
+			metricType, err := metricType(ctx, c, name)
+			if err != nil {
+				return diag.Errorf("unable to read the metadata of the recorded metric %q: %s", name, err)
+			}
+			status := recordingRuleOutputOK
+			if series == 0 {
+				status = recordingRuleOutputNoData
+			}
+			metrics = append(metrics, map[string]interface{}{
+				"name":   name,
+				"group":  group.Name,
+				"type":   metricType,
+				"series": series,
+				"status": status,
+			})
+		}
+	}
+	d.Set("metrics", metrics)
+	return nil
+}
+
+// recordedSeries counts the series of metric having samples within lookback.
+func recordedSeries(ctx context.Context, c *client, metric string, lookback time.Duration, now time.Time) (int, error) {
+	query := fmt.Sprintf("count(last_over_time({__name__=%s}[%ds]))", strconv.Quote(metric), int(lookback.Seconds()))
+	params := url.Values{"query": {query}, "time": {strconv.FormatInt(now.Unix(), 10)}}
+	body, err := c.apiGet(ctx, "mimirtool_recording_rule_outputs", c.config.prometheusHTTPPrefix+"/api/v1/query?"+params.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	var res struct {
+		Data struct {
+			Result []struct {
+				Value [2]interface{} `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return 0, fmt.Errorf("unexpected query response: %w", err)
+	}
+	// count() returns an empty vector when there is no series at all.
+	if len(res.Data.Result) == 0 {
+		return 0, nil
+	}
+	value, _ := res.Data.Result[0].Value[1].(string)
+	count, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected query result %q: %w", value, err)
+	}
+	return int(count), nil
+}
+
+// metricType returns the type of metric from its metadata, "unknown" when it
+// has none.
+func metricType(ctx context.Context, c *client, metric string) (string, error) {
+	params := url.Values{"metric": {metric}, "limit": {"1"}}
+	body, err := c.apiGet(ctx, "mimirtool_recording_rule_outputs", c.config.prometheusHTTPPrefix+"/api/v1/metadata?"+params.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	var res struct {
+		Data map[string][]struct {
+			Type string `json:"type"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return "", fmt.Errorf("unexpected metadata response: %w", err)
+	}
+	if metadata := res.Data[metric]; len(metadata) > 0 && metadata[0].Type != "" {
+		return metadata[0].Type, nil
+	}
+	return "unknown", nil
+}
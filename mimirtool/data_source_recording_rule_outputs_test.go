@@ -0,0 +1,75 @@
+package mimirtool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mimirtool "github.com/grafana/mimir/pkg/mimirtool/client"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRecordingRuleOutputsRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Scope-OrgID") != "team-a" {
+			t.Errorf("expected the tenant to be sent, got %q", r.Header.Get("X-Scope-OrgID"))
+		}
+		switch r.URL.Path {
+		case "/prometheus/api/v1/query":
+			if strings.Contains(r.URL.Query().Get("query"), `"job:up:sum"`) {
+				w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"3"]}]}}`))
+				return
+			}
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+		case "/prometheus/api/v1/metadata":
+			w.Write([]byte(`{"status":"success","data":{}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var group rwrulefmt.RuleGroup
+	if err := yaml.Unmarshal([]byte(`
+name: demo
+rules:
+  - record: job:up:sum
+    expr: sum by (job) (up)
+  - alert: Down
+    expr: up == 0
+  - record: job:errors:rate5m
+    expr: sum by (job) (rate(errors_total[5m]))
+`), &group); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeMimirClient()
+	fake.CreateRuleGroup(context.Background(), "demo", group)
+
+	d := schema.TestResourceDataRaw(t, dataSourceRecordingRuleOutputs().Schema, map[string]interface{}{"namespace": "demo"})
+	meta := &client{cli: fake, config: clientConfig{
+		Config:               mimirtool.Config{Address: server.URL, ID: "team-a"},
+		prometheusHTTPPrefix: "/prometheus",
+	}}
+	if diags := recordingRuleOutputsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if d.Get("metrics.#").(int) != 2 {
+		t.Fatalf("expected the two recorded metrics, got %v", d.Get("metrics"))
+	}
+	for i, want := range []map[string]interface{}{
+		{"name": "job:up:sum", "group": "demo", "type": "unknown", "series": 3, "status": recordingRuleOutputOK},
+		{"name": "job:errors:rate5m", "group": "demo", "type": "unknown", "series": 0, "status": recordingRuleOutputNoData},
+	} {
+		got := d.Get("metrics").([]interface{})[i].(map[string]interface{})
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("metric %d: expected %s to be %v, got %v", i, k, v, got[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package mimirtool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceRuleDependencyGraph() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Maps the metrics recorded by the recording rules of the given namespaces to the rules using them, e.g. to know which alerts a change of a recording rule impacts. The analysis only covers the namespaces given, which are parsed locally: Grafana Mimir is not called.
+
+An alert depends on a recorded metric when its expression selects it, or selects a metric recorded by a rule depending on it, however indirectly.
+`,
+
+		ReadContext: ruleDependencyGraphRead,
+
+		Schema: map[string]*schema.Schema{
+			"namespace": {
+				Description: "A namespace of rules, e.g. from the `namespace` and `config_yaml` of a `mimirtool_ruler_namespace` resource.",
+				Type:        schema.TypeList,
+				Required:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Description: "The name of the namespace.",
+							Type:        schema.TypeString,
+							Required:    true,
+						},
+						"config_yaml": {
+							Description: "The rule groups of the namespace, as for `mimirtool_ruler_namespace`.",
+							Type:        schema.TypeString,
+							Required:    true,
+						},
+					},
+				},
+			},
+			"recorded_metrics": {
+				Description: "The metrics recorded by the namespaces, sorted by name.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"metric": {
+							Description: "The name of the recorded metric.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"namespaces": {
+							Description: "The namespaces recording the metric, usually one.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"dependent_metrics": {
+							Description: "The recorded metrics whose expression selects the metric.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"direct_alerts": {
+							Description: "The alerts whose expression selects the metric.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"alerts": {
+							Description: "The alerts depending on the metric, directly or through other recorded metrics.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ruleDependencyGraph is the graph of the rules using recorded metrics.
+type ruleDependencyGraph struct {
+	// namespaces are the namespaces recording the metrics.
+	namespaces map[string]map[string]bool
+	// metrics are the recorded metrics selected by the expression of the
+	// recording rules, by recorded metric.
+	metrics map[string]map[string]bool
+	// alerts are the alerts selecting the recorded metrics, by metric.
+	alerts map[string]map[string]bool
+}
+
+func ruleDependencyGraphRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	namespaces := map[string]string{}
+	var ids []string
+	for _, block := range d.Get("namespace").([]interface{}) {
+		block := block.(map[string]interface{})
+		name := block["name"].(string)
+		if _, ok := namespaces[name]; ok {
+			return diag.Errorf("namespace %q is set more than once", name)
+		}
+		namespaces[name] = block["config_yaml"].(string)
+		ids = append(ids, name+"\n"+namespaces[name])
+	}
+	d.SetId(hash(strings.Join(ids, "\n")))
+
+	graph, diags := newRuleDependencyGraph(namespaces)
+	if diags.HasError() {
+		return diags
+	}
+	metrics := make([]string, 0, len(graph.namespaces))
+	for metric := range graph.namespaces {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+	res := make([]interface{}, 0, len(metrics))
+	for _, metric := range metrics {
+		res = append(res, map[string]interface{}{
+			"metric":            metric,
+			"namespaces":        sortedKeys(graph.namespaces[metric]),
+			"dependent_metrics": sortedKeys(graph.metrics[metric]),
+			"direct_alerts":     sortedKeys(graph.alerts[metric]),
+			"alerts":            sortedKeys(graph.transitiveAlerts(metric)),
+		})
+	}
+	d.Set("recorded_metrics", res)
+	return diags
+}
+
+// newRuleDependencyGraph returns the dependency graph of the recorded metrics
+// of namespaces, given by name. The rules whose expression cannot be parsed
+// are left out with a warning.
+func newRuleDependencyGraph(namespaces map[string]string) (*ruleDependencyGraph, diag.Diagnostics) {
+	graph := &ruleDependencyGraph{
+		namespaces: map[string]map[string]bool{},
+		metrics:    map[string]map[string]bool{},
+		alerts:     map[string]map[string]bool{},
+	}
+	add := func(edges map[string]map[string]bool, from string, to string) {
+		if edges[from] == nil {
+			edges[from] = map[string]bool{}
+		}
+		edges[from][to] = true
+	}
+
+	var diags diag.Diagnostics
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// The metrics are recorded first so that the rules can use the ones
+	// recorded by rules of namespaces after them.
+	selected := map[string][]string{}
+	for _, name := range names {
+		ruleNamespace, err := parseNamespace(namespaces[name]).decodedNamespace()
+		if err != nil {
+			return nil, append(diags, diag.Errorf("namespace %q: %s", name, err)...)
+		}
+		for _, group := range ruleNamespace.Groups {
+			for _, rule := range group.Rules {
+				if rule.Record.Value != "" {
+					add(graph.namespaces, rule.Record.Value, name)
+				}
+				metrics, err := referencedMetrics(rule.Expr.Value)
+				if err != nil {
+					diags = append(diags, diag.Diagnostic{
+						Severity: diag.Warning,
+						Summary:  "Rule left out of the dependency graph.",
+						Detail:   fmt.Sprintf("The expression of rule %q of group %q of namespace %q cannot be parsed: %s", rule.Alert.Value+rule.Record.Value, group.Name, name, err),
+					})
+					continue
+				}
+				key := "record\n" + rule.Record.Value
+				if rule.Alert.Value != "" {
+					key = "alert\n" + rule.Alert.Value
+				}
+				selected[key] = append(selected[key], metrics...)
+			}
+		}
+	}
+	for key, metrics := range selected {
+		kind, rule, _ := strings.Cut(key, "\n")
+		for _, metric := range metrics {
+			if _, ok := graph.namespaces[metric]; !ok {
+				continue
+			}
+			if kind == "alert" {
+				add(graph.alerts, metric, rule)
+			} else {
+				add(graph.metrics, metric, rule)
+			}
+		}
+	}
+	return graph, diags
+}
+
+// transitiveAlerts returns the alerts depending on metric, directly or
+// through the recorded metrics depending on it.
+func (g *ruleDependencyGraph) transitiveAlerts(metric string) map[string]bool {
+	alerts := map[string]bool{}
+	seen := map[string]bool{metric: true}
+	queue := []string{metric}
+	for len(queue) > 0 {
+		metric, queue = queue[0], queue[1:]
+		for alert := range g.alerts[metric] {
+			alerts[alert] = true
+		}
+		for dependent := range g.metrics[metric] {
+			if !seen[dependent] {
+				seen[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	return alerts
+}
+
+// sortedKeys returns the keys of set, sorted.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,69 @@
+package mimirtool
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRuleDependencyGraphRead(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, dataSourceRuleDependencyGraph().Schema, map[string]interface{}{
+		"namespace": []interface{}{
+			map[string]interface{}{"name": "alerts", "config_yaml": `groups:
+  - name: alerts
+    rules:
+      - alert: NoTargets
+        expr: job:up:sum == 0
+      - alert: HighErrorRatio
+        expr: job:errors:ratio5m > 0.1
+      - alert: Down
+        expr: up == 0
+`},
+			map[string]interface{}{"name": "recording", "config_yaml": `groups:
+  - name: recording
+    rules:
+      - record: job:up:sum
+        expr: sum by (job) (up)
+      - record: job:errors:rate5m
+        expr: sum by (job) (rate(errors_total[5m]))
+      - record: job:errors:ratio5m
+        expr: job:errors:rate5m / job:up:sum
+`},
+		},
+	})
+	if diags := ruleDependencyGraphRead(context.Background(), d, nil); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	metrics := d.Get("recorded_metrics").([]interface{})
+	if len(metrics) != 3 {
+		t.Fatalf("expected the three recorded metrics, got %v", metrics)
+	}
+	for i, want := range []map[string]string{
+		{"metric": "job:errors:rate5m", "dependent_metrics": "[job:errors:ratio5m]", "direct_alerts": "[]", "alerts": "[HighErrorRatio]"},
+		{"metric": "job:errors:ratio5m", "dependent_metrics": "[]", "direct_alerts": "[HighErrorRatio]", "alerts": "[HighErrorRatio]"},
+		{"metric": "job:up:sum", "dependent_metrics": "[job:errors:ratio5m]", "direct_alerts": "[NoTargets]", "alerts": "[HighErrorRatio NoTargets]"},
+	} {
+		got := metrics[i].(map[string]interface{})
+		for k, v := range want {
+			if s := fmt.Sprint(got[k]); s != v {
+				t.Errorf("metric %d: expected %s to be %s, got %s", i, k, v, s)
+			}
+		}
+		if fmt.Sprint(got["namespaces"]) != "[recording]" {
+			t.Errorf("metric %d: unexpected namespaces %v", i, got["namespaces"])
+		}
+	}
+}
+
+func TestRuleDependencyGraphCycle(t *testing.T) {
+	graph := &ruleDependencyGraph{
+		metrics: map[string]map[string]bool{"a": {"b": true}, "b": {"a": true}},
+		alerts:  map[string]map[string]bool{"b": {"B": true}},
+	}
+	if alerts := sortedKeys(graph.transitiveAlerts("a")); fmt.Sprint(alerts) != "[B]" {
+		t.Errorf("unexpected alerts %v", alerts)
+	}
+}
@@ -0,0 +1,179 @@
+package mimirtool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v3"
+)
+
+func dataSourceRulerDefaults() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Reads the effective ruler limits of the tenant: the [per-tenant overrides](https://grafana.com/docs/mimir/latest/configure/about-runtime-configuration/) when set, the defaults of the Grafana Mimir configuration otherwise. It reads the ` + "`/config`" + ` and ` + "`/runtime_config`" + ` endpoints, which must be reachable with the provider credentials.
+`,
+
+		ReadContext: rulerDefaultsRead,
+
+		Schema: map[string]*schema.Schema{
+			"evaluation_interval": {
+				Description: "The evaluation interval of the groups which don't set one, from `ruler_evaluation_interval`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"max_rules_per_rule_group": {
+				Description: "The maximum number of rules of a group, from `ruler_max_rules_per_rule_group`. 0 means unlimited.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"max_rule_groups_per_tenant": {
+				Description: "The maximum number of groups of the tenant, from `ruler_max_rule_groups_per_tenant`. 0 means unlimited.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"alertmanager_url": {
+				Description: "The Alertmanager the alerts of the tenant are sent to, from `ruler_alertmanager_client_config`, empty when the ruler one applies.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"recording_rules_evaluation_enabled": {
+				Description: "Whether the ruler evaluates the recording rules of the tenant, from `ruler_recording_rules_evaluation_enabled`. When disabled, the recording rules are accepted but never produce series, which the read reports as a warning.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"alerting_rules_evaluation_enabled": {
+				Description: "Whether the ruler evaluates the alerting rules of the tenant, from `ruler_alerting_rules_evaluation_enabled`. When disabled, the alerts are accepted but never fire, which the read reports as a warning.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"overridden": {
+				Description: "The names of the limits above overridden for the tenant.",
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// rulerLimits are the ruler limits of the Mimir configuration, unset ones are
+// nil so that overrides can be told apart from defaults.
+type rulerLimits struct {
+	EvaluationInterval              *string `yaml:"ruler_evaluation_interval"`
+	MaxRulesPerRuleGroup            *int    `yaml:"ruler_max_rules_per_rule_group"`
+	MaxRuleGroupsPerTenant          *int    `yaml:"ruler_max_rule_groups_per_tenant"`
+	RecordingRulesEvaluationEnabled *bool   `yaml:"ruler_recording_rules_evaluation_enabled"`
+	AlertingRulesEvaluationEnabled  *bool   `yaml:"ruler_alerting_rules_evaluation_enabled"`
+	AlertmanagerClientConfig        *struct {
+		AlertmanagerURL string `yaml:"alertmanager_url"`
+	} `yaml:"ruler_alertmanager_client_config"`
+}
+
+// override sets the limits set in overrides, and returns their names.
+func (l *rulerLimits) override(overrides rulerLimits) []string {
+	var names []string
+	if overrides.EvaluationInterval != nil {
+		l.EvaluationInterval = overrides.EvaluationInterval
+		names = append(names, "ruler_evaluation_interval")
+	}
+	if overrides.MaxRulesPerRuleGroup != nil {
+		l.MaxRulesPerRuleGroup = overrides.MaxRulesPerRuleGroup
+		names = append(names, "ruler_max_rules_per_rule_group")
+	}
+	if overrides.MaxRuleGroupsPerTenant != nil {
+		l.MaxRuleGroupsPerTenant = overrides.MaxRuleGroupsPerTenant
+		names = append(names, "ruler_max_rule_groups_per_tenant")
+	}
+	if overrides.RecordingRulesEvaluationEnabled != nil {
+		l.RecordingRulesEvaluationEnabled = overrides.RecordingRulesEvaluationEnabled
+		names = append(names, "ruler_recording_rules_evaluation_enabled")
+	}
+	if overrides.AlertingRulesEvaluationEnabled != nil {
+		l.AlertingRulesEvaluationEnabled = overrides.AlertingRulesEvaluationEnabled
+		names = append(names, "ruler_alerting_rules_evaluation_enabled")
+	}
+	if overrides.AlertmanagerClientConfig != nil {
+		l.AlertmanagerClientConfig = overrides.AlertmanagerClientConfig
+		names = append(names, "ruler_alertmanager_client_config")
+	}
+	sort.Strings(names)
+	return names
+}
+
+func rulerDefaultsRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := meta.(*client)
+	tenant := c.config.ID
+	if tenant == "" {
+		// The tenant of Mimir running without multi-tenancy.
+		tenant = "anonymous"
+	}
+	d.SetId(hash(c.config.Address + "/" + tenant))
+
+	body, err := c.apiGet(ctx, "mimirtool_ruler_defaults", "/config", nil)
+	if err != nil {
+		return diag.Errorf("unable to read the ruler defaults from the Grafana Mimir `/config` endpoint, it may not be exposed to the provider, e.g. by a gateway: %s", err)
+	}
+	var config struct {
+		Limits rulerLimits `yaml:"limits"`
+	}
+	if err := yaml.Unmarshal(body, &config); err != nil {
+		return diag.Errorf("unexpected Grafana Mimir configuration: %s", err)
+	}
+	limits := config.Limits
+
+	var overridden []string
+	body, err = c.apiGet(ctx, "mimirtool_ruler_defaults", "/runtime_config", nil)
+	if err == nil {
+		var runtimeConfig struct {
+			Overrides map[string]rulerLimits `yaml:"overrides"`
+		}
+		if err = yaml.Unmarshal(body, &runtimeConfig); err != nil {
+			err = fmt.Errorf("unexpected runtime configuration: %w", err)
+		}
+		overridden = limits.override(runtimeConfig.Overrides[tenant])
+	}
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Unable to read the tenant overrides.",
+			Detail:   fmt.Sprintf("The defaults of the Grafana Mimir configuration are reported, they may be overridden for tenant %q: %s", tenant, err),
+		})
+	}
+
+	if limits.EvaluationInterval != nil {
+		d.Set("evaluation_interval", *limits.EvaluationInterval)
+	}
+	if limits.MaxRulesPerRuleGroup != nil {
+		d.Set("max_rules_per_rule_group", *limits.MaxRulesPerRuleGroup)
+	}
+	if limits.MaxRuleGroupsPerTenant != nil {
+		d.Set("max_rule_groups_per_tenant", *limits.MaxRuleGroupsPerTenant)
+	}
+	if limits.AlertmanagerClientConfig != nil {
+		d.Set("alertmanager_url", limits.AlertmanagerClientConfig.AlertmanagerURL)
+	}
+	// Both evaluations are enabled unless the configuration disables them.
+	for _, evaluation := range []struct {
+		attribute, limit, rules string
+		enabled                 *bool
+	}{
+		{"recording_rules_evaluation_enabled", "ruler_recording_rules_evaluation_enabled", "recording rules", limits.RecordingRulesEvaluationEnabled},
+		{"alerting_rules_evaluation_enabled", "ruler_alerting_rules_evaluation_enabled", "alerting rules", limits.AlertingRulesEvaluationEnabled},
+	} {
+		enabled := evaluation.enabled == nil || *evaluation.enabled
+		d.Set(evaluation.attribute, enabled)
+		if !enabled {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Rule evaluation is disabled for the tenant.",
+				Detail:   fmt.Sprintf("`%s` is false for tenant %q: the ruler accepts its %s but never evaluates them.", evaluation.limit, tenant, evaluation.rules),
+			})
+		}
+	}
+	d.Set("overridden", overridden)
+	return diags
+}
@@ -0,0 +1,61 @@
+package mimirtool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mimirtool "github.com/grafana/mimir/pkg/mimirtool/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRulerDefaultsRead(t *testing.T) {
+	runtimeConfig := `overrides:
+  team-a:
+    ruler_evaluation_interval: 30s
+    ruler_alertmanager_client_config:
+      alertmanager_url: http://alertmanager-team-a/alertmanager
+    ruler_alerting_rules_evaluation_enabled: false
+  team-b:
+    ruler_evaluation_interval: 5m
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/config":
+			w.Write([]byte("limits:\n  ruler_evaluation_interval: 1m\n  ruler_max_rules_per_rule_group: 20\n  ruler_max_rule_groups_per_tenant: 70\n"))
+		case r.URL.Path == "/runtime_config" && runtimeConfig != "":
+			w.Write([]byte(runtimeConfig))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	meta := &client{cli: newFakeMimirClient(), config: clientConfig{Config: mimirtool.Config{Address: server.URL, ID: "team-a"}}}
+	d := schema.TestResourceDataRaw(t, dataSourceRulerDefaults().Schema, map[string]interface{}{})
+	if diags := rulerDefaultsRead(context.Background(), d, meta); len(diags) != 1 || diags.HasError() || !strings.Contains(diags[0].Detail, "ruler_alerting_rules_evaluation_enabled") {
+		t.Fatalf("expected a warning about the disabled alerting rules, got %v", diags)
+	}
+	if !d.Get("recording_rules_evaluation_enabled").(bool) || d.Get("alerting_rules_evaluation_enabled").(bool) {
+		t.Fatalf("expected only the alerting rules evaluation to be disabled, got %v", d.State().Attributes)
+	}
+	if d.Get("evaluation_interval") != "30s" || d.Get("max_rules_per_rule_group") != 20 || d.Get("alertmanager_url") != "http://alertmanager-team-a/alertmanager" {
+		t.Fatalf("expected the overrides to apply over the defaults, got %v", d.State().Attributes)
+	}
+	if d.Get("overridden.#") != 3 {
+		t.Fatalf("expected three overridden limits, got %v", d.Get("overridden"))
+	}
+
+	// Without overrides API, the defaults are reported with a warning.
+	runtimeConfig = ""
+	d = schema.TestResourceDataRaw(t, dataSourceRulerDefaults().Schema, map[string]interface{}{})
+	diags := rulerDefaultsRead(context.Background(), d, meta)
+	if len(diags) != 1 || diags.HasError() {
+		t.Fatalf("expected a warning, got %v", diags)
+	}
+	if d.Get("evaluation_interval") != "1m" {
+		t.Fatalf("expected the default interval, got %v", d.Get("evaluation_interval"))
+	}
+}
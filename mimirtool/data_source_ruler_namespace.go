@@ -0,0 +1,69 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceRulerNamespace() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Reads the rule groups of an existing ruler namespace, e.g. one managed outside of Terraform, to reference them from other configurations. The content is the one held by Grafana Mimir, in the canonical form ` + "`mimirtool_ruler_namespace`" + ` stores.
+`,
+
+		ReadContext: rulerNamespaceDataSourceRead,
+
+		Schema: map[string]*schema.Schema{
+			"namespace": {
+				Description: "The name of the namespace to read.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"tenant_id": {
+				Description: "The tenant of the namespace. Defaults to the provider `tenant_id`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"config_yaml": {
+				Description: "The rule groups of the namespace as YAML.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"sha256": {
+				Description: "The SHA-256 of `config_yaml`, comparable with the `content_sha256` of a `mimirtool_ruler_namespace` resource.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func rulerNamespaceDataSourceRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	namespace := d.Get("namespace").(string)
+	tenant := d.Get("tenant_id").(string)
+	if tenant == "" {
+		tenant = c.config.ID
+	}
+	client, err := c.mimirClientForTenant("mimirtool_ruler_namespace", tenant)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(tenant + "/" + namespace)
+
+	remote, err := client.ListRules(ctx, namespace)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return diag.FromErr(err)
+	}
+	if len(remote[namespace]) == 0 {
+		return diag.Errorf("namespace %q not found for tenant %q", namespace, tenant)
+	}
+	configYAML := normalizeRuleNamespace(rules.RuleNamespace{Groups: remote[namespace]})
+	d.Set("config_yaml", configYAML)
+	d.Set("sha256", hash(configYAML))
+	return nil
+}
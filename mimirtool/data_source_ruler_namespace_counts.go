@@ -0,0 +1,204 @@
+package mimirtool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Sources of the namespace counts.
+const (
+	countsSourceRulesAPI    = "rules_api"
+	countsSourceRulerConfig = "ruler_config"
+)
+
+// namespaceCounts are the numbers of groups and rules of a namespace.
+type namespaceCounts struct {
+	groups    int
+	alerting  int
+	recording int
+}
+
+func dataSourceRulerNamespaceCounts() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Counts the rule groups and rules of the ruler namespaces of the tenant, e.g. for dashboards of large tenants, without downloading their content.
+
+Grafana Mimir has no endpoint reporting the counts alone: they are read from the Prometheus rules API with ` + "`exclude_alerts`" + `, which the rulers answer from the groups they have loaded, rather than from the rule storage. The groups written since the last sync of the rulers, by default every minute, are not counted yet. When the rules API is not reachable, e.g. behind a gateway only routing the ruler configuration API, the counts fall back to the full content of the namespaces. ` + "`source`" + ` tells which one was used.
+`,
+
+		ReadContext: rulerNamespaceCountsRead,
+
+		Schema: map[string]*schema.Schema{
+			"namespaces": {
+				Description: "The namespaces to count. Defaults to all the namespaces of the tenant.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"source": {
+				Description: "Where the counts come from: `" + countsSourceRulesAPI + "`, the Prometheus rules API, or `" + countsSourceRulerConfig + "`, the full content of the namespaces.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"counts": {
+				Description: "The counts of the namespaces holding groups, sorted by namespace.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"namespace": {
+							Description: "The name of the namespace.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"groups": {
+							Description: "The number of rule groups.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						"rules": {
+							Description: "The number of rules.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						"alerting_rules": {
+							Description: "The number of alerting rules.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						"recording_rules": {
+							Description: "The number of recording rules.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func rulerNamespaceCountsRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	d.SetId(hash(c.config.ID))
+
+	source := countsSourceRulesAPI
+	counts, err := rulesAPINamespaceCounts(ctx, c)
+	if err != nil {
+		tflog.Debug(ctx, "Unable to count the rules through the rules API, falling back to the ruler configuration API", map[string]interface{}{
+			"error": err.Error(),
+		})
+		source = countsSourceRulerConfig
+		if counts, err = rulerConfigNamespaceCounts(ctx, c); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	selected := d.Get("namespaces").(*schema.Set)
+	namespaces := make([]string, 0, len(counts))
+	for namespace := range counts {
+		if selected.Len() == 0 || selected.Contains(namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	res := make([]interface{}, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		count := counts[namespace]
+		res = append(res, map[string]interface{}{
+			"namespace":       namespace,
+			"groups":          count.groups,
+			"rules":           count.alerting + count.recording,
+			"alerting_rules":  count.alerting,
+			"recording_rules": count.recording,
+		})
+	}
+	d.Set("source", source)
+	d.Set("counts", res)
+	return nil
+}
+
+// rulesAPINamespaceCounts counts the groups and rules loaded by the rulers,
+// by namespace.
+func rulesAPINamespaceCounts(ctx context.Context, c *client) (map[string]*namespaceCounts, error) {
+	body, err := c.apiGet(ctx, "mimirtool_ruler_namespace_counts", c.config.prometheusHTTPPrefix+"/api/v1/rules?exclude_alerts=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	var res struct {
+		Status string `json:"status"`
+		Data   struct {
+			Groups []struct {
+				File  string `json:"file"`
+				Rules []struct {
+					Type string `json:"type"`
+				} `json:"rules"`
+			} `json:"groups"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	if res.Status != "success" {
+		return nil, errors.New("the rules API did not answer with a success")
+	}
+	counts := map[string]*namespaceCounts{}
+	for _, group := range res.Data.Groups {
+		count, ok := counts[group.File]
+		if !ok {
+			count = &namespaceCounts{}
+			counts[group.File] = count
+		}
+		count.groups++
+		for _, rule := range group.Rules {
+			if rule.Type == "alerting" {
+				count.alerting++
+			} else {
+				count.recording++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// rulerConfigNamespaceCounts counts the groups and rules of the namespaces
+// from their full content.
+func rulerConfigNamespaceCounts(ctx context.Context, c *client) (map[string]*namespaceCounts, error) {
+	client, err := c.mimirClient("mimirtool_ruler_namespace_counts")
+	if err != nil {
+		return nil, err
+	}
+	remote, err := client.ListRules(ctx, "")
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	counts := map[string]*namespaceCounts{}
+	for namespace, groups := range remote {
+		if len(groups) > 0 {
+			counts[namespace] = groupCounts(groups)
+		}
+	}
+	return counts, nil
+}
+
+// groupCounts counts groups and their rules.
+func groupCounts(groups []rwrulefmt.RuleGroup) *namespaceCounts {
+	count := &namespaceCounts{groups: len(groups)}
+	for _, group := range groups {
+		for _, rule := range group.Rules {
+			if rule.Alert.Value != "" {
+				count.alerting++
+			} else {
+				count.recording++
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,80 @@
+package mimirtool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mimirtool "github.com/grafana/mimir/pkg/mimirtool/client"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRulerNamespaceCountsRead(t *testing.T) {
+	rulesAPI := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prometheus/api/v1/rules" || !rulesAPI {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("exclude_alerts") != "true" {
+			t.Errorf("expected the alerts to be excluded, got %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"status":"success","data":{"groups":[
+			{"name":"a","file":"team-a","rules":[{"type":"alerting"},{"type":"recording"}]},
+			{"name":"b","file":"team-a","rules":[{"type":"recording"}]},
+			{"name":"c","file":"team-b","rules":[{"type":"alerting"}]}
+		]}}`))
+	}))
+	defer server.Close()
+
+	var group rwrulefmt.RuleGroup
+	if err := yaml.Unmarshal([]byte(`
+name: demo
+rules:
+  - record: job:up:sum
+    expr: sum by (job) (up)
+  - alert: Down
+    expr: up == 0
+`), &group); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeMimirClient()
+	fake.CreateRuleGroup(context.Background(), "team-c", group)
+	meta := &client{cli: fake, config: clientConfig{
+		Config:               mimirtool.Config{Address: server.URL},
+		prometheusHTTPPrefix: "/prometheus",
+	}}
+
+	d := schema.TestResourceDataRaw(t, dataSourceRulerNamespaceCounts().Schema, map[string]interface{}{})
+	if diags := rulerNamespaceCountsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("source") != countsSourceRulesAPI || d.Get("counts.#") != 2 {
+		t.Fatalf("expected the counts of the rules API, got %v from %v", d.Get("counts"), d.Get("source"))
+	}
+	if d.Get("counts.0.namespace") != "team-a" || d.Get("counts.0.groups") != 2 || d.Get("counts.0.rules") != 3 || d.Get("counts.0.alerting_rules") != 1 {
+		t.Errorf("unexpected counts of team-a: %v", d.Get("counts.0"))
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceRulerNamespaceCounts().Schema, map[string]interface{}{
+		"namespaces": []interface{}{"team-b"},
+	})
+	if diags := rulerNamespaceCountsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("counts.#") != 1 || d.Get("counts.0.namespace") != "team-b" {
+		t.Errorf("expected only the selected namespace, got %v", d.Get("counts"))
+	}
+
+	rulesAPI = false
+	d = schema.TestResourceDataRaw(t, dataSourceRulerNamespaceCounts().Schema, map[string]interface{}{})
+	if diags := rulerNamespaceCountsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("source") != countsSourceRulerConfig || d.Get("counts.#") != 1 || d.Get("counts.0.recording_rules") != 1 || d.Get("counts.0.alerting_rules") != 1 {
+		t.Fatalf("expected the counts of the full content, got %v from %v", d.Get("counts"), d.Get("source"))
+	}
+}
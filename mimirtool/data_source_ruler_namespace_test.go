@@ -0,0 +1,39 @@
+package mimirtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRulerNamespaceDataSourceRead(t *testing.T) {
+	fakes := map[string]*fakeMimirClient{"team-a": newFakeMimirClient(), "team-b": newFakeMimirClient()}
+	group := rwrulefmt.RuleGroup{}
+	group.Name = "group"
+	fakes["team-b"].CreateRuleGroup(context.Background(), "shared", group)
+
+	meta := &client{cli: fakes["team-a"], factory: func(cfg clientConfig) (mimirClientInterface, error) {
+		return fakes[cfg.ID], nil
+	}}
+	meta.config.Address = "http://mimir.invalid"
+	meta.config.ID = "team-a"
+
+	d := schema.TestResourceDataRaw(t, dataSourceRulerNamespace().Schema, map[string]interface{}{"namespace": "shared", "tenant_id": "team-b"})
+	if diags := rulerNamespaceDataSourceRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if configYAML := d.Get("config_yaml").(string); !strings.Contains(configYAML, "name: group") || d.Get("sha256") != hash(configYAML) {
+		t.Errorf("unexpected content %q, sha256 %v", configYAML, d.Get("sha256"))
+	}
+	if d.Id() != "team-b/shared" {
+		t.Errorf("unexpected ID %q", d.Id())
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceRulerNamespace().Schema, map[string]interface{}{"namespace": "shared"})
+	if diags := rulerNamespaceDataSourceRead(context.Background(), d, meta); !diags.HasError() || !strings.Contains(diags[0].Summary, `namespace "shared" not found for tenant "team-a"`) {
+		t.Errorf("expected a missing namespace error, got %v", diags)
+	}
+}
@@ -0,0 +1,180 @@
+package mimirtool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// rulerShardsNote is always reported as the assignment is derived from the ring
+// rather than read from the rulers.
+const rulerShardsNote = "Assignment computed from the ruler hash ring. It does not account for ruler tenant shuffle sharding (`ruler_tenant_shard_size`) nor for rulers joining or leaving the ring."
+
+func dataSourceRulerShards() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Reports the ruler instance responsible for evaluating each group of a namespace, as computed from the [ruler hash ring](https://grafana.com/docs/mimir/latest/references/architecture/components/ruler/). When the ring is not available, e.g. the ruler is not sharded or the endpoint is disabled, ` + "`available`" + ` is ` + "`false`" + ` and ` + "`note`" + ` tells why.
+`,
+
+		ReadContext: rulerShardsRead,
+
+		Schema: map[string]*schema.Schema{
+			"namespace": {
+				Description: "The namespace whose groups to report.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"available": {
+				Description: "Whether the assignment could be computed.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"note": {
+				Description: "Caveats of the reported assignment, or why it is not available.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"groups": {
+				Description: "The groups of the namespace along with the ruler responsible for them.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Description: "The name of the group.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"instance": {
+							Description: "The ID of the ruler evaluating the group, empty when not available.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"address": {
+							Description: "The address of the ruler evaluating the group, empty when not available.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"zone": {
+							Description: "The availability zone of the ruler evaluating the group, if any.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// rulerRingShard is an instance of the ruler ring as reported by the ring
+// status page.
+type rulerRingShard struct {
+	ID      string   `json:"id"`
+	State   string   `json:"state"`
+	Address string   `json:"address"`
+	Zone    string   `json:"zone"`
+	Tokens  []uint32 `json:"tokens"`
+}
+
+func rulerShardsRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	client, err := c.mimirClient("mimirtool_ruler_shards")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace := d.Get("namespace").(string)
+	d.SetId(hash(c.config.ID + "/" + namespace))
+
+	remote, err := client.ListRules(ctx, namespace)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	groups := remote[namespace]
+
+	var shards []rulerRingShard
+	body, err := c.apiGet(ctx, "mimirtool_ruler_shards", "/ruler/ring?tokens=true", http.Header{"Accept": []string{"application/json"}})
+	if err == nil {
+		var ring struct {
+			Shards []rulerRingShard `json:"shards"`
+		}
+		if err = json.Unmarshal(body, &ring); err != nil {
+			err = fmt.Errorf("unexpected ring status page: %w", err)
+		}
+		shards = ring.Shards
+	}
+	if err != nil {
+		d.Set("available", false)
+		d.Set("note", fmt.Sprintf("The ruler ring is not available, the ruler may not be sharded or the endpoint may be disabled: %s", err))
+		d.Set("groups", flattenRulerShards(groups, nil))
+		return nil
+	}
+
+	tenant := c.config.ID
+	if tenant == "" {
+		// The tenant of Mimir running without multi-tenancy.
+		tenant = "anonymous"
+	}
+	d.Set("available", true)
+	d.Set("note", rulerShardsNote)
+	d.Set("groups", flattenRulerShards(groups, func(group string) *rulerRingShard {
+		return rulerShardFor(shards, tenant, namespace, group)
+	}))
+	return nil
+}
+
+func flattenRulerShards(groups []rwrulefmt.RuleGroup, owner func(group string) *rulerRingShard) []interface{} {
+	res := make([]interface{}, 0, len(groups))
+	for _, group := range groups {
+		g := map[string]interface{}{"name": group.Name}
+		if owner != nil {
+			if shard := owner(group.Name); shard != nil {
+				g["instance"], g["address"], g["zone"] = shard.ID, shard.Address, shard.Zone
+			}
+		}
+		res = append(res, g)
+	}
+	return res
+}
+
+// rulerShardFor returns the ACTIVE ruler owning the first token following the
+// one of the group, the way the ruler shards groups.
+func rulerShardFor(shards []rulerRingShard, tenant, namespace, group string) *rulerRingShard {
+	type ownedToken struct {
+		token uint32
+		shard int
+	}
+	var tokens []ownedToken
+	for i, shard := range shards {
+		if shard.State != "ACTIVE" {
+			continue
+		}
+		for _, token := range shard.Tokens {
+			tokens = append(tokens, ownedToken{token, i})
+		}
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].token < tokens[j].token })
+
+	// Same hash as the ruler's tokenForGroup.
+	hasher := fnv.New32a()
+	hasher.Write([]byte(tenant))
+	hasher.Write([]byte(namespace))
+	hasher.Write([]byte(group))
+	key := hasher.Sum32()
+
+	i := sort.Search(len(tokens), func(i int) bool { return tokens[i].token >= key })
+	if i == len(tokens) {
+		i = 0
+	}
+	return &shards[tokens[i].shard]
+}
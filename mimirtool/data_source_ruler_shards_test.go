@@ -0,0 +1,53 @@
+package mimirtool
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mimirtool "github.com/grafana/mimir/pkg/mimirtool/client"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRulerShardFor(t *testing.T) {
+	hasher := fnv.New32a()
+	hasher.Write([]byte("team-a" + "demo" + "group"))
+	key := hasher.Sum32()
+
+	shards := []rulerRingShard{
+		{ID: "ruler-0", State: "ACTIVE", Tokens: []uint32{key - 1}},
+		{ID: "ruler-1", State: "ACTIVE", Tokens: []uint32{key + 10}},
+		{ID: "ruler-2", State: "LEAVING", Tokens: []uint32{key}},
+	}
+	if shard := rulerShardFor(shards, "team-a", "demo", "group"); shard == nil || shard.ID != "ruler-1" {
+		t.Fatalf("expected ruler-1 to own the group, got %v", shard)
+	}
+	if shard := rulerShardFor(shards[2:], "team-a", "demo", "group"); shard != nil {
+		t.Fatalf("expected no owner without active rulers, got %v", shard)
+	}
+}
+
+func TestRulerShardsReadUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	fake := newFakeMimirClient()
+	group := rwrulefmt.RuleGroup{}
+	group.Name = "group"
+	fake.CreateRuleGroup(context.Background(), "demo", group)
+
+	d := schema.TestResourceDataRaw(t, dataSourceRulerShards().Schema, map[string]interface{}{"namespace": "demo"})
+	meta := &client{cli: fake, config: clientConfig{Config: mimirtool.Config{Address: server.URL}}}
+	if diags := rulerShardsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("a missing ring should not fail the read: %v", diags)
+	}
+	if d.Get("available").(bool) || d.Get("note").(string) == "" {
+		t.Fatalf("expected the assignment to be reported unavailable with a note")
+	}
+	if d.Get("groups.#").(int) != 1 || d.Get("groups.0.name").(string) != "group" {
+		t.Fatalf("expected the groups to be listed anyway, got %v", d.Get("groups"))
+	}
+}
@@ -0,0 +1,172 @@
+package mimirtool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceRulesBatchValidate() *schema.Resource {
+	ruleNamespaceSchema := resourceRulerNamespace().Schema
+	r := &schema.Resource{
+		Description: `
+Validates the rules of many namespaces at once, e.g. as a CI gate over a large rule repository. The namespaces go through the checks ` + "`mimirtool_ruler_namespace`" + ` runs before pushing them, those of ` + "`allowed_annotation_domains`" + ` and ` + "`forbidden_label_combinations`" + ` included once ` + "`transform`" + ` applies, locally: Grafana Mimir is not called, so ` + "`rule_dependencies`" + ` is not checked. The provider ` + "`namespace_name_pattern`" + ` and ` + "`warn_deprecated`" + ` apply.
+`,
+
+		ReadContext: rulesBatchValidateRead,
+
+		Schema: map[string]*schema.Schema{
+			"namespaces": {
+				Description: "The rule groups of the namespaces to validate as YAML, by namespace.",
+				Type:        schema.TypeMap,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"strict_recording_rule_check":      ruleNamespaceSchema["strict_recording_rule_check"],
+			"extended_validation":              ruleNamespaceSchema["extended_validation"],
+			"require_for_multiple_of_interval": ruleNamespaceSchema["require_for_multiple_of_interval"],
+			"check_recording_rule_cardinality": ruleNamespaceSchema["check_recording_rule_cardinality"],
+			"high_cardinality_metrics":         ruleNamespaceSchema["high_cardinality_metrics"],
+			"duplicate_alert_names":            ruleNamespaceSchema["duplicate_alert_names"],
+			"allowed_annotation_domains":       ruleNamespaceSchema["allowed_annotation_domains"],
+			"annotation_link_keys":             ruleNamespaceSchema["annotation_link_keys"],
+			"forbidden_label_combinations":     ruleNamespaceSchema["forbidden_label_combinations"],
+			"transform":                        ruleNamespaceSchema["transform"],
+			"fail_on_error": {
+				Description: "Fail the read when a namespace is not valid. Otherwise the errors are only reported through `valid` and `results`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+			},
+			"valid": {
+				Description: "Whether all the namespaces are valid. Warnings don't make a namespace invalid.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"results": {
+				Description: "The results of the namespaces, sorted by namespace.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"namespace": {
+							Description: "The name of the namespace.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"valid": {
+							Description: "Whether the namespace has no error.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+						"errors": {
+							Description: "The errors of the namespace, which would fail its apply.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"warnings": {
+							Description: "The warnings of the namespace.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+	for k, v := range validationSchema(rulerNamespaceValidators) {
+		r.Schema[k] = v
+	}
+	return r
+}
+
+func rulesBatchValidateRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c, _ := meta.(*client)
+	if c == nil {
+		c = &client{}
+	}
+	namespaces := stringValueMap(d.Get("namespaces").(map[string]interface{}))
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ids, invalid []string
+	results := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		ids = append(ids, name+"\n"+namespaces[name])
+		var errs, warnings []string
+		for _, diagnostic := range validateBatchNamespace(ctx, d, c, name, namespaces[name]) {
+			message := diagnostic.Summary
+			if diagnostic.Detail != "" {
+				message += " " + diagnostic.Detail
+			}
+			if diagnostic.Severity == diag.Error {
+				errs = append(errs, message)
+			} else {
+				warnings = append(warnings, message)
+			}
+		}
+		if len(errs) > 0 {
+			invalid = append(invalid, fmt.Sprintf("%s: %s", name, strings.Join(errs, " ")))
+		}
+		results = append(results, map[string]interface{}{
+			"namespace": name,
+			"valid":     len(errs) == 0,
+			"errors":    errs,
+			"warnings":  warnings,
+		})
+	}
+	d.SetId(hash(strings.Join(ids, "\n")))
+	if len(invalid) > 0 && d.Get("fail_on_error").(bool) {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("%d of %d namespaces are not valid.", len(invalid), len(names)),
+			Detail:   strings.Join(invalid, "\n"),
+		}}
+	}
+	d.Set("valid", len(invalid) == 0)
+	d.Set("results", results)
+	return nil
+}
+
+// validateBatchNamespace runs the checks of mimirtool_ruler_namespace which
+// don't need Mimir on the namespace name and its configYAML, as set by d.
+func validateBatchNamespace(ctx context.Context, d *schema.ResourceData, c *client, name string, configYAML string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if err := c.checkNamespaceName(name); err != nil {
+		diags = append(diags, diag.FromErr(err)...)
+	}
+	ruleNamespace, err := getRuleNamespaceFromYAML(ctx, c.namespaces, configYAML, validatorEnabled(d, validatorPromQL))
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	if validatorEnabled(d, validatorRecordingRules) {
+		if err := checkRecordingRules(ruleNamespace, d.Get("strict_recording_rule_check").(bool)); err != nil {
+			diags = append(diags, diag.FromErr(err)...)
+		}
+	}
+	if validatorEnabled(d, validatorAlertNames) {
+		severity := diag.Warning
+		if d.Get("duplicate_alert_names").(string) == duplicateAlertNamesError {
+			severity = diag.Error
+		}
+		diags = append(diags, checkDuplicateAlertNames(ruleNamespace, severity)...)
+	}
+	diags = append(diags, rulerNamespaceLints(ctx, c, nil, d, name, ruleNamespace)...)
+	transforms, err := expandRuleTransforms(d.Get("transform").([]interface{}))
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	if checks := checkTransformedNamespace(d, transformNamespace(ruleNamespace, transforms)); checks.HasError() {
+		diags = append(diags, checks...)
+	}
+	return diags
+}
@@ -0,0 +1,103 @@
+package mimirtool
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRulesBatchValidateRead(t *testing.T) {
+	namespaces := map[string]interface{}{
+		"team-ok": `groups:
+  - name: ok
+    rules:
+      - alert: Down
+        expr: up == 0
+        for: 30s
+`,
+		"team-duplicates": `groups:
+  - name: first
+    rules:
+      - alert: Down
+        expr: up == 0
+  - name: second
+    rules:
+      - alert: Down
+        expr: up == 0
+`,
+		"team-syntax":  "groups: [",
+		"Invalid_Name": `groups: []`,
+	}
+	meta := &client{namespaceNamePattern: regexp.MustCompile(`^(?:[a-z]+-[a-z]+)$`)}
+
+	d := schema.TestResourceDataRaw(t, dataSourceRulesBatchValidate().Schema, map[string]interface{}{
+		"namespaces":            namespaces,
+		"duplicate_alert_names": duplicateAlertNamesError,
+		"extended_validation":   true,
+		"fail_on_error":         false,
+	})
+	if diags := rulesBatchValidateRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("valid").(bool) {
+		t.Error("expected the batch to be invalid")
+	}
+	results := map[string]map[string]interface{}{}
+	for _, result := range d.Get("results").([]interface{}) {
+		result := result.(map[string]interface{})
+		results[result["namespace"].(string)] = result
+	}
+	for namespace, valid := range map[string]bool{"team-ok": true, "team-duplicates": false, "team-syntax": false, "Invalid_Name": false} {
+		if results[namespace]["valid"] != valid {
+			t.Errorf("%s: expected valid to be %v, got %v", namespace, valid, results[namespace])
+		}
+	}
+	if warnings := fmt.Sprint(results["team-ok"]["warnings"]); !strings.Contains(warnings, "shorter than the group interval") {
+		t.Errorf("expected the extended validation to run, got warnings %s", warnings)
+	}
+	if errs := fmt.Sprint(results["Invalid_Name"]["errors"]); !strings.Contains(errs, "namespace_name_pattern") {
+		t.Errorf("expected the naming convention to be checked, got %s", errs)
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceRulesBatchValidate().Schema, map[string]interface{}{"namespaces": namespaces})
+	diags := rulesBatchValidateRead(context.Background(), d, meta)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "2 of 4 namespaces are not valid") {
+		t.Errorf("expected the read to fail, got %v", diags)
+	}
+}
+
+func TestRulesBatchValidateTransformedChecks(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, dataSourceRulesBatchValidate().Schema, map[string]interface{}{
+		"namespaces": map[string]interface{}{
+			"links":  "groups:\n  - name: group\n    rules:\n      - alert: Down\n        expr: up == 0\n        annotations:\n          runbook_url: https://evil.example.com/down\n",
+			"labels": "groups:\n  - name: group\n    rules:\n      - alert: Down\n        expr: up == 0\n        labels:\n          team: a\n",
+		},
+		"allowed_annotation_domains": []interface{}{"runbooks.example.org"},
+		"forbidden_label_combinations": []interface{}{
+			map[string]interface{}{"labels": []interface{}{"team", "squad"}},
+		},
+		"transform": []interface{}{
+			map[string]interface{}{"type": transformSetLabels, "params": map[string]interface{}{"squad": "b"}},
+		},
+		"fail_on_error": false,
+	})
+	if diags := rulesBatchValidateRead(context.Background(), d, &client{}); diags.HasError() {
+		t.Fatal(diags)
+	}
+	results := map[string]map[string]interface{}{}
+	for _, result := range d.Get("results").([]interface{}) {
+		result := result.(map[string]interface{})
+		results[result["namespace"].(string)] = result
+	}
+	if errs := fmt.Sprint(results["links"]["errors"]); !strings.Contains(errs, "allowed_annotation_domains") {
+		t.Errorf("expected the annotation links to be checked, got %s", errs)
+	}
+	// The label the transform adds completes the forbidden combination.
+	if errs := fmt.Sprint(results["labels"]["errors"]); !strings.Contains(errs, "forbidden_label_combinations") {
+		t.Errorf("expected the labels of the transformed rules to be checked, got %s", errs)
+	}
+}
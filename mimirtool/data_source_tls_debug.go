@@ -0,0 +1,227 @@
+package mimirtool
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Transport modes reported by mimirtool_tls_debug.
+const (
+	tlsModePlainHTTP = "plain_http"
+	tlsModeTLS       = "tls"
+	tlsModeMutualTLS = "mutual_tls"
+)
+
+func dataSourceTLSDebug() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+Reports the TLS settings the provider loaded, e.g. to troubleshoot failing mTLS handshakes in isolation. Only the metadata of the certificates is reported: the private key is read to check that it matches the client certificate, but never exposed. Problems with the files are reported through the attributes rather than failing the plan.
+
+No connection to Grafana Mimir is made, see ` + "`mimirtool_connectivity`" + ` to check the handshake itself.
+`,
+
+		ReadContext: tlsDebugRead,
+
+		Schema: map[string]*schema.Schema{
+			"mode": {
+				Description: "`" + tlsModePlainHTTP + "` when `address` is an `http` URL, which doesn't use TLS at all, `" + tlsModeTLS + "` for an `https` one, `" + tlsModeMutualTLS + "` when a client certificate is configured too.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"ca_bundle_configured": {
+				Description: "Whether `tls_ca_path` or `ca_cert_pem` is set. The system CA bundle is used otherwise.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"ca_certificates": {
+				Description: "The number of certificates of `tls_ca_path` or `ca_cert_pem`.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"insecure_skip_verify": {
+				Description: "Whether the certificate of Grafana Mimir is left unverified, as set by `insecure_skip_verify`.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"client_certificates": {
+				Description: "The chain of `tls_cert_path` or `tls_cert_pem`, the client certificate first.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"subject": {
+							Description: "The subject of the certificate.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"issuer": {
+							Description: "The issuer of the certificate.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"serial_number": {
+							Description: "The serial number of the certificate, in hexadecimal.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"not_before": {
+							Description: "The RFC 3339 time the certificate is valid from.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"not_after": {
+							Description: "The RFC 3339 time the certificate expires at.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"expired": {
+							Description: "Whether the certificate is expired or not valid yet.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+						"sha256_fingerprint": {
+							Description: "The SHA-256 fingerprint of the certificate, in hexadecimal.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"error": {
+				Description: "The error raised while loading the TLS files, if any, e.g. a key not matching the client certificate.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func tlsDebugRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	cfg := c.config.TLS
+	d.SetId(hash(c.config.Address + "/" + cfg.CAPath + "/" + cfg.CertPath))
+
+	inline := c.config.tlsPEM
+	if inline == nil {
+		inline = &tlsPEM{}
+	}
+	mode := tlsModeTLS
+	if u, err := url.Parse(c.config.Address); err == nil && u.Scheme == "http" {
+		mode = tlsModePlainHTTP
+	} else if cfg.CertPath != "" || inline.certificate != nil {
+		mode = tlsModeMutualTLS
+	}
+	d.Set("mode", mode)
+	d.Set("ca_bundle_configured", cfg.CAPath != "" || len(inline.caChain) > 0)
+	d.Set("insecure_skip_verify", cfg.InsecureSkipVerify)
+
+	// The inline PEM material was checked when the provider was configured,
+	// only the files may fail to load here.
+	var errs []error
+	switch {
+	case len(inline.caChain) > 0:
+		d.Set("ca_certificates", len(inline.caChain))
+	case cfg.CAPath != "":
+		chain, err := readCertificates(cfg.CAPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tls_ca_path: %w", err))
+		}
+		d.Set("ca_certificates", len(chain))
+	}
+	var certificates []interface{}
+	switch {
+	case inline.certificate != nil:
+		certificates = flattenCertificates(inline.certChain)
+	case cfg.CertPath != "":
+		chain, err := readCertificates(cfg.CertPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tls_cert_path: %w", err))
+		}
+		certificates = flattenCertificates(chain)
+		// The key pair is only loaded to check it, the key is dropped.
+		if err == nil {
+			if _, err := tls.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath); err != nil {
+				errs = append(errs, fmt.Errorf("tls_key_path: %w", err))
+			}
+		}
+	}
+	d.Set("client_certificates", certificates)
+	if err := errors.Join(errs...); err != nil {
+		d.Set("error", err.Error())
+	} else {
+		d.Set("error", "")
+	}
+	return nil
+}
+
+// flattenCertificates returns the metadata of the certificates of chain.
+func flattenCertificates(chain []*x509.Certificate) []interface{} {
+	now := time.Now()
+	certificates := make([]interface{}, 0, len(chain))
+	for _, cert := range chain {
+		fingerprint := sha256.Sum256(cert.Raw)
+		certificates = append(certificates, map[string]interface{}{
+			"subject":            cert.Subject.String(),
+			"issuer":             cert.Issuer.String(),
+			"serial_number":      cert.SerialNumber.Text(16),
+			"not_before":         cert.NotBefore.UTC().Format(time.RFC3339),
+			"not_after":          cert.NotAfter.UTC().Format(time.RFC3339),
+			"expired":            now.Before(cert.NotBefore) || now.After(cert.NotAfter),
+			"sha256_fingerprint": hex.EncodeToString(fingerprint[:]),
+		})
+	}
+	return certificates
+}
+
+// readCertificates returns the certificates of the PEM file at path.
+func readCertificates(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := parseCertificates(data)
+	if errors.Is(err, errNoPEMCertificate) {
+		return nil, fmt.Errorf("no PEM certificate found in %s", path)
+	}
+	return chain, err
+}
+
+// errNoPEMCertificate is returned by parseCertificates for data holding no
+// certificate.
+var errNoPEMCertificate = errors.New("no PEM certificate found")
+
+// parseCertificates returns the certificates of the PEM data.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return chain, err
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, errNoPEMCertificate
+	}
+	return chain, nil
+}
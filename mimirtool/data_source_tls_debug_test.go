@@ -0,0 +1,112 @@
+package mimirtool
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// writeTestKeyPair writes a self-signed certificate of name and its key to
+// dir, returning their paths.
+func writeTestKeyPair(t *testing.T, dir string, name string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath, keyPath := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func TestTLSDebugRead(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeTestKeyPair(t, dir, "client")
+	_, otherKeyPath := writeTestKeyPair(t, dir, "other")
+
+	read := func(address string, certPath string, keyPath string) *schema.ResourceData {
+		t.Helper()
+		meta := &client{}
+		meta.config.Address = address
+		meta.config.TLS.CAPath = certPath
+		meta.config.TLS.CertPath = certPath
+		meta.config.TLS.KeyPath = keyPath
+		d := schema.TestResourceDataRaw(t, dataSourceTLSDebug().Schema, map[string]interface{}{})
+		if diags := tlsDebugRead(context.Background(), d, meta); diags.HasError() {
+			t.Fatal(diags)
+		}
+		return d
+	}
+
+	d := read("https://mimir.example.org", certPath, keyPath)
+	if d.Get("mode") != tlsModeMutualTLS || !d.Get("ca_bundle_configured").(bool) || d.Get("ca_certificates") != 1 || d.Get("error") != "" {
+		t.Fatalf("unexpected result: mode %v, CA %v, error %v", d.Get("mode"), d.Get("ca_certificates"), d.Get("error"))
+	}
+	cert := d.Get("client_certificates").([]interface{})[0].(map[string]interface{})
+	if cert["subject"] != "CN=client" || cert["serial_number"] != "2a" || cert["expired"] != false || len(cert["sha256_fingerprint"].(string)) != 64 {
+		t.Errorf("unexpected certificate %v", cert)
+	}
+	for _, value := range cert {
+		if s, ok := value.(string); ok && strings.Contains(s, "PRIVATE") {
+			t.Errorf("key material exposed: %v", cert)
+		}
+	}
+
+	if d := read("https://mimir.example.org", certPath, otherKeyPath); !strings.Contains(d.Get("error").(string), "tls_key_path") {
+		t.Errorf("expected a mismatching key to be reported, got %q", d.Get("error"))
+	}
+	if d := read("https://mimir.example.org", filepath.Join(dir, "missing.crt"), keyPath); !strings.Contains(d.Get("error").(string), "tls_cert_path") {
+		t.Errorf("expected a missing certificate to be reported, got %q", d.Get("error"))
+	}
+
+	certPEM, _ := os.ReadFile(certPath)
+	keyPEM, _ := os.ReadFile(keyPath)
+	inline, err := parseTLSPEM(string(certPEM), string(certPEM), string(keyPEM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := &client{}
+	meta.config.Address = "https://mimir.example.org"
+	meta.config.tlsPEM = inline
+	d = schema.TestResourceDataRaw(t, dataSourceTLSDebug().Schema, map[string]interface{}{})
+	if diags := tlsDebugRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("mode") != tlsModeMutualTLS || d.Get("ca_certificates") != 1 || d.Get("client_certificates.0.subject") != "CN=client" {
+		t.Errorf("unexpected inline PEM result: mode %v, CA %v, certificates %v", d.Get("mode"), d.Get("ca_certificates"), d.Get("client_certificates"))
+	}
+	if d := read("http://mimir.example.org", "", ""); d.Get("mode") != tlsModePlainHTTP || d.Get("ca_bundle_configured").(bool) || d.Get("client_certificates.#") != 0 {
+		t.Errorf("unexpected plain HTTP result: mode %v", d.Get("mode"))
+	}
+}
@@ -0,0 +1,136 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// defaultSensitivePatterns match the secrets Mimir may echo back in its
+// errors, e.g. the parts of a rejected Alertmanager configuration. The
+// provider sensitive_patterns extend them.
+var defaultSensitivePatterns = []*regexp.Regexp{
+	secretFieldRegexp,
+	// The secret fields quoted inline, e.g. `api_key: "..."` in a message.
+	regexp.MustCompile(`(?i)(\b(?:[a-z]+_)*(?:password|secret|api_key|api_url|service_key|routing_key|user_key|webhook_url)["']?\s*[:=]\s*|\b(?:[a-z]+_)+token["']?\s*[:=]\s*)["']?[^\s"',}]+["']?`),
+	// The webhook URLs which embed their credentials.
+	regexp.MustCompile(`https://hooks\.slack\.com/\S+`),
+	regexp.MustCompile(`https://[\w.-]+\.webhook\.office\.com/\S+`),
+	regexp.MustCompile(`https://(?:[\w-]+\.)?discord(?:app)?\.com/api/webhooks/\S+`),
+}
+
+// redactSensitive replaces the matches of patterns in s with `<redacted>`,
+// keeping the text of the first capture group of a pattern, e.g. the name of
+// a field, in front of it.
+func redactSensitive(s string, patterns []*regexp.Regexp) string {
+	for _, pattern := range patterns {
+		if pattern.NumSubexp() > 0 {
+			s = pattern.ReplaceAllString(s, "${1}<redacted>")
+		} else {
+			s = pattern.ReplaceAllString(s, "<redacted>")
+		}
+	}
+	return s
+}
+
+// sensitivePatternsOf returns the patterns to redact for meta, the default
+// ones when the provider is not configured.
+func sensitivePatternsOf(meta any) []*regexp.Regexp {
+	if c, ok := meta.(*client); ok && c.sensitivePatterns != nil {
+		return c.sensitivePatterns
+	}
+	return defaultSensitivePatterns
+}
+
+// providerSensitivePatterns returns the default sensitive patterns along with
+// the sensitive_patterns of the provider configuration d. The default ones are
+// returned along with the error when one of d is invalid.
+func providerSensitivePatterns(d *schema.ResourceData) ([]*regexp.Regexp, error) {
+	patterns := append([]*regexp.Regexp{}, defaultSensitivePatterns...)
+	for _, pattern := range expandStringList(d.Get("sensitive_patterns").([]interface{})) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return defaultSensitivePatterns, fmt.Errorf("invalid sensitive_patterns: %w", err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// maskLogs returns ctx whose logs, their messages and field values, are
+// masked where they match patterns.
+func maskLogs(ctx context.Context, patterns []*regexp.Regexp) context.Context {
+	return tflog.MaskLogRegexes(ctx, patterns...)
+}
+
+// redactDiagnosticList redacts the summary and the detail of diags in place.
+func redactDiagnosticList(diags diag.Diagnostics, patterns []*regexp.Regexp) {
+	for i := range diags {
+		diags[i].Summary = redactSensitive(diags[i].Summary, patterns)
+		diags[i].Detail = redactSensitive(diags[i].Detail, patterns)
+	}
+}
+
+// redactDiagnostics makes the diagnostics, errors and logs of every operation
+// of r, its plan and import included, go through the sensitive patterns of the
+// provider before they reach the Terraform output and logs.
+func redactDiagnostics(r *schema.Resource) {
+	wrap := func(f func(context.Context, *schema.ResourceData, any) diag.Diagnostics) func(context.Context, *schema.ResourceData, any) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+			patterns := sensitivePatternsOf(meta)
+			diags := f(maskLogs(ctx, patterns), d, meta)
+			redactDiagnosticList(diags, patterns)
+			return diags
+		}
+	}
+	r.CreateContext = wrap(r.CreateContext)
+	r.ReadContext = wrap(r.ReadContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+
+	if customizeDiff := r.CustomizeDiff; customizeDiff != nil {
+		r.CustomizeDiff = func(ctx context.Context, d *schema.ResourceDiff, meta any) error {
+			return redactError(customizeDiff(maskLogs(ctx, sensitivePatternsOf(meta)), d, meta), meta)
+		}
+	}
+	if r.Importer != nil && r.Importer.StateContext != nil {
+		importState := r.Importer.StateContext
+		r.Importer.StateContext = func(ctx context.Context, d *schema.ResourceData, meta any) ([]*schema.ResourceData, error) {
+			resources, err := importState(maskLogs(ctx, sensitivePatternsOf(meta)), d, meta)
+			return resources, redactError(err, meta)
+		}
+	}
+}
+
+// redactConfigure makes the diagnostics and logs of the provider
+// configuration go through its sensitive patterns, the default ones when its
+// sensitive_patterns are invalid.
+func redactConfigure(configure schema.ConfigureContextFunc) schema.ConfigureContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData) (any, diag.Diagnostics) {
+		patterns, _ := providerSensitivePatterns(d)
+		meta, diags := configure(maskLogs(ctx, patterns), d)
+		redactDiagnosticList(diags, patterns)
+		return meta, diags
+	}
+}
+
+// redactError returns err with its message redacted, or err itself when
+// nothing is redacted so that it can still be inspected.
+func redactError(err error, meta any) error {
+	if err == nil {
+		return nil
+	}
+	message := redactSensitive(err.Error(), sensitivePatternsOf(meta))
+	if message == err.Error() {
+		return err
+	}
+	return errors.New(message)
+}
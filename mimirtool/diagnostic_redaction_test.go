@@ -0,0 +1,102 @@
+package mimirtool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRedactSensitive(t *testing.T) {
+	message := `error validating Alertmanager config: unsupported scheme "" for URL
+    slack_api_url: https://hooks.slack.com/services/T000/B000/secret
+  rejected routing_key: "pd-secret", api_key: 'ops-secret' and bot_token=tg-secret
+  see https://team.webhook.office.com/webhookb2/secret and https://discord.com/api/webhooks/1/secret
+  the token of the provider has expired, api_key_file: /etc/opsgenie`
+	got := redactSensitive(message, defaultSensitivePatterns)
+	if strings.Contains(got, "secret") {
+		t.Fatalf("expected the secrets to be redacted, got:\n%s", got)
+	}
+	for _, kept := range []string{"slack_api_url: <redacted>", "routing_key: <redacted>,", "api_key: <redacted> and", "bot_token=<redacted>", "see <redacted> and <redacted>", "the token of the provider has expired", "api_key_file: /etc/opsgenie"} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("expected %q, got:\n%s", kept, got)
+		}
+	}
+
+	patterns := append(append([]*regexp.Regexp{}, defaultSensitivePatterns...), regexp.MustCompile(`(X-Scope-Key: )\S+`), regexp.MustCompile(`corp-[0-9]+`))
+	if got := redactSensitive("X-Scope-Key: abc for corp-1234", patterns); got != "X-Scope-Key: <redacted> for <redacted>" {
+		t.Errorf("unexpected redaction by the configured patterns: %q", got)
+	}
+}
+
+func TestRedactDiagnostics(t *testing.T) {
+	leak := "api_key: leaked"
+	r := &schema.Resource{
+		Schema: map[string]*schema.Schema{"name": {Type: schema.TypeString, Optional: true}},
+		ReadContext: func(context.Context, *schema.ResourceData, any) diag.Diagnostics {
+			return diag.Diagnostics{
+				{Severity: diag.Error, Summary: "upload failed: " + leak, Detail: "body: " + leak},
+				{Severity: diag.Warning, Summary: "a warning"},
+			}
+		},
+		CustomizeDiff: func(context.Context, *schema.ResourceDiff, any) error {
+			return errors.New("invalid " + leak)
+		},
+	}
+	redactDiagnostics(r)
+
+	c := &client{sensitivePatterns: append([]*regexp.Regexp{}, defaultSensitivePatterns...)}
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{})
+	diags := r.ReadContext(context.Background(), d, c)
+	if len(diags) != 2 || diags[0].Summary != "upload failed: api_key: <redacted>" || diags[0].Detail != "body: api_key: <redacted>" || diags[1].Summary != "a warning" {
+		t.Fatalf("unexpected diagnostics %v", diags)
+	}
+	if err := r.CustomizeDiff(context.Background(), nil, nil); err == nil || strings.Contains(err.Error(), "leaked") {
+		t.Fatalf("expected the plan error to be redacted with the default patterns, got %v", err)
+	}
+
+	notFound := errors.New("not found")
+	if err := redactError(notFound, c); err != notFound {
+		t.Errorf("expected an error without secrets to be kept as is, got %v", err)
+	}
+}
+
+func TestRedactLogs(t *testing.T) {
+	r := &schema.Resource{
+		Schema: map[string]*schema.Schema{"name": {Type: schema.TypeString, Optional: true}},
+		ReadContext: func(ctx context.Context, _ *schema.ResourceData, _ any) diag.Diagnostics {
+			tflog.Warn(ctx, "Retrying the call, api_key: leaked", map[string]interface{}{
+				"error": "upload failed: api_key: leaked",
+			})
+			return nil
+		},
+	}
+	redactDiagnostics(r)
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+	r.ReadContext(ctx, schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{}), &client{})
+	if strings.Contains(output.String(), "leaked") || !strings.Contains(output.String(), "Retrying the call") {
+		t.Fatalf("expected the log to be masked, got %s", output.String())
+	}
+}
+
+func TestRedactConfigure(t *testing.T) {
+	configure := redactConfigure(func(ctx context.Context, d *schema.ResourceData) (any, diag.Diagnostics) {
+		return nil, diag.Errorf("unable to reach https://hooks.slack.com/services/secret as corp-1234")
+	})
+	d := schema.TestResourceDataRaw(t, New("test")().Schema, map[string]interface{}{
+		"sensitive_patterns": []interface{}{`corp-[0-9]+`},
+	})
+	_, diags := configure(context.Background(), d)
+	if len(diags) != 1 || diags[0].Summary != "unable to reach <redacted> as <redacted>" {
+		t.Fatalf("expected the configuration error to be redacted, got %v", diags)
+	}
+}
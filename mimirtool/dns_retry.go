@@ -0,0 +1,129 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// dnsFailure tells whether the last call of an operation failed to resolve
+// the host of Mimir.
+type dnsFailure struct {
+	last atomic.Bool
+}
+
+type dnsFailureKey struct{}
+
+// withDNSFailure returns ctx along with the DNS failure tracker of the
+// operation.
+func withDNSFailure(ctx context.Context, failure *dnsFailure) context.Context {
+	return context.WithValue(ctx, dnsFailureKey{}, failure)
+}
+
+// dnsFailureTransport records in the tracker of the operation whether its
+// calls fail to resolve the host of Mimir, once the fallback addresses are
+// tried.
+type dnsFailureTransport struct {
+	base http.RoundTripper
+}
+
+func (t *dnsFailureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if failure, ok := req.Context().Value(dnsFailureKey{}).(*dnsFailure); ok {
+		var dnsErr *net.DNSError
+		failure.last.Store(errors.As(err, &dnsErr))
+	}
+	return resp, err
+}
+
+// retryOnDNSFailure runs f, the operation of a resource, again with the
+// backoff of policy while it fails because its last call could not resolve
+// the host of Mimir, e.g. in a pod whose network is not ready yet. The calls
+// are retried on their own first, the operation is run at most
+// policy.maxAttempts times.
+func retryOnDNSFailure(ctx context.Context, policy retryPolicy, f func(context.Context) diag.Diagnostics) diag.Diagnostics {
+	backoff := policy.backoff
+	for attempt := 1; ; attempt++ {
+		failure := &dnsFailure{}
+		diags := f(withDNSFailure(ctx, failure))
+		if !diags.HasError() || !failure.last.Load() || attempt >= policy.maxAttempts {
+			return diags
+		}
+		tflog.Warn(ctx, "Retrying the operation after a DNS resolution failure", map[string]interface{}{
+			"attempt":      attempt,
+			"max_attempts": policy.maxAttempts,
+			"backoff":      backoff.String(),
+		})
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return diags
+		}
+		backoff = min(2*backoff, policy.maxBackoff)
+	}
+}
+
+// retryDNSFailures makes the operations of r run again when they fail to
+// resolve the host of Mimir and the provider `retry_dns_failures` is set.
+func retryDNSFailures(r *schema.Resource) {
+	wrap := func(f func(context.Context, *schema.ResourceData, any) diag.Diagnostics) func(context.Context, *schema.ResourceData, any) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+			c, ok := meta.(*client)
+			if !ok || !c.retryDNSFailures {
+				return f(ctx, d, meta)
+			}
+			// A failed run may have rewritten d, e.g. a namespace write records
+			// the groups it wrote, the reruns start again from the plan.
+			planned := snapshotResourceData(r, d)
+			runs := 0
+			return retryOnDNSFailure(ctx, retryPolicyFrom(ctx, c.retry), func(ctx context.Context) diag.Diagnostics {
+				if runs++; runs > 1 {
+					planned.restore(d)
+				}
+				return f(ctx, d, meta)
+			})
+		}
+	}
+	r.CreateContext = wrap(r.CreateContext)
+	r.ReadContext = wrap(r.ReadContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+}
+
+// resourceDataSnapshot holds the ID and the values of the attributes of a
+// resource at the start of an operation.
+type resourceDataSnapshot struct {
+	id     string
+	values map[string]any
+}
+
+// snapshotResourceData returns the snapshot of d, of the schema of r.
+func snapshotResourceData(r *schema.Resource, d *schema.ResourceData) resourceDataSnapshot {
+	snapshot := resourceDataSnapshot{id: d.Id(), values: make(map[string]any, len(r.Schema))}
+	for k := range r.Schema {
+		snapshot.values[k] = d.Get(k)
+	}
+	return snapshot
+}
+
+// restore sets back on d the ID and the values of the attributes changed
+// since the snapshot.
+func (s resourceDataSnapshot) restore(d *schema.ResourceData) {
+	for k, v := range s.values {
+		if !reflect.DeepEqual(d.Get(k), v) {
+			d.Set(k, v)
+		}
+	}
+	d.SetId(s.id)
+}
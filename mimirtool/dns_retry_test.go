@@ -0,0 +1,137 @@
+package mimirtool
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"gopkg.in/yaml.v3"
+)
+
+// flakyDNSTransport fails the resolution of the first failures calls.
+type flakyDNSTransport struct {
+	failures int
+}
+
+func (t *flakyDNSTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.failures > 0 {
+		t.failures--
+		return nil, &net.DNSError{Err: "no such host", Name: req.URL.Hostname(), IsNotFound: true}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestRetryOnDNSFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/invalid" {
+			http.Error(w, "invalid", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	policy := retryPolicy{maxAttempts: 3, backoff: time.Millisecond, maxBackoff: time.Millisecond}
+
+	for _, tc := range []struct {
+		name     string
+		failures int
+		path     string
+		runs     int
+		failed   bool
+	}{
+		{name: "resolved", runs: 1},
+		{name: "resolved after failures", failures: 2, runs: 3},
+		{name: "attempts exhausted", failures: 5, runs: 3, failed: true},
+		{name: "other failure", path: "/invalid", runs: 1, failed: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			httpClient := &http.Client{Transport: &dnsFailureTransport{base: &flakyDNSTransport{failures: tc.failures}}}
+			runs := 0
+			diags := retryOnDNSFailure(context.Background(), policy, func(ctx context.Context) diag.Diagnostics {
+				runs++
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+tc.path, nil)
+				resp, err := httpClient.Do(req)
+				if err != nil {
+					return diag.FromErr(err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					return diag.Errorf("unexpected status %s", resp.Status)
+				}
+				return nil
+			})
+			if runs != tc.runs || diags.HasError() != tc.failed {
+				t.Fatalf("expected %d runs and failed %t, got %d runs and %v", tc.runs, tc.failed, runs, diags)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	runs := 0
+	httpClient := &http.Client{Transport: &dnsFailureTransport{base: &flakyDNSTransport{failures: 5}}}
+	retryOnDNSFailure(ctx, retryPolicy{maxAttempts: 3, backoff: time.Hour, maxBackoff: time.Hour}, func(ctx context.Context) diag.Diagnostics {
+		runs++
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		_, err := httpClient.Do(req)
+		return diag.FromErr(err)
+	})
+	if runs != 1 {
+		t.Fatalf("expected a cancelled operation not to be retried, got %d runs", runs)
+	}
+}
+
+// dnsFailingClient fails to resolve Mimir on the first writes of group.
+type dnsFailingClient struct {
+	*fakeMimirClient
+	group    string
+	failures int
+}
+
+func (c *dnsFailingClient) CreateRuleGroup(ctx context.Context, namespace string, rg rwrulefmt.RuleGroup) error {
+	if rg.Name == c.group && c.failures > 0 {
+		c.failures--
+		if failure, ok := ctx.Value(dnsFailureKey{}).(*dnsFailure); ok {
+			failure.last.Store(true)
+		}
+		return &net.DNSError{Err: "no such host", Name: "mimir", IsNotFound: true}
+	}
+	return c.fakeMimirClient.CreateRuleGroup(ctx, namespace, rg)
+}
+
+func TestRetryDNSFailuresRestoresPlan(t *testing.T) {
+	group := func(name, version string) string {
+		return fmt.Sprintf("  - name: %s\n    rules:\n      - record: %s:version\n        expr: vector(%s)\n", name, name, version)
+	}
+	oldYAML := normalizeNamespaceYAML("groups:\n" + group("a", "1") + group("b", "1"))
+	newYAML := "groups:\n" + group("a", "2") + group("b", "2") + group("c", "2")
+	fake := newFakeMimirClient()
+	ruleNamespace, _ := getRuleNamespaceFromYAML(context.Background(), nil, oldYAML, true)
+	for _, g := range ruleNamespace.Groups {
+		fake.CreateRuleGroup(context.Background(), "demo", g)
+	}
+	meta := &client{
+		// The second group fails all the attempts of the first run, after the
+		// first group was written.
+		cli:              &dnsFailingClient{fakeMimirClient: fake, group: "b", failures: 3},
+		retryDNSFailures: true,
+		retry:            retryPolicy{maxAttempts: 3, backoff: time.Millisecond, maxBackoff: time.Millisecond},
+	}
+	r := resourceRulerNamespace()
+	retryDNSFailures(r)
+
+	d := testRulerNamespaceUpdateData(t, meta, oldYAML, newYAML)
+	if diags := r.UpdateContext(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	remote, _ := fake.ListRules(context.Background(), "demo")
+	content, _ := yaml.Marshal(rules.RuleNamespace{Groups: remote["demo"]})
+	if !diffNamespaceYAML("config_yaml", normalizeNamespaceYAML(newYAML), string(content), nil) {
+		t.Fatalf("expected the rerun to push the planned namespace, got:\n%s", content)
+	}
+}
@@ -0,0 +1,136 @@
+package mimirtool
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	mimirtool "github.com/grafana/mimir/pkg/mimirtool/client"
+)
+
+// Errors returned by the client wrapper, test them with errors.Is rather than
+// matching messages which are worded by the mimirtool client.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrTooLarge     = errors.New("too large")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// apiError is an error of the mimirtool client along with its class, it keeps
+// the message of the original error and matches both of them.
+type apiError struct {
+	class error
+	err   error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func (e *apiError) Unwrap() []error {
+	return []error{e.class, e.err}
+}
+
+// The mimirtool client reports HTTP errors other than 404 as
+// "server returned HTTP status: <code> <text>, body: <body>".
+var httpStatusRegexp = regexp.MustCompile(`server returned HTTP status: (\d{3})`)
+
+// classifyError wraps an error of the mimirtool client with the sentinel
+// error matching the Mimir response. Errors which cannot be classified are
+// returned as is.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *apiError
+	if errors.As(err, &classified) {
+		return err
+	}
+	if errors.Is(err, mimirtool.ErrResourceNotFound) {
+		return &apiError{class: ErrNotFound, err: err}
+	}
+
+	match := httpStatusRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	status, _ := strconv.Atoi(match[1])
+	var class error
+	switch {
+	case status == 404:
+		class = ErrNotFound
+	case status == 401 || status == 403:
+		class = ErrUnauthorized
+	case status == 409:
+		class = ErrConflict
+	case status == 413:
+		class = ErrTooLarge
+	case status == 429:
+		class = ErrRateLimited
+	case status == 400 && isLimitExceeded(err.Error()):
+		// Mimir rejects rule groups and alertmanager configurations over the
+		// tenant limits with a plain 400.
+		class = ErrTooLarge
+	default:
+		return err
+	}
+	return &apiError{class: class, err: err}
+}
+
+func isLimitExceeded(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "too big") || (strings.Contains(msg, "limit") && strings.Contains(msg, "exceeded"))
+}
+
+// Authentication mechanisms, as selected by the mimirtool client.
+const (
+	authBasic  = "basic auth"
+	authBearer = "bearer token"
+	authNone   = "none"
+)
+
+// authMechanism returns the authentication mechanism used for cfg, basic auth
+// taking precedence over the token as in the mimirtool client, and the token
+// file and command over both as they are set by the transport.
+func authMechanism(cfg clientConfig) string {
+	switch {
+	case cfg.authTokenFile != "" || len(cfg.credentialCommand) > 0:
+		return authBearer
+	case cfg.User != "" || cfg.Key != "":
+		return authBasic
+	case cfg.AuthToken != "":
+		return authBearer
+	default:
+		return authNone
+	}
+}
+
+// describeAuthError adds to an ErrUnauthorized error the authentication in use
+// and a hint about the likely cause. Only the names of the mechanisms are
+// reported, never the credentials.
+func describeAuthError(err error, auth string, tenantSent bool) error {
+	tenant := "not sent"
+	if tenantSent {
+		tenant = "sent"
+	}
+
+	msg := err.Error()
+	var hint string
+	switch {
+	case strings.Contains(msg, "no org id"):
+		hint = "the server reported 'no org id', set the provider `tenant_id` or the `MIMIRTOOL_TENANT_ID` or `MIMIR_TENANT_ID` environment variable"
+	case strings.Contains(msg, "403"):
+		hint = "the credentials were accepted but are not allowed to access this endpoint or tenant, check the permissions they were granted"
+	case auth == authNone:
+		hint = "no credentials are configured, set the provider `api_user` and `api_key` or `auth_token`"
+	case auth == authBasic:
+		hint = "check the provider `api_user` and `api_key`"
+	case auth == authBearer:
+		hint = "check the provider `auth_token` or `auth_token_file`, it may have expired"
+	}
+	return fmt.Errorf("%w\nauthentication: %s, tenant header: %s\nhint: %s", err, auth, tenant, hint)
+}
@@ -0,0 +1,81 @@
+package mimirtool
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	mimirtool "github.com/grafana/mimir/pkg/mimirtool/client"
+)
+
+func TestClassifyError(t *testing.T) {
+	// Errors of the mimirtool client for responses recorded from Mimir.
+	for _, tc := range []struct {
+		err  error
+		want error
+	}{
+		{mimirtool.ErrResourceNotFound, ErrNotFound},
+		{errors.New(`server returned HTTP status: 401 Unauthorized, body: "no org id\n"`), ErrUnauthorized},
+		{errors.New(`server returned HTTP status: 403 Forbidden, body: "forbidden\n"`), ErrUnauthorized},
+		{errors.New(`server returned HTTP status: 409 Conflict, body: "conflict\n"`), ErrConflict},
+		{errors.New(`server returned HTTP status: 413 Request Entity Too Large, body: "http: request body too large\n"`), ErrTooLarge},
+		{errors.New(`server returned HTTP status: 400 Bad Request, body: "per-user rules per rule group limit (limit: 20 actual: 21) exceeded\n"`), ErrTooLarge},
+		{errors.New(`server returned HTTP status: 400 Bad Request, body: "Alertmanager configuration is too big, limit: 10240 bytes\n"`), ErrTooLarge},
+		{errors.New(`server returned HTTP status: 429 Too Many Requests, body: "per-tenant request rate limit exceeded\n"`), ErrRateLimited},
+		{errors.New(`server returned HTTP status: 400 Bad Request, body: "invalid rules config: rule group name must not be empty\n"`), nil},
+		{errors.New(`server returned HTTP status: 500 Internal Server Error, body: "internal error\n"`), nil},
+		{errors.New(`dial tcp 127.0.0.1:9009: connect: connection refused`), nil},
+	} {
+		err := classifyError(tc.err)
+		for _, class := range []error{ErrNotFound, ErrConflict, ErrTooLarge, ErrUnauthorized, ErrRateLimited} {
+			if errors.Is(err, class) != (class == tc.want) {
+				t.Errorf("%q: errors.Is(%q) = %v", tc.err, class, errors.Is(err, class))
+			}
+		}
+		if !errors.Is(err, tc.err) || err.Error() != tc.err.Error() {
+			t.Errorf("%q: the original error should be kept, got %q", tc.err, err)
+		}
+	}
+}
+
+func TestClassifyErrorWrapped(t *testing.T) {
+	err := classifyError(fmt.Errorf("mimirtool_ruler_namespace: %w", mimirtool.ErrResourceNotFound))
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a wrapped not found error to be classified, got: %v", err)
+	}
+}
+
+func TestDescribeAuthError(t *testing.T) {
+	for _, tc := range []struct {
+		body       string
+		auth       string
+		tenantSent bool
+		want       string
+	}{
+		{`401 Unauthorized, body: "no org id\n"`, authNone, false, "set the provider `tenant_id`"},
+		{`401 Unauthorized, body: "invalid credentials\n"`, authNone, true, "no credentials are configured"},
+		{`401 Unauthorized, body: "invalid credentials\n"`, authBasic, true, "check the provider `api_user` and `api_key`"},
+		{`401 Unauthorized, body: "token expired\n"`, authBearer, true, "check the provider `auth_token`"},
+		{`403 Forbidden, body: "forbidden\n"`, authBearer, true, "not allowed to access"},
+	} {
+		err := describeAuthError(classifyError(errors.New("server returned HTTP status: "+tc.body)), tc.auth, tc.tenantSent)
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected hint %q, got: %s", tc.body, tc.want, err)
+		}
+		if !strings.Contains(err.Error(), "authentication: "+tc.auth) {
+			t.Errorf("%s: expected the mechanism to be reported, got: %s", tc.body, err)
+		}
+		if !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("%s: expected the error to stay classified", tc.body)
+		}
+	}
+}
+
+func TestAuthMechanismNeverLeaksCredentials(t *testing.T) {
+	cfg := mimirtool.Config{User: "user", Key: "s3cr3t-key", AuthToken: "s3cr3t-token"}
+	err := describeAuthError(classifyError(errors.New(`server returned HTTP status: 401 Unauthorized, body: ""`)), authMechanism(clientConfig{Config: cfg}), false)
+	if strings.Contains(err.Error(), "s3cr3t") {
+		t.Fatalf("credentials leaked in: %s", err)
+	}
+}
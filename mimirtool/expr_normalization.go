@@ -0,0 +1,39 @@
+package mimirtool
+
+import (
+	"fmt"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// lintExpressions rewrites the expressions of ruleNamespace, which it
+// modifies, in the canonical form of the PromQL parser. Unlike the linter of
+// mimirtool it doesn't stop at the first expression the parser rejects, those
+// are kept as written and returned as warnings.
+func lintExpressions(ruleNamespace rules.RuleNamespace) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, group := range ruleNamespace.Groups {
+		for j, rule := range group.Rules {
+			expr, err := parser.ParseExpr(rule.Expr.Value)
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Warning,
+					Summary:  "Expression kept as written.",
+					Detail:   fmt.Sprintf("The expression of rule %q of group %q cannot be parsed, it is kept as written rather than in its canonical form: %s", ruleName(rule), group.Name, err),
+				})
+				continue
+			}
+			group.Rules[j].Expr.Value = expr.String()
+		}
+	}
+	return diags
+}
+
+// canonicalExpressions returns a copy of ruleNamespace whose expressions are
+// in their canonical form, along with warnings for the ones kept as written.
+func canonicalExpressions(ruleNamespace rules.RuleNamespace) (rules.RuleNamespace, diag.Diagnostics) {
+	res := copyRuleNamespace(ruleNamespace)
+	return res, lintExpressions(res)
+}
@@ -0,0 +1,56 @@
+package mimirtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestLintExpressions(t *testing.T) {
+	ruleNamespace := mustRuleNamespace(t, `groups:
+  - name: group
+    rules:
+      - record: job:up:sum
+        expr: sum  by (job)   (up)
+      - alert: Unparsable
+        expr: sum(up
+      - alert: Down
+        expr: up   ==   0
+`)
+	diags := lintExpressions(ruleNamespace)
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, `"Unparsable"`) {
+		t.Fatalf("expected a warning for the unparsable expression, got %v", diags)
+	}
+	rules := ruleNamespace.Groups[0].Rules
+	if rules[0].Expr.Value != "sum by (job) (up)" || rules[1].Expr.Value != "sum(up" || rules[2].Expr.Value != "up == 0" {
+		t.Fatalf("expected the expressions after the unparsable one to be linted, got %q, %q, %q", rules[0].Expr.Value, rules[1].Expr.Value, rules[2].Expr.Value)
+	}
+}
+
+func TestRulerNamespaceNormalizeExpr(t *testing.T) {
+	for _, normalize := range []bool{false, true} {
+		fake := newFakeMimirClient()
+		d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{
+			"namespace":      "demo",
+			"config_yaml":    "groups:\n  - name: group\n    rules:\n      - record: job:up:sum\n        expr: sum  by (job)   (up)\n",
+			"normalize_expr": normalize,
+		})
+		source := d.Get("config_yaml").(string)
+		if diags := rulerNamespaceCreate(context.Background(), d, &client{cli: fake}); diags.HasError() {
+			t.Fatal(diags)
+		}
+		remote, _ := fake.ListRules(context.Background(), "demo")
+		expected := "sum  by (job)   (up)"
+		if normalize {
+			expected = "sum by (job) (up)"
+		}
+		if got := remote["demo"][0].Rules[0].Expr.Value; got != expected {
+			t.Errorf("normalize_expr=%t: expected %q to be pushed, got %q", normalize, expected, got)
+		}
+		if parsed, _ := parseNamespace(source).decodedNamespace(); parsed.Groups[0].Rules[0].Expr.Value != "sum  by (job)   (up)" {
+			t.Errorf("normalize_expr=%t: the parsed source was modified", normalize)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// operationEndpoint remembers the endpoint serving the calls of an operation.
+type operationEndpoint struct {
+	index atomic.Int32
+}
+
+type operationEndpointKey struct{}
+
+// withOperationEndpoint returns ctx along with the memory of the endpoint
+// serving its calls, which start with the primary one.
+func withOperationEndpoint(ctx context.Context) (context.Context, *operationEndpoint) {
+	e := &operationEndpoint{}
+	return context.WithValue(ctx, operationEndpointKey{}, e), e
+}
+
+// failoverTransport sends the requests made to the primary address of Mimir
+// to the fallback ones when it cannot be reached or is unavailable. The last
+// endpoint reached is remembered for the remainder of the operation of the
+// request context. A request only fails over to the endpoints after the
+// remembered one, so that the retries don't go through all of them each time.
+type failoverTransport struct {
+	base http.RoundTripper
+	// endpoints are the primary address followed by the fallback ones.
+	endpoints []*url.URL
+}
+
+func newFailoverTransport(base http.RoundTripper, address string, fallbacks []string) (http.RoundTripper, error) {
+	if len(fallbacks) == 0 {
+		return base, nil
+	}
+	t := &failoverTransport{base: base}
+	for _, address := range append([]string{address}, fallbacks...) {
+		u, err := url.Parse(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", address, err)
+		}
+		t.endpoints = append(t.endpoints, u)
+	}
+	return t, nil
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	remembered, ok := req.Context().Value(operationEndpointKey{}).(*operationEndpoint)
+	if !ok {
+		remembered = &operationEndpoint{}
+	}
+	start := int(remembered.index.Load())
+	for i := start; ; i++ {
+		endpointReq, err := t.request(req, i)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(endpointReq)
+		last := i == len(t.endpoints)-1 || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil)
+		if last || !shouldFailover(req.Context(), resp, err) {
+			if i != start {
+				remembered.index.CompareAndSwap(int32(start), int32(i))
+			}
+			tflog.Debug(req.Context(), "Grafana Mimir endpoint of the call", map[string]interface{}{
+				"endpoint": t.endpoints[i].Redacted(),
+				"method":   req.Method,
+				"path":     req.URL.Path,
+			})
+			return resp, err
+		}
+
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			resp.Body.Close()
+		}
+		tflog.Warn(req.Context(), "Grafana Mimir endpoint failed, failing over to the next one", map[string]interface{}{
+			"endpoint": t.endpoints[i].Redacted(),
+			"next":     t.endpoints[i+1].Redacted(),
+			"reason":   reason,
+		})
+	}
+}
+
+// request returns req sent to the endpoint i, the path below the primary
+// address being kept.
+func (t *failoverTransport) request(req *http.Request, i int) (*http.Request, error) {
+	if i == 0 {
+		return req, nil
+	}
+	primary, endpoint := t.endpoints[0], t.endpoints[i]
+	if !strings.HasPrefix(req.URL.Path, primary.Path) {
+		// Not a request of the primary address.
+		return req, nil
+	}
+	u := *req.URL
+	u.Scheme, u.Host, u.User = endpoint.Scheme, endpoint.Host, endpoint.User
+	u.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, primary.Path), "/")
+	if req.URL.RawPath != "" {
+		u.RawPath = strings.TrimSuffix(endpoint.EscapedPath(), "/") + "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.RawPath, primary.EscapedPath()), "/")
+	}
+
+	res := req.Clone(req.Context())
+	res.URL, res.Host = &u, ""
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		res.Body = body
+	}
+	return res, nil
+}
+
+// shouldFailover tells whether the endpoint which answered resp, or failed
+// with err, is unreachable or unavailable. Errors of Mimir itself, such as
+// the 4xx ones, are not.
+func shouldFailover(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, errRedirect)
+	}
+	return resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// trackOperationEndpoint makes the calls of every operation of r remember the
+// endpoint of Mimir they reached, and logs it when it isn't the primary one.
+func trackOperationEndpoint(r *schema.Resource) {
+	wrap := func(f func(context.Context, *schema.ResourceData, any) diag.Diagnostics) func(context.Context, *schema.ResourceData, any) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+			ctx, endpoint := withOperationEndpoint(ctx)
+			diags := f(ctx, d, meta)
+			if c, ok := meta.(*client); ok {
+				if i := int(endpoint.index.Load()); i > 0 && i <= len(c.config.fallbackAddresses) {
+					address := c.config.fallbackAddresses[i-1]
+					if u, err := url.Parse(address); err == nil {
+						address = u.Redacted()
+					}
+					tflog.Info(ctx, "Operation served by a fallback Grafana Mimir endpoint", map[string]interface{}{
+						"endpoint": address,
+					})
+				}
+			}
+			return diags
+		}
+	}
+	r.CreateContext = wrap(r.CreateContext)
+	r.ReadContext = wrap(r.ReadContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+}
@@ -0,0 +1,103 @@
+package mimirtool
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFailoverTransport(t *testing.T) {
+	var primaryStatus atomic.Int32
+	var primaryCalls, fallbackCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.WriteHeader(int(primaryStatus.Load()))
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackCalls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Header.Get("X-Scope-OrgID") + " " + string(body)))
+	}))
+	defer fallback.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	transport, err := newFailoverTransport(http.DefaultTransport, primary.URL, []string{unreachable.URL, fallback.URL + "/mimir/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient := &http.Client{Transport: transport}
+	do := func(ctx context.Context) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, primary.URL+"/config/v1/rules/demo", strings.NewReader("name: group"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Scope-OrgID", "tenant")
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Mimir errors don't fail over.
+	primaryStatus.Store(http.StatusBadRequest)
+	if resp := do(context.Background()); resp.StatusCode != http.StatusBadRequest || fallbackCalls.Load() != 0 {
+		t.Fatalf("expected the 400 of the primary endpoint, got %s and %d fallback calls", resp.Status, fallbackCalls.Load())
+	}
+
+	primaryStatus.Store(http.StatusServiceUnavailable)
+	ctx, endpoint := withOperationEndpoint(context.Background())
+	for i := 0; i < 2; i++ {
+		resp := do(ctx)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if want := "POST /mimir/config/v1/rules/demo tenant name: group"; string(body) != want {
+			t.Fatalf("expected %q from the fallback endpoint, got %s %q", want, resp.Status, body)
+		}
+	}
+	if primaryCalls.Load() != 2 || endpoint.index.Load() != 2 {
+		t.Fatalf("expected the fallback endpoint to be kept for the operation, got %d primary calls", primaryCalls.Load())
+	}
+
+	// Another operation starts with the primary endpoint.
+	primaryStatus.Store(http.StatusOK)
+	if resp := do(context.Background()); resp.StatusCode != http.StatusOK || primaryCalls.Load() != 3 {
+		t.Fatalf("expected the primary endpoint to serve the operation, got %s", resp.Status)
+	}
+}
+
+func TestFailoverRetries(t *testing.T) {
+	var calls atomic.Int32
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	transport, err := newFailoverTransport(http.DefaultTransport, unavailable.URL, []string{unavailable.URL + "/a", unavailable.URL + "/b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &client{
+		cli:         newFakeMimirClient(),
+		httpClients: map[string]*http.Client{"": {Transport: transport}},
+		retry:       retryPolicy{maxAttempts: 3, backoff: time.Millisecond, maxBackoff: time.Millisecond},
+	}
+	c.config.Address = unavailable.URL
+
+	ctx, _ := withOperationEndpoint(context.Background())
+	if _, err := c.apiGet(ctx, "mimirtool_ruler_namespace", "/api/v1/rules", nil); err == nil {
+		t.Fatal("expected the call to fail")
+	}
+	// The three endpoints once, then the two retries on the last one.
+	if calls.Load() != 5 {
+		t.Fatalf("expected 5 requests, got %d", calls.Load())
+	}
+}
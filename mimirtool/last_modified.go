@@ -0,0 +1,60 @@
+package mimirtool
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// lastModified records the latest Last-Modified header of the successful
+// responses of Mimir during a read.
+type lastModified struct {
+	mu    sync.Mutex
+	value time.Time
+}
+
+type lastModifiedKey struct{}
+
+// withLastModified returns ctx along with the recorder of the Last-Modified
+// headers of the calls made with it.
+func withLastModified(ctx context.Context) (context.Context, *lastModified) {
+	l := &lastModified{}
+	return context.WithValue(ctx, lastModifiedKey{}, l), l
+}
+
+// String returns the time recorded as RFC 3339, empty when none was.
+func (l *lastModified) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.value.IsZero() {
+		return ""
+	}
+	return l.value.UTC().Format(time.RFC3339)
+}
+
+// lastModifiedTransport records the Last-Modified header of the responses for
+// the read of the request context.
+type lastModifiedTransport struct {
+	base http.RoundTripper
+}
+
+func (t *lastModifiedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	l, ok := req.Context().Value(lastModifiedKey{}).(*lastModified)
+	if !ok || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, nil
+	}
+	// Missing or invalid headers are ignored, Mimir itself doesn't set it.
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		l.mu.Lock()
+		if modified.After(l.value) {
+			l.value = modified
+		}
+		l.mu.Unlock()
+	}
+	return resp, nil
+}
@@ -0,0 +1,51 @@
+package mimirtool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLastModifiedTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			w.Header().Set("Last-Modified", "Tue, 01 Oct 2024 08:00:00 GMT")
+		case "/new":
+			w.Header().Set("Last-Modified", "Wed, 02 Oct 2024 10:30:00 GMT")
+		case "/invalid":
+			w.Header().Set("Last-Modified", "yesterday")
+		case "/missing":
+			w.Header().Set("Last-Modified", "Thu, 03 Oct 2024 10:30:00 GMT")
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	httpClient := &http.Client{Transport: &lastModifiedTransport{base: http.DefaultTransport}}
+
+	for _, tc := range []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{name: "not reported", paths: []string{"/none", "/invalid"}},
+		{name: "reported", paths: []string{"/old"}, want: "2024-10-01T08:00:00Z"},
+		{name: "latest kept", paths: []string{"/new", "/old", "/missing"}, want: "2024-10-02T10:30:00Z"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, modified := withLastModified(context.Background())
+			for _, path := range tc.paths {
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+				resp, err := httpClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+			}
+			if got := modified.String(); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
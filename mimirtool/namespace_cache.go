@@ -0,0 +1,183 @@
+package mimirtool
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"gopkg.in/yaml.v3"
+)
+
+// Bounds of a namespace cache. A plan needs a few configurations per
+// resource: the source, the state and the planned value. The size counts the
+// configurations only, their representations take a few times more.
+const (
+	namespaceCacheSize  = 16
+	namespaceCacheBytes = 64 << 20
+)
+
+// parsedNamespace memoizes the representations of a namespace configuration,
+// so that the validation, the diff suppression and the push of an operation
+// parse it once. They are shared and must not be modified.
+type parsedNamespace struct {
+	configYAML string
+
+	documentOnce sync.Once
+	document     yaml.Node
+	documentErr  error
+
+	validateOnce sync.Once
+	validated    rules.RuleNamespace
+	validateErr  error
+
+	uncheckedOnce sync.Once
+	unchecked     rules.RuleNamespace
+	uncheckedErr  error
+
+	decodeOnce sync.Once
+	decoded    rules.RuleNamespace
+	decodeErr  error
+
+	normalizeOnce sync.Once
+	normalized    string
+
+	fieldsOnce sync.Once
+	fields     map[string]groupFields
+}
+
+// namespaceCache keeps the parsedNamespace of the configurations used last,
+// within namespaceCacheSize and namespaceCacheBytes. The content is the key,
+// which saves hashing it.
+type namespaceCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// recent holds the entries, the most recently used first.
+	recent list.List
+	bytes  int
+}
+
+// schemaNamespaces is the cache of the schema functions of the namespaces,
+// e.g. StateFunc, which get no provider instance. The operations use the
+// cache of their provider instance.
+var schemaNamespaces = &namespaceCache{}
+
+// namespacesOf returns the namespace cache of meta, the one of the schema
+// functions when the provider is not configured.
+func namespacesOf(meta any) *namespaceCache {
+	if c, ok := meta.(*client); ok && c.namespaces != nil {
+		return c.namespaces
+	}
+	return schemaNamespaces
+}
+
+// parseNamespace returns the memoized representations of configYAML, as
+// cached for the schema functions.
+func parseNamespace(configYAML string) *parsedNamespace {
+	return schemaNamespaces.parse(configYAML)
+}
+
+// parse returns the memoized representations of configYAML. A nil cache is
+// the one of the schema functions.
+func (n *namespaceCache) parse(configYAML string) *parsedNamespace {
+	if n == nil {
+		n = schemaNamespaces
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if e, ok := n.entries[configYAML]; ok {
+		n.recent.MoveToFront(e)
+		return e.Value.(*parsedNamespace)
+	}
+	p := &parsedNamespace{configYAML: configYAML}
+	if len(configYAML) > namespaceCacheBytes {
+		return p
+	}
+	if n.entries == nil {
+		n.entries = make(map[string]*list.Element, namespaceCacheSize)
+	}
+	for n.recent.Len() >= namespaceCacheSize || n.bytes+len(configYAML) > namespaceCacheBytes {
+		oldest := n.recent.Remove(n.recent.Back()).(*parsedNamespace)
+		delete(n.entries, oldest.configYAML)
+		n.bytes -= len(oldest.configYAML)
+	}
+	n.entries[configYAML] = n.recent.PushFront(p)
+	n.bytes += len(configYAML)
+	return p
+}
+
+// reset drops the memoized namespaces.
+func (n *namespaceCache) reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.entries = nil
+	n.recent.Init()
+	n.bytes = 0
+}
+
+// validatedNamespace returns the namespace as parsed and validated by the
+// mimirtool parser.
+func (p *parsedNamespace) validatedNamespace() (rules.RuleNamespace, error) {
+	p.validateOnce.Do(func() {
+		p.validated, p.validateErr = parseRuleNamespace(p.configYAML, true)
+	})
+	return p.validated, p.validateErr
+}
+
+// uncheckedNamespace returns the namespace as decoded by the mimirtool
+// parser, without the validation of its rules.
+func (p *parsedNamespace) uncheckedNamespace() (rules.RuleNamespace, error) {
+	p.uncheckedOnce.Do(func() {
+		p.unchecked, p.uncheckedErr = parseRuleNamespace(p.configYAML, false)
+	})
+	return p.unchecked, p.uncheckedErr
+}
+
+// parsedDocument returns the YAML document of the configuration, the other
+// representations are decoded from it rather than parsing the text again.
+func (p *parsedNamespace) parsedDocument() (*yaml.Node, error) {
+	p.documentOnce.Do(func() {
+		p.documentErr = yaml.Unmarshal([]byte(p.configYAML), &p.document)
+	})
+	return &p.document, p.documentErr
+}
+
+// decodedNamespace returns the namespace as decoded, without validation.
+func (p *parsedNamespace) decodedNamespace() (rules.RuleNamespace, error) {
+	p.decodeOnce.Do(func() {
+		p.decoded, p.decodeErr = p.decodeNamespace()
+	})
+	return p.decoded, p.decodeErr
+}
+
+func (p *parsedNamespace) decodeNamespace() (rules.RuleNamespace, error) {
+	var ruleNamespace rules.RuleNamespace
+	doc, err := p.parsedDocument()
+	if err != nil {
+		return ruleNamespace, err
+	}
+	// An empty document decodes to the zero value, as with yaml.Unmarshal.
+	if len(doc.Content) == 0 {
+		return ruleNamespace, nil
+	}
+	return ruleNamespace, doc.Decode(&ruleNamespace)
+}
+
+// normalizedYAML returns the canonical form of the configuration.
+func (p *parsedNamespace) normalizedYAML() string {
+	p.normalizeOnce.Do(func() {
+		// Linting rewrites the expressions, work on a copy of our own.
+		ruleNamespace, _ := p.decodeNamespace()
+		p.normalized = withUnknownGroupFields(normalizeRuleNamespace(ruleNamespace), p.groupFields())
+	})
+	return p.normalized
+}
+
+// groupFields returns the unknown fields of the groups.
+func (p *parsedNamespace) groupFields() map[string]groupFields {
+	p.fieldsOnce.Do(func() {
+		if doc, err := p.parsedDocument(); err == nil {
+			p.fields = documentGroupFields(doc, "groups")
+		}
+	})
+	return p.fields
+}
@@ -0,0 +1,105 @@
+package mimirtool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+)
+
+// largeNamespaceYAML builds a namespace of groups rules, as a large fixture.
+func largeNamespaceYAML(groups, rules int) string {
+	var b strings.Builder
+	b.WriteString("groups:\n")
+	for g := 0; g < groups; g++ {
+		fmt.Fprintf(&b, "  - name: group-%d\n    interval: 1m\n    rules:\n", g)
+		for r := 0; r < rules; r++ {
+			fmt.Fprintf(&b, "      - record: job:metric_%d:rate5m\n        expr: sum by (job) (rate(metric_%d_total{group=\"%d\"}[5m]))\n        labels:\n          team: group-%d\n", r, r, g, g)
+		}
+	}
+	return b.String()
+}
+
+// BenchmarkNamespacePlan runs what a plan of an unchanged namespace does:
+// validation, normalization and diff suppression, then the parsing of the
+// push.
+func BenchmarkNamespacePlan(b *testing.B) {
+	source := largeNamespaceYAML(200, 50)
+	state := normalizeNamespaceYAML(source)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		schemaNamespaces.reset()
+		validateNamespaceYAML(source, nil)
+		planned := normalizeNamespaceYAML(source)
+		if !diffNamespaceYAML("config_yaml", state, planned, nil) {
+			b.Fatal("expected no difference")
+		}
+		if _, err := getRuleNamespaceFromYAML(context.Background(), nil, source, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestNormalizeRuleNamespace(t *testing.T) {
+	source := largeNamespaceYAML(3, 2)
+	ruleNamespace, err := getRuleNamespaceFromYAML(context.Background(), nil, source, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The remote groups are normalized without going through their YAML, the
+	// result must be the same.
+	if normalizeRuleNamespace(rules.RuleNamespace{Groups: ruleNamespace.Groups}) != normalizeNamespaceYAML(source) {
+		t.Fatal("expected the remote and source normalizations to match")
+	}
+	// The memoized namespace is shared, normalizing it again must not see the
+	// changes made by the previous normalization.
+	if normalizeNamespaceYAML(normalizeNamespaceYAML(source)) != normalizeNamespaceYAML(source) {
+		t.Fatal("expected the normalization to be stable")
+	}
+}
+
+func TestNamespaceCacheBounds(t *testing.T) {
+	n := &namespaceCache{}
+	first := n.parse("groups: []\n")
+	for i := 1; i < namespaceCacheSize; i++ {
+		n.parse(fmt.Sprintf("# %d\ngroups: []\n", i))
+	}
+	// The first configuration is the most recently used, the next one is
+	// evicted in its place.
+	if n.parse("groups: []\n") != first {
+		t.Fatal("expected the cached namespace")
+	}
+	n.parse("# new\ngroups: []\n")
+	if n.recent.Len() != namespaceCacheSize {
+		t.Fatalf("expected %d cached namespaces, got %d", namespaceCacheSize, n.recent.Len())
+	}
+	if _, ok := n.entries["# 1\ngroups: []\n"]; ok {
+		t.Fatal("expected the least recently used namespace to be evicted")
+	}
+	if n.parse("groups: []\n") != first {
+		t.Fatal("expected the recently used namespace to be kept")
+	}
+
+	// The configurations larger than the cache are parsed but not kept.
+	large := strings.Repeat("#", namespaceCacheBytes+1)
+	if n.parse(large) == n.parse(large) {
+		t.Fatal("expected the large configuration not to be cached")
+	}
+	if n.bytes > namespaceCacheBytes {
+		t.Fatalf("expected at most %d cached bytes, got %d", namespaceCacheBytes, n.bytes)
+	}
+}
+
+func TestNamespaceCachePerClient(t *testing.T) {
+	source := largeNamespaceYAML(1, 1)
+	a, b := &client{namespaces: &namespaceCache{}}, &client{namespaces: &namespaceCache{}}
+	if namespacesOf(a).parse(source) == namespacesOf(b).parse(source) {
+		t.Fatal("expected the provider instances not to share their namespaces")
+	}
+	if namespacesOf(nil) != schemaNamespaces {
+		t.Fatal("expected the schema cache when the provider is not configured")
+	}
+}
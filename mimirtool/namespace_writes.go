@@ -0,0 +1,63 @@
+package mimirtool
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// namespaceWrites tracks the namespaces a provider instance is writing, so
+// that the write of a namespace can wait for the writes of its
+// depends_on_namespaces already in progress. It is a best effort, not an
+// ordering: the writes starting later are not waited for. Its zero value is
+// ready to use.
+type namespaceWrites struct {
+	mu sync.Mutex
+	// inflight holds the writes in progress by namespace key, their channel
+	// is closed once they complete.
+	inflight map[string]chan struct{}
+}
+
+// start records the write of namespace and waits for the writes of
+// dependencies, the keys of the namespaces it depends on, that are already in
+// progress. The dependencies whose write hasn't started are not waited for,
+// which also keeps namespaces depending on each other from waiting forever.
+// The returned function must be called once the write completes, including
+// when start fails because ctx is done.
+func (w *namespaceWrites) start(ctx context.Context, namespace string, dependencies []string) (func(), error) {
+	w.mu.Lock()
+	if w.inflight == nil {
+		w.inflight = map[string]chan struct{}{}
+	}
+	done := make(chan struct{})
+	waits := map[string]chan struct{}{}
+	for _, dependency := range dependencies {
+		if other, ok := w.inflight[dependency]; ok && dependency != namespace {
+			waits[dependency] = other
+		}
+	}
+	w.inflight[namespace] = done
+	w.mu.Unlock()
+
+	finish := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.inflight[namespace] == done {
+			delete(w.inflight, namespace)
+		}
+		close(done)
+	}
+	for dependency, other := range waits {
+		tflog.Debug(ctx, "Waiting for the write of a namespace dependency", map[string]interface{}{
+			"namespace":  namespace,
+			"dependency": dependency,
+		})
+		select {
+		case <-other:
+		case <-ctx.Done():
+			return finish, ctx.Err()
+		}
+	}
+	return finish, nil
+}
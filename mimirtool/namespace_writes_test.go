@@ -0,0 +1,62 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNamespaceWritesWait(t *testing.T) {
+	var writes namespaceWrites
+	finishRecording, err := writes.start(context.Background(), "team-a/recording", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	go func() {
+		finish, err := writes.start(context.Background(), "team-a/alerts", []string{"team-a/recording", "team-a/absent"})
+		if err != nil {
+			t.Error(err)
+		}
+		close(started)
+		finish()
+	}()
+	select {
+	case <-started:
+		t.Fatal("the dependent write started before its dependency completed")
+	case <-time.After(20 * time.Millisecond):
+	}
+	finishRecording()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("the dependent write didn't start once its dependency completed")
+	}
+
+	// A dependency written after its dependent doesn't wait for it, and a
+	// wait ends with its context.
+	finishAlerts, _ := writes.start(context.Background(), "team-a/alerts", []string{"team-a/recording"})
+	finishRecording, err = writes.start(context.Background(), "team-a/recording", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	finish, err := writes.start(ctx, "team-b/alerts", []string{"team-b/recording"})
+	if err != nil {
+		t.Fatal("a dependency of another tenant was waited for")
+	}
+	finish()
+	finish, err = writes.start(ctx, "team-a/other", []string{"team-a/recording"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the wait to end with the context, got %v", err)
+	}
+	finish()
+	finishRecording()
+	finishAlerts()
+	if len(writes.inflight) != 0 {
+		t.Fatalf("expected no write in progress, got %v", writes.inflight)
+	}
+}
@@ -2,6 +2,12 @@ package mimirtool
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -29,6 +35,12 @@ func init() {
 
 // New returns a newly created provider
 func New(version string) func() *schema.Provider {
+	return newProvider(version, nil)
+}
+
+// newProvider returns a provider whose Mimir clients are built by factory,
+// the mimirtool ones when nil.
+func newProvider(version string, factory clientFactory) func() *schema.Provider {
 	return func() *schema.Provider {
 		p := &schema.Provider{
 			Schema: map[string]*schema.Schema{
@@ -37,16 +49,46 @@ func New(version string) func() *schema.Provider {
 				// terraform convention will be taken into account first.
 				"address": {
 					Type:         schema.TypeString,
-					Required:     true,
+					Optional:     true,
 					DefaultFunc:  schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_ADDRESS", "MIMIR_ADDRESS"}, nil),
-					Description:  "Address to use when contacting Grafana Mimir. May alternatively be set via the `MIMIRTOOL_ADDRESS` or `MIMIR_ADDRESS` environment variable.",
+					Description:  "Address to use when contacting Grafana Mimir. Only needed once a resource has to reach the API, so `terraform validate` works without it. Redirects to the same scheme, host and port are followed, keeping the method, the body and the headers; redirects elsewhere are refused. May alternatively be set via the `MIMIRTOOL_ADDRESS` or `MIMIR_ADDRESS` environment variable.",
 					ValidateFunc: validation.IsURLWithHTTPorHTTPS,
 				},
+				"base_path": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_BASE_PATH", "MIMIR_BASE_PATH"}, ""),
+					Description: "Path Grafana Mimir is mounted under behind a reverse proxy, e.g. `/observability/mimir`. It is appended to `address` and `fallback_addresses`, so it prefixes all the API paths of the ruler and the alertmanager before `prometheus_http_prefix`. May alternatively be set via the `MIMIRTOOL_BASE_PATH` or `MIMIR_BASE_PATH` environment variable.",
+				},
+				"fallback_addresses": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.IsURLWithHTTPorHTTPS},
+					Description: "Addresses of Grafana Mimir tried in order when `address` cannot be reached or answers `502` or `503`, e.g. the passive gateway of an active/passive pair. Errors of Mimir itself, such as the `4xx` ones, don't fail over. The last endpoint reached is kept for the remainder of the operation of the resource and the calls only fail over to the addresses after it, so a call is sent at most `retry_max_attempts` plus the number of fallback addresses times.",
+				},
 				"tenant_id": {
 					Type:        schema.TypeString,
 					Optional:    true,
 					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_TENANT_ID", "MIMIR_TENANT_ID"}, nil),
-					Description: "Tenant ID to use when contacting Grafana Mimir. May alternatively be set via the `MIMIRTOOL_TENANT_ID` or `MIMIR_TENANT_ID` environment variable.",
+					Description: "Tenant ID to use when contacting Grafana Mimir. Takes precedence over `tenant_id_template`. May alternatively be set via the `MIMIRTOOL_TENANT_ID` or `MIMIR_TENANT_ID` environment variable.",
+				},
+				"tenant_id_template": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_TENANT_ID_TEMPLATE", "MIMIR_TENANT_ID_TEMPLATE"}, nil),
+					Description: "Template of the tenant ID, used when `tenant_id` is not set, e.g. `{env}-metrics`. `{env}` is replaced by `environment`, which must then be set. The rendered tenant ID must be a valid Grafana Mimir one: at most 150 letters, digits and `!-_.*'()`. May alternatively be set via the `MIMIRTOOL_TENANT_ID_TEMPLATE` or `MIMIR_TENANT_ID_TEMPLATE` environment variable.",
+				},
+				"environment": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_ENVIRONMENT", "MIMIR_ENVIRONMENT"}, nil),
+					Description: "Environment of the workspace, e.g. `staging`, available as `{env}` to `tenant_id_template`. May alternatively be set via the `MIMIRTOOL_ENVIRONMENT` or `MIMIR_ENVIRONMENT` environment variable.",
+				},
+				"verify_tenant": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_VERIFY_TENANT", "MIMIR_VERIFY_TENANT"}, false),
+					Description: "Read back every write using the configured tenant and fail when it cannot be found, which means a gateway rewrote or ignored the tenant header. May alternatively be set via the `MIMIRTOOL_VERIFY_TENANT` or `MIMIR_VERIFY_TENANT` environment variable.",
 				},
 				"api_user": {
 					Type:        schema.TypeString,
@@ -68,6 +110,53 @@ func New(version string) func() *schema.Provider {
 					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_AUTH_TOKEN", "MIMIR_AUTH_TOKEN"}, nil),
 					Description: "Authentication token for bearer token or JWT auth when contacting Grafana Mimir. May alternatively be set via the `MIMIRTOOL_AUTH_TOKEN` or `MIMIR_AUTH_TOKEN` environment variable.",
 				},
+				"tenant_credentials": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Description: "Credentials of tenants, used instead of the provider ones (`auth_token`, `auth_token_file`, `credential_command`, `api_user` and `api_key`) by the clients of the tenants listed, `tenant_id` included. The other tenants use the provider credentials. Each tenant sets either `api_user` and `api_key` or `auth_token`.",
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"tenant_id": {
+								Type:         schema.TypeString,
+								Required:     true,
+								Description:  "The tenant the credentials are for.",
+								ValidateFunc: validation.StringIsNotEmpty,
+							},
+							"api_user": {
+								Type:        schema.TypeString,
+								Optional:    true,
+								Sensitive:   true,
+								Description: "The user of basic auth, the tenant when empty.",
+							},
+							"api_key": {
+								Type:        schema.TypeString,
+								Optional:    true,
+								Sensitive:   true,
+								Description: "The key of basic auth.",
+							},
+							"auth_token": {
+								Type:        schema.TypeString,
+								Optional:    true,
+								Sensitive:   true,
+								Description: "The token of bearer token or JWT auth.",
+							},
+						},
+					},
+				},
+				"auth_token_file": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					DefaultFunc:   schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_AUTH_TOKEN_FILE", "MIMIR_AUTH_TOKEN_FILE"}, nil),
+					Description:   "Path of a file holding the authentication token, for bearer token or JWT auth. Unlike `auth_token`, the file is read again a minute before the token expires, so that a token refreshed by an external process outlives long applies. May alternatively be set via the `MIMIRTOOL_AUTH_TOKEN_FILE` or `MIMIR_AUTH_TOKEN_FILE` environment variable.",
+					ConflictsWith: []string{"auth_token", "credential_command"},
+				},
+				"credential_command": {
+					Type:          schema.TypeList,
+					Optional:      true,
+					Elem:          &schema.Schema{Type: schema.TypeString},
+					Description:   "Command, as a list of its program and arguments, printing the token to use for bearer token or JWT auth, e.g. a script wrapping a secret manager. It may print the token alone or a JSON object `{\"token\": \"...\", \"expiry\": \"<RFC 3339 time>\"}`. The command is run again a minute before the token expires, as read from `expiry` or the JWT, and is killed if it runs for more than a minute. Its output and standard error are never logged nor reported.",
+					ConflictsWith: []string{"auth_token", "auth_token_file"},
+				},
 				"tls_key_path": {
 					Type:        schema.TypeString,
 					Optional:    true,
@@ -86,6 +175,30 @@ func New(version string) func() *schema.Provider {
 					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_TLS_CA_PATH", "MIMIR_TLS_CA_PATH"}, nil),
 					Description: "Certificate CA bundle to use to verify the MIMIR server's certificate. May alternatively be set via the `MIMIRTOOL_TLS_CA_PATH` or `MIMIR_TLS_CA_PATH` environment variable.",
 				},
+				"tls_key_pem": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					Sensitive:     true,
+					DefaultFunc:   schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_TLS_KEY_PEM", "MIMIR_TLS_KEY_PEM"}, nil),
+					Description:   "Client TLS key, as PEM content, to use to authenticate to the MIMIR server, e.g. as read from a secret manager. It is never written to disk. May alternatively be set via the `MIMIRTOOL_TLS_KEY_PEM` or `MIMIR_TLS_KEY_PEM` environment variable.",
+					ConflictsWith: []string{"tls_key_path", "tls_cert_path"},
+					RequiredWith:  []string{"tls_cert_pem"},
+				},
+				"tls_cert_pem": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					DefaultFunc:   schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_TLS_CERT_PEM", "MIMIR_TLS_CERT_PEM"}, nil),
+					Description:   "Client TLS certificate, as PEM content, to use to authenticate to the MIMIR server. May alternatively be set via the `MIMIRTOOL_TLS_CERT_PEM` or `MIMIR_TLS_CERT_PEM` environment variable.",
+					ConflictsWith: []string{"tls_cert_path", "tls_key_path"},
+					RequiredWith:  []string{"tls_key_pem"},
+				},
+				"ca_cert_pem": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					DefaultFunc:   schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_CA_CERT_PEM", "MIMIR_CA_CERT_PEM"}, nil),
+					Description:   "Certificate CA bundle, as PEM content, to use to verify the MIMIR server's certificate. May alternatively be set via the `MIMIRTOOL_CA_CERT_PEM` or `MIMIR_CA_CERT_PEM` environment variable.",
+					ConflictsWith: []string{"tls_ca_path"},
+				},
 				"insecure_skip_verify": {
 					Type:        schema.TypeBool,
 					Optional:    true,
@@ -104,51 +217,479 @@ func New(version string) func() *schema.Provider {
 					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_ALERTMANAGER_HTTP_PREFIX", "MIMIR_ALERTMANAGER_HTTP_PREFIX"}, "/alertmanager"),
 					Description: "Path prefix to use for alertmanager. May alternatively be set via the `MIMIRTOOL_ALERTMANAGER_HTTP_PREFIX` or `MIMIR_ALERTMANAGER_HTTP_PREFIX` environment variable.",
 				},
+				"clock_skew_tolerance": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_CLOCK_SKEW_TOLERANCE", "MIMIR_CLOCK_SKEW_TOLERANCE"}, "0s"),
+					Description:      "How far apart the clocks of the runner and of the issuer of the tokens of `auth_token`, `auth_token_file` and `credential_command` may be, as a duration string such as `30s`. The tokens of `auth_token_file` and `credential_command` are refreshed that much earlier before their expiry, and a request whose token is rejected because of its time claims, such as `token not yet valid`, is retried once after waiting for it. The retries are logged as warnings. `0s` disables both. May alternatively be set via the `MIMIRTOOL_CLOCK_SKEW_TOLERANCE` or `MIMIR_CLOCK_SKEW_TOLERANCE` environment variable.",
+					ValidateDiagFunc: validateDuration,
+				},
+				"dial_timeout": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_DIAL_TIMEOUT", "MIMIR_DIAL_TIMEOUT"}, "10s"),
+					Description:      "Maximum time to wait for a connection to Grafana Mimir to be established, as a duration string such as `5s`. This only bounds connecting, not waiting for a response. May alternatively be set via the `MIMIRTOOL_DIAL_TIMEOUT` or `MIMIR_DIAL_TIMEOUT` environment variable.",
+					ValidateDiagFunc: validateDuration,
+				},
+				"validate_rule_dependencies": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_VALIDATE_RULE_DEPENDENCIES", "MIMIR_VALIDATE_RULE_DEPENDENCIES"}, false),
+					Description: "Warn when an alert of a ruler namespace references a metric which looks like a recording rule output (`level:metric:operations`) but isn't recorded by any namespace of the tenant. Opt-in as naming conventions aren't always followed. May alternatively be set via the `MIMIRTOOL_VALIDATE_RULE_DEPENDENCIES` or `MIMIR_VALIDATE_RULE_DEPENDENCIES` environment variable.",
+				},
+				"max_concurrent_operations": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					DefaultFunc:  schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_MAX_CONCURRENT_OPERATIONS", "MIMIR_MAX_CONCURRENT_OPERATIONS"}, 0),
+					Description:  "Maximum number of write operations sent to Grafana Mimir at the same time, regardless of Terraform's `-parallelism`. Reads are not limited. `0` means no limit. May alternatively be set via the `MIMIRTOOL_MAX_CONCURRENT_OPERATIONS` or `MIMIR_MAX_CONCURRENT_OPERATIONS` environment variable.",
+					ValidateFunc: validation.IntAtLeast(0),
+				},
+				"id_scheme": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					DefaultFunc:  schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_ID_SCHEME", "MIMIR_ID_SCHEME"}, idSchemeNamespace),
+					Description:  "Format of the ID of `mimirtool_ruler_namespace` resources: `namespace` or `tenant/namespace`, which keeps IDs unique when the same namespace exists under several tenants. Existing IDs are migrated on refresh. May alternatively be set via the `MIMIRTOOL_ID_SCHEME` or `MIMIR_ID_SCHEME` environment variable.",
+					ValidateFunc: validation.StringInSlice([]string{idSchemeNamespace, idSchemeTenantNamespace}, false),
+				},
+				"namespace_name_pattern": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					DefaultFunc:  schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_NAMESPACE_NAME_PATTERN", "MIMIR_NAMESPACE_NAME_PATTERN"}, nil),
+					Description:  "Regular expression the whole name of the `mimirtool_ruler_namespace` resources must match, e.g. `[a-z]+-[a-z]+` for `{team}-{purpose}` names. Checked at plan time and on import. No check when unset. May alternatively be set via the `MIMIRTOOL_NAMESPACE_NAME_PATTERN` or `MIMIR_NAMESPACE_NAME_PATTERN` environment variable.",
+					ValidateFunc: validation.StringIsValidRegExp,
+				},
+				"fast_refresh": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_FAST_REFRESH", "MIMIR_FAST_REFRESH"}, false),
+					Description: "On refresh, only list the groups of `mimirtool_ruler_namespace` resources through the Prometheus rules API and skip downloading their content when the groups are the ones in the state. Changes made out of band to the rules of an existing group are then not detected until a full read, which happens whenever the resource is created or updated, or when this option is disabled. May alternatively be set via the `MIMIRTOOL_FAST_REFRESH` or `MIMIR_FAST_REFRESH` environment variable.",
+				},
+				"warn_deprecated": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_WARN_DEPRECATED", "MIMIR_WARN_DEPRECATED"}, false),
+					Description: "Warn about the rules of `mimirtool_ruler_namespace` resources using constructs deprecated by Grafana Mimir or Prometheus, e.g. the `evaluation_delay` group field, along with how to migrate. The warnings don't fail the apply. May alternatively be set via the `MIMIRTOOL_WARN_DEPRECATED` or `MIMIR_WARN_DEPRECATED` environment variable.",
+				},
+				"rollback_on_failure": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_ROLLBACK_ON_FAILURE", "MIMIR_ROLLBACK_ON_FAILURE"}, false),
+					Description: "Capture the content of a `mimirtool_ruler_namespace` before writing its groups and restore it, best-effort, when one of the writes fails, instead of leaving the namespace partially updated. Mimir has no transactions: the rollback only covers the groups of one namespace, resources are still applied independently by Terraform, changes made concurrently by others may be overwritten, and the rollback itself may fail. May alternatively be set via the `MIMIRTOOL_ROLLBACK_ON_FAILURE` or `MIMIR_ROLLBACK_ON_FAILURE` environment variable.",
+				},
+				"max_writes_per_second": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					DefaultFunc:  schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_MAX_WRITES_PER_SECOND", "MIMIR_MAX_WRITES_PER_SECOND"}, 0),
+					Description:  "Maximum number of write operations sent to Grafana Mimir per second. All the writes of the provider go through a single queue, paced at this rate and bounded by `max_concurrent_operations`, so large applies present a steady load. `0` means no limit. May alternatively be set via the `MIMIRTOOL_MAX_WRITES_PER_SECOND` or `MIMIR_MAX_WRITES_PER_SECOND` environment variable.",
+					ValidateFunc: validation.IntAtLeast(0),
+				},
+				"max_rules_per_rule_group": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					DefaultFunc:  schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_MAX_RULES_PER_RULE_GROUP", "MIMIR_MAX_RULES_PER_RULE_GROUP"}, 0),
+					Description:  "The `ruler_max_rules_per_rule_group` limit of the tenant, so that the plan fails naming the rule groups of more rules rather than the apply midway, with a namespace partially updated. With `auto_split_groups`, the groups are checked as split. The limit applies to all the tenants the provider writes to, including the `tenant_id` of the resources. `0` skips the check. May alternatively be set via the `MIMIRTOOL_MAX_RULES_PER_RULE_GROUP` or `MIMIR_MAX_RULES_PER_RULE_GROUP` environment variable.",
+					ValidateFunc: validation.IntAtLeast(0),
+				},
+				"max_rule_groups_per_namespace": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					DefaultFunc:  schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_MAX_RULE_GROUPS_PER_NAMESPACE", "MIMIR_MAX_RULE_GROUPS_PER_NAMESPACE"}, 0),
+					Description:  "The `ruler_max_rule_groups_per_namespace` limit of the tenant, so that the plan of a `mimirtool_ruler_namespace` of more rule groups fails rather than the apply midway. With `auto_split_groups`, the groups are counted after the split. The limit applies to all the tenants the provider writes to, including the `tenant_id` of the resources. `0` skips the check. May alternatively be set via the `MIMIRTOOL_MAX_RULE_GROUPS_PER_NAMESPACE` or `MIMIR_MAX_RULE_GROUPS_PER_NAMESPACE` environment variable.",
+					ValidateFunc: validation.IntAtLeast(0),
+				},
+				"retries": {
+					Type:          schema.TypeInt,
+					Optional:      true,
+					Deprecated:    "Use retry_max_attempts, the number of attempts of a call, which is retries plus one.",
+					Description:   fmt.Sprintf("Maximum number of retries of a call, between 0 and %d. Sets `retry_max_attempts` to its value plus one.", maxRetryAttempts-1),
+					ValidateFunc:  validation.IntBetween(0, maxRetryAttempts-1),
+					ConflictsWith: []string{"retry_max_attempts"},
+				},
+				"retry_max_attempts": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					DefaultFunc:  retryMaxAttemptsDefault,
+					Description:  fmt.Sprintf("Maximum number of attempts of a call to Grafana Mimir failing with a transient error: rate limiting, a server error, see `retryable_status_codes`, a network error or the timeout of an attempt, see `http_timeout`. Between 1, which disables the retries, and %d. Defaults to `3`. Resources may override the retry settings with a `retry` block. May alternatively be set via the `MIMIRTOOL_RETRY_MAX_ATTEMPTS` or `MIMIR_RETRY_MAX_ATTEMPTS` environment variable, or as a number of retries via the `MIMIR_CLIENT_RETRIES` one.", maxRetryAttempts),
+					ValidateFunc: validation.IntBetween(1, maxRetryAttempts),
+				},
+				"retry_backoff": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_RETRY_BACKOFF", "MIMIR_RETRY_BACKOFF"}, "1s"),
+					Description:      fmt.Sprintf("Delay before the first retry of a call, doubled on each retry, between %s and %s. May alternatively be set via the `MIMIRTOOL_RETRY_BACKOFF` or `MIMIR_RETRY_BACKOFF` environment variable.", minRetryBackoff, maxRetryBackoff),
+					ValidateDiagFunc: validateRetryBackoff,
+				},
+				"retry_backoff_seconds": {
+					Type:          schema.TypeFloat,
+					Optional:      true,
+					Deprecated:    "Use retry_backoff, a duration string.",
+					Description:   fmt.Sprintf("`retry_backoff` as a number of seconds, e.g. `0.5`, between %g and %g.", minRetryBackoff.Seconds(), maxRetryBackoff.Seconds()),
+					ValidateFunc:  validation.FloatBetween(minRetryBackoff.Seconds(), maxRetryBackoff.Seconds()),
+					ConflictsWith: []string{"retry_backoff", "retry_min_wait"},
+				},
+				"retry_max_backoff": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_RETRY_MAX_BACKOFF", "MIMIR_RETRY_MAX_BACKOFF"}, "30s"),
+					Description:      fmt.Sprintf("Maximum delay between two retries of a call, between %s and %s. It is raised to `retry_backoff` when shorter. May alternatively be set via the `MIMIRTOOL_RETRY_MAX_BACKOFF` or `MIMIR_RETRY_MAX_BACKOFF` environment variable.", minRetryBackoff, maxRetryBackoff),
+					ValidateDiagFunc: validateRetryBackoff,
+				},
+				"retry_min_wait": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Deprecated:       "Use retry_backoff.",
+					Description:      "`retry_backoff` under another name.",
+					ValidateDiagFunc: validateRetryBackoff,
+					ConflictsWith:    []string{"retry_backoff", "retry_backoff_seconds"},
+				},
+				"retry_max_wait": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Deprecated:       "Use retry_max_backoff.",
+					Description:      "`retry_max_backoff` under another name.",
+					ValidateDiagFunc: validateRetryBackoff,
+					ConflictsWith:    []string{"retry_max_backoff"},
+				},
+				"retry_dns_failures": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_RETRY_DNS_FAILURES", "MIMIR_RETRY_DNS_FAILURES"}, false),
+					Description: "Run the whole operation of a resource again when it fails because the host of Grafana Mimir could not be resolved, e.g. right after the start of a CI pod whose network is not ready yet. The operation is run up to `retry_max_attempts` times, waiting `retry_backoff` doubled on each retry up to `retry_max_backoff`, after the retries of its calls. A `retry` block of the resource overrides these settings. May alternatively be set via the `MIMIRTOOL_RETRY_DNS_FAILURES` or `MIMIR_RETRY_DNS_FAILURES` environment variable.",
+				},
+				"retryable_status_codes": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Elem:        &schema.Schema{Type: schema.TypeInt, ValidateFunc: validation.IntBetween(100, 599)},
+					Description: "HTTP status codes of the transient errors of Grafana Mimir, retried as set by `retry_max_attempts`, e.g. the `520` to `526` ones of some CDNs. They extend the default ones, `429` and the `5xx` ones, or replace them as set by `retryable_status_codes_mode`. Network errors are retried whatever the codes.",
+				},
+				"retryable_status_codes_mode": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					DefaultFunc:  schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_RETRYABLE_STATUS_CODES_MODE", "MIMIR_RETRYABLE_STATUS_CODES_MODE"}, retryableStatusesExtend),
+					Description:  "How `retryable_status_codes` combine with the default retryable codes, `429` and the `5xx` ones: `extend` retries both, `replace` only retries `retryable_status_codes`, none when empty. May alternatively be set via the `MIMIRTOOL_RETRYABLE_STATUS_CODES_MODE` or `MIMIR_RETRYABLE_STATUS_CODES_MODE` environment variable.",
+					ValidateFunc: validation.StringInSlice([]string{retryableStatusesExtend, retryableStatusesReplace}, false),
+				},
+				"timeout": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_TIMEOUT", "MIMIR_TIMEOUT"}, "0s"),
+					Description:      "Maximum duration of a call to Grafana Mimir, its retries included, as a duration string such as `30s`. `0s` means no limit. `ruler_timeout` and `alertmanager_timeout` override it for the calls of the ruler and of the alertmanager. May alternatively be set via the `MIMIRTOOL_TIMEOUT` or `MIMIR_TIMEOUT` environment variable.",
+					ValidateDiagFunc: validateDuration,
+				},
+				"ruler_timeout": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_RULER_TIMEOUT", "MIMIR_RULER_TIMEOUT"}, ""),
+					Description:      "Maximum duration of a call to the ruler of Grafana Mimir, its retries included, overriding `timeout`. Falls back to `timeout` when unset. Unless `http_timeout` is set, it also replaces its default as the bound of each attempt. May alternatively be set via the `MIMIRTOOL_RULER_TIMEOUT` or `MIMIR_RULER_TIMEOUT` environment variable.",
+					ValidateDiagFunc: validateDuration,
+				},
+				"alertmanager_timeout": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_ALERTMANAGER_TIMEOUT", "MIMIR_ALERTMANAGER_TIMEOUT"}, ""),
+					Description:      "Maximum duration of a call to the alertmanager of Grafana Mimir, its retries included, overriding `timeout`, e.g. to allow large configuration uploads. Falls back to `timeout` when unset. Unless `http_timeout` is set, it also replaces its default as the bound of each attempt. May alternatively be set via the `MIMIRTOOL_ALERTMANAGER_TIMEOUT` or `MIMIR_ALERTMANAGER_TIMEOUT` environment variable.",
+					ValidateDiagFunc: validateDuration,
+				},
+				"http_timeout": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_HTTP_TIMEOUT", "MIMIR_HTTP_TIMEOUT"}, nil),
+					Description:      "Maximum duration of a single HTTP request to Grafana Mimir, as a duration string such as `30s`. Unlike `timeout`, it bounds each attempt of a call rather than the call with its retries, so that a request left unanswered fails, and is retried as set by `retry_max_attempts`, rather than hangs. The call deadline set by `timeout`, `ruler_timeout` or `alertmanager_timeout` still bounds the attempts, so `http_timeout` only matters when shorter. `0s` means no limit. Defaults to `30s`, except for the calls of the ruler when `ruler_timeout` is set and the ones of the alertmanager when `alertmanager_timeout` is set, whose attempts are then only bounded by that timeout. May alternatively be set via the `MIMIRTOOL_HTTP_TIMEOUT` or `MIMIR_HTTP_TIMEOUT` environment variable.",
+					ValidateDiagFunc: validateDuration,
+				},
+				"max_request_bytes": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					DefaultFunc:  schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_MAX_REQUEST_BYTES", "MIMIR_MAX_REQUEST_BYTES"}, defaultMaxBodyBytes),
+					Description:  fmt.Sprintf("Maximum size in bytes of the content sent to Grafana Mimir: the configuration of a resource is checked before being parsed, then each request before being sent. Defaults to %d, `0` means no limit. May alternatively be set via the `MIMIRTOOL_MAX_REQUEST_BYTES` or `MIMIR_MAX_REQUEST_BYTES` environment variable.", defaultMaxBodyBytes),
+					ValidateFunc: validation.IntAtLeast(0),
+				},
+				"max_response_bytes": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					DefaultFunc:  schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_MAX_RESPONSE_BYTES", "MIMIR_MAX_RESPONSE_BYTES"}, defaultMaxBodyBytes),
+					Description:  fmt.Sprintf("Maximum size in bytes of a response of Grafana Mimir, the reads going past it fail. Defaults to %d, `0` means no limit. May alternatively be set via the `MIMIRTOOL_MAX_RESPONSE_BYTES` or `MIMIR_MAX_RESPONSE_BYTES` environment variable.", defaultMaxBodyBytes),
+					ValidateFunc: validation.IntAtLeast(0),
+				},
+				"log_request_bodies": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_LOG_REQUEST_BODIES", "MIMIR_LOG_REQUEST_BODIES"}, false),
+					Description: fmt.Sprintf("Log the bodies of the writes to the ruler and the alertmanager at trace level, e.g. `TF_LOG_PROVIDER=TRACE`, to debug rejected uploads. The bodies are capped to %d bytes and the values of the secret fields of the Alertmanager configuration, e.g. `api_key`, `*_password` or the webhook `url`, are redacted by pattern matching, which may miss secrets held by other fields. The request headers, credentials included, are never logged. May alternatively be set via the `MIMIRTOOL_LOG_REQUEST_BODIES` or `MIMIR_LOG_REQUEST_BODIES` environment variable.", maxLoggedBodyBytes),
+				},
+				"sensitive_patterns": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.StringIsValidRegExp},
+					Description: "Regular expressions of the sensitive values to redact from the errors and warnings of the provider, e.g. the parts of a rejected Alertmanager configuration echoed back by Grafana Mimir. They extend the default ones, which match the secret fields of the Alertmanager configuration, e.g. `api_key`, `*_password` or the webhook `url`, and the Slack, Microsoft Teams and Discord webhook URLs. The matches are replaced by `<redacted>`, keeping the text of the first capture group of the expression, if any, e.g. the name of a field. The provider logs, the ones of the mimirtool client included, are masked with `***` where they match.",
+				},
+				"suppress_api_warnings": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_SUPPRESS_API_WARNINGS", "MIMIR_SUPPRESS_API_WARNINGS"}, false),
+					Description: "Don't report the non-fatal warnings returned by Grafana Mimir along with its responses. They are reported by default as warnings of the plan or apply of the resource or data source whose operation got them, once per operation, and logged at the debug level when suppressed. May alternatively be set via the `MIMIRTOOL_SUPPRESS_API_WARNINGS` or `MIMIR_SUPPRESS_API_WARNINGS` environment variable.",
+				},
+			},
+			DataSourcesMap: map[string]*schema.Resource{
+				"mimirtool_connectivity":                 dataSourceConnectivity(),
+				"mimirtool_ruler_shards":                 dataSourceRulerShards(),
+				"mimirtool_recording_rule_outputs":       dataSourceRecordingRuleOutputs(),
+				"mimirtool_ruler_defaults":               dataSourceRulerDefaults(),
+				"mimirtool_alertmanager_config_versions": dataSourceAlertmanagerConfigVersions(),
+				"mimirtool_export":                       dataSourceExport(),
+				"mimirtool_alertmanager_notifications":   dataSourceAlertmanagerNotifications(),
+				"mimirtool_ruler_namespace_counts":       dataSourceRulerNamespaceCounts(),
+				"mimirtool_alerts":                       dataSourceAlerts(),
+				"mimirtool_rule_dependency_graph":        dataSourceRuleDependencyGraph(),
+				"mimirtool_tls_debug":                    dataSourceTLSDebug(),
+				"mimirtool_ruler_namespace":              dataSourceRulerNamespace(),
+				"mimirtool_rules_batch_validate":         dataSourceRulesBatchValidate(),
+				"mimirtool_alertmanager":                 dataSourceAlertmanager(),
+				"mimirtool_alertmanager_routing":         dataSourceAlertmanagerRouting(),
 			},
-			DataSourcesMap: map[string]*schema.Resource{},
 			ResourcesMap: map[string]*schema.Resource{
 				"mimirtool_ruler_namespace": resourceRulerNamespace(),
 				"mimirtool_alertmanager":    resourceAlertManager(),
+				"mimirtool_rule_group":      resourceRuleGroup(),
 			},
 		}
 
-		p.ConfigureContextFunc = configure(version, p)
+		for _, r := range p.ResourcesMap {
+			retryDNSFailures(r)
+			applyRetryPolicy(r)
+			applyResourceTenant(r)
+			trackOperationEndpoint(r)
+			surfaceAPIWarnings(r)
+			logAPIStats(r)
+			redactDiagnostics(r)
+		}
+		for _, r := range p.DataSourcesMap {
+			trackOperationEndpoint(r)
+			surfaceAPIWarnings(r)
+			logAPIStats(r)
+			redactDiagnostics(r)
+		}
+		p.ConfigureContextFunc = redactConfigure(configure(version, p, factory))
 
 		return p
 	}
 }
 
-func configure(version string, p *schema.Provider) func(context.Context, *schema.ResourceData) (interface{}, diag.Diagnostics) {
+// logAPIStats makes every operation of r log the summary of the calls it made
+// to Mimir once done.
+func logAPIStats(r *schema.Resource) {
+	wrap := func(f func(context.Context, *schema.ResourceData, any) diag.Diagnostics) func(context.Context, *schema.ResourceData, any) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+			ctx, stats := withOperationStats(ctx)
+			diags := f(ctx, d, meta)
+			if _, ok := meta.(*client); ok {
+				stats.log(ctx)
+			}
+			return diags
+		}
+	}
+	r.CreateContext = wrap(r.CreateContext)
+	r.ReadContext = wrap(r.ReadContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+}
+
+func configure(version string, p *schema.Provider, factory clientFactory) func(context.Context, *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	return func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-		var (
-			diags diag.Diagnostics
-			err   error
-		)
+		var diags diag.Diagnostics
 		p.UserAgent("terraform-provider-mimirtool", version)
+		sensitivePatterns, err := providerSensitivePatterns(d)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		routeClientLogs(ctx, sensitivePatterns)
 
-		c := &client{}
-
-		c.cli, err = getDefaultMimirClient(d)
+		config, err := getMimirClientConfig(d)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		var statusCodes []int
+		for _, code := range d.Get("retryable_status_codes").([]interface{}) {
+			statusCodes = append(statusCodes, code.(int))
+		}
+		statuses := newRetryableStatuses(statusCodes, d.Get("retryable_status_codes_mode").(string))
+		maxAttempts, backoff, maxBackoff := providerRetrySettings(d)
+		retry, err := newRetryPolicy("provider", maxAttempts, backoff, maxBackoff, statuses)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		timeouts, err := newCallTimeouts(d.Get("timeout").(string), d.Get("ruler_timeout").(string), d.Get("alertmanager_timeout").(string), d.Get("http_timeout").(string))
 		if err != nil {
 			return nil, diag.FromErr(err)
 		}
+
+		var namespaceNamePattern *regexp.Regexp
+		if pattern := d.Get("namespace_name_pattern").(string); pattern != "" {
+			if namespaceNamePattern, err = regexp.Compile("^(?:" + pattern + ")$"); err != nil {
+				return nil, diag.Errorf("invalid namespace_name_pattern: %s", err)
+			}
+		}
+
+		// The client itself is built lazily by client.mimirClient so that
+		// validation-only workflows don't need a reachable, configured Mimir.
+		c := &client{
+			config:                   config,
+			writes:                   newWriteQueue(d.Get("max_concurrent_operations").(int), d.Get("max_writes_per_second").(int)),
+			validateRuleDependencies: d.Get("validate_rule_dependencies").(bool),
+			verifyTenant:             d.Get("verify_tenant").(bool),
+			idScheme:                 d.Get("id_scheme").(string),
+			namespaceNamePattern:     namespaceNamePattern,
+			sensitivePatterns:        sensitivePatterns,
+			namespaces:               &namespaceCache{},
+			rulerLimits: rulerLimitChecks{
+				maxRulesPerRuleGroup:      d.Get("max_rules_per_rule_group").(int),
+				maxRuleGroupsPerNamespace: d.Get("max_rule_groups_per_namespace").(int),
+			},
+			fastRefresh:         d.Get("fast_refresh").(bool),
+			rollbackOnFailure:   d.Get("rollback_on_failure").(bool),
+			warnDeprecated:      d.Get("warn_deprecated").(bool),
+			retry:               retry,
+			timeouts:            timeouts,
+			suppressAPIWarnings: d.Get("suppress_api_warnings").(bool),
+			retryDNSFailures:    d.Get("retry_dns_failures").(bool),
+			factory:             factory,
+		}
 		return c, diags
 	}
 }
 
-func getDefaultMimirClient(d *schema.ResourceData) (mimirClientInterface, error) {
-
-	return mimirtool.New(mimirtool.Config{
-		AuthToken: d.Get("auth_token").(string),
-		User:      d.Get("api_user").(string),
-		Key:       d.Get("api_key").(string),
-		Address:   d.Get("address").(string),
-		ID:        d.Get("tenant_id").(string),
-		TLS: tls.ClientConfig{
-			CAPath:             d.Get("tls_ca_path").(string),
-			CertPath:           d.Get("tls_cert_path").(string),
-			KeyPath:            d.Get("tls_key_path").(string),
-			InsecureSkipVerify: d.Get("insecure_skip_verify").(bool),
+func getMimirClientConfig(d *schema.ResourceData) (clientConfig, error) {
+	dialTimeout, err := time.ParseDuration(d.Get("dial_timeout").(string))
+	if err != nil {
+		return clientConfig{}, fmt.Errorf("invalid dial_timeout: %w", err)
+	}
+	clockSkewTolerance, err := time.ParseDuration(d.Get("clock_skew_tolerance").(string))
+	if err != nil {
+		return clientConfig{}, fmt.Errorf("invalid clock_skew_tolerance: %w", err)
+	}
+	credentials, err := expandTenantCredentials(d.Get("tenant_credentials").([]interface{}))
+	if err != nil {
+		return clientConfig{}, err
+	}
+	tenant, err := tenantID(d.Get("tenant_id").(string), d.Get("tenant_id_template").(string), d.Get("environment").(string))
+	if err != nil {
+		return clientConfig{}, err
+	}
+	inlineTLS, err := parseTLSPEM(d.Get("ca_cert_pem").(string), d.Get("tls_cert_pem").(string), d.Get("tls_key_pem").(string))
+	if err != nil {
+		return clientConfig{}, err
+	}
+	fallbackAddresses := expandStringList(d.Get("fallback_addresses").([]interface{}))
+	for i, address := range fallbackAddresses {
+		fallbackAddresses[i] = joinBasePath(address, d.Get("base_path").(string))
+	}
+
+	return clientConfig{
+		Config: mimirtool.Config{
+			AuthToken: d.Get("auth_token").(string),
+			User:      d.Get("api_user").(string),
+			Key:       d.Get("api_key").(string),
+			Address:   joinBasePath(d.Get("address").(string), d.Get("base_path").(string)),
+			ID:        tenant,
+			TLS: tls.ClientConfig{
+				CAPath:             d.Get("tls_ca_path").(string),
+				CertPath:           d.Get("tls_cert_path").(string),
+				KeyPath:            d.Get("tls_key_path").(string),
+				InsecureSkipVerify: d.Get("insecure_skip_verify").(bool),
+			},
 		},
-	})
+		dialTimeout:          dialTimeout,
+		tlsPEM:               inlineTLS,
+		clockSkewTolerance:   clockSkewTolerance,
+		authTokenFile:        d.Get("auth_token_file").(string),
+		credentialCommand:    expandStringList(d.Get("credential_command").([]interface{})),
+		prometheusHTTPPrefix: normalizePathPrefix(d.Get("prometheus_http_prefix").(string)),
+		fallbackAddresses:    fallbackAddresses,
+		tenantCredentials:    credentials,
+		maxRequestBytes:      int64(d.Get("max_request_bytes").(int)),
+		maxResponseBytes:     int64(d.Get("max_response_bytes").(int)),
+		logRequestBodies:     d.Get("log_request_bodies").(bool),
+	}, nil
+}
+
+// expandTenantCredentials returns the tenant_credentials blocks by tenant.
+func expandTenantCredentials(blocks []interface{}) (map[string]tenantCredentials, error) {
+	credentials := make(map[string]tenantCredentials, len(blocks))
+	for _, block := range blocks {
+		block, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		tenant := block["tenant_id"].(string)
+		if _, ok := credentials[tenant]; ok {
+			return nil, fmt.Errorf("tenant_credentials: tenant %q is set more than once", tenant)
+		}
+		c := tenantCredentials{user: block["api_user"].(string), key: block["api_key"].(string), authToken: block["auth_token"].(string)}
+		if (c.authToken == "") == (c.user == "" && c.key == "") {
+			return nil, fmt.Errorf("tenant_credentials: tenant %q must set either api_user and api_key or auth_token", tenant)
+		}
+		credentials[tenant] = c
+	}
+	return credentials, nil
+}
+
+// joinBasePath returns address with basePath appended, with a single slash
+// between them.
+func joinBasePath(address string, basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if address == "" || basePath == "" {
+		return address
+	}
+	return strings.TrimSuffix(address, "/") + "/" + basePath
+}
+
+// normalizePathPrefix returns prefix with a leading slash and no trailing one,
+// so that it can be joined with the API paths.
+func normalizePathPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+func getDefaultMimirClient(cfg clientConfig) (*mimirtool.MimirClient, error) {
+	cli, err := mimirtool.New(cfg.Config)
+	if err != nil {
+		return nil, err
+	}
+	var transport http.RoundTripper = newTransport(cli.Client.Transport, cfg)
+	var source *cachedTokenSource
+	switch {
+	case cfg.authTokenFile != "":
+		source = newFileTokenSource(cfg.authTokenFile)
+	case len(cfg.credentialCommand) > 0:
+		source = newCommandTokenSource(cfg.credentialCommand, credentialCommandTimeout)
+	}
+	if source != nil {
+		source.leeway = cfg.clockSkewTolerance
+		transport = &tokenTransport{base: transport, source: source}
+	}
+	if cfg.clockSkewTolerance > 0 {
+		transport = &clockSkewTransport{base: transport, tolerance: cfg.clockSkewTolerance, source: source}
+	}
+	transport, err = newFailoverTransport(&redirectTransport{base: transport}, cfg.Address, cfg.fallbackAddresses)
+	if err != nil {
+		return nil, err
+	}
+	transport = &dnsFailureTransport{base: transport}
+	address, err := url.Parse(cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	transport = &componentTransport{base: transport, basePath: address.Path}
+	transport = &responseLimitTransport{base: transport, max: cfg.maxResponseBytes}
+	if cfg.logRequestBodies {
+		transport = &requestBodyLogTransport{base: transport}
+	}
+	cli.Client.Transport = &warningTransport{base: &lastModifiedTransport{base: transport}}
+	return cli, nil
 }
@@ -2,9 +2,12 @@ package mimirtool
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
@@ -51,6 +54,71 @@ func TestProvider(t *testing.T) {
 	}
 }
 
+func TestProviderConfigureWithoutAddress(t *testing.T) {
+	t.Setenv("MIMIRTOOL_ADDRESS", "")
+	t.Setenv("MIMIR_ADDRESS", "")
+
+	p := New("dev")()
+	if diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(nil)); diags.HasError() {
+		t.Fatalf("configure should not require an address: %v", diags)
+	}
+
+	_, err := p.Meta().(*client).mimirClient("mimirtool_ruler_namespace")
+	if err == nil {
+		t.Fatal("expected an error when no address is configured")
+	}
+	if !strings.Contains(err.Error(), "mimirtool_ruler_namespace") {
+		t.Fatalf("error should name the resource which needed the client, got: %s", err)
+	}
+}
+
+func TestProviderClientFactory(t *testing.T) {
+	fakes := map[string]*fakeMimirClient{}
+	p := newProvider("dev", func(cfg clientConfig) (mimirClientInterface, error) {
+		if _, ok := fakes[cfg.ID]; ok {
+			t.Fatalf("the client of tenant %q was built twice", cfg.ID)
+		}
+		fakes[cfg.ID] = newFakeMimirClient()
+		return fakes[cfg.ID], nil
+	})()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"address":   "http://mimir.invalid",
+		"tenant_id": "team-a",
+	})
+	if diags := p.Configure(context.Background(), config); diags.HasError() {
+		t.Fatal(diags)
+	}
+	c := p.Meta().(*client)
+
+	group := rwrulefmt.RuleGroup{}
+	group.Name = "group"
+	// The provider tenant is served by the same client, whether named or not.
+	for _, tenant := range []string{"team-a", "team-b", "team-b", ""} {
+		cli, err := c.mimirClientForTenant("mimirtool_ruler_namespace", tenant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cli.CreateRuleGroup(context.Background(), "from-"+tenant, group); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(fakes) != 2 {
+		t.Fatalf("expected a client per tenant, got %v", fakes)
+	}
+	for tenant, namespaces := range map[string][]string{"team-a": {"from-", "from-team-a"}, "team-b": {"from-team-b"}} {
+		remote, _ := fakes[tenant].ListRules(context.Background(), "")
+		if len(remote) != len(namespaces) {
+			t.Errorf("tenant %q: expected the namespaces %v, got %v", tenant, namespaces, remote)
+		}
+		for _, namespace := range namespaces {
+			if _, ok := remote[namespace]; !ok {
+				t.Errorf("tenant %q: expected the namespace %q, got %v", tenant, namespace, remote)
+			}
+		}
+	}
+}
+
 // testAccPreCheck verifies required provider testing configuration. It should
 // be present in every acceptance test.
 //
@@ -66,3 +134,90 @@ func testAccPreCheck(t *testing.T) {
 		}
 	})
 }
+
+func TestProviderBasePath(t *testing.T) {
+	p := newProvider("dev", func(clientConfig) (mimirClientInterface, error) {
+		return newFakeMimirClient(), nil
+	})()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"address":                "https://gateway.example.org/",
+		"fallback_addresses":     []interface{}{"https://backup.example.org"},
+		"base_path":              "/observability/mimir/",
+		"prometheus_http_prefix": "prometheus/",
+	})
+	if diags := p.Configure(context.Background(), config); diags.HasError() {
+		t.Fatal(diags)
+	}
+	cfg := p.Meta().(*client).config
+	if cfg.Address != "https://gateway.example.org/observability/mimir" {
+		t.Errorf("unexpected address %q", cfg.Address)
+	}
+	if len(cfg.fallbackAddresses) != 1 || cfg.fallbackAddresses[0] != "https://backup.example.org/observability/mimir" {
+		t.Errorf("unexpected fallback addresses %v", cfg.fallbackAddresses)
+	}
+	if cfg.prometheusHTTPPrefix != "/prometheus" {
+		t.Errorf("unexpected prometheus_http_prefix %q", cfg.prometheusHTTPPrefix)
+	}
+
+	for _, tc := range []struct{ address, basePath, want string }{
+		{"https://mimir", "", "https://mimir"},
+		{"https://mimir/", "/", "https://mimir/"},
+		{"https://mimir/", "mimir", "https://mimir/mimir"},
+		{"https://gateway/tenants", "/mimir/", "https://gateway/tenants/mimir"},
+		{"", "/mimir", ""},
+	} {
+		if got := joinBasePath(tc.address, tc.basePath); got != tc.want {
+			t.Errorf("joinBasePath(%q, %q) = %q, expected %q", tc.address, tc.basePath, got, tc.want)
+		}
+	}
+}
+
+func TestProviderTenantCredentials(t *testing.T) {
+	configs := map[string]clientConfig{}
+	p := newProvider("dev", func(cfg clientConfig) (mimirClientInterface, error) {
+		configs[cfg.ID] = cfg
+		return newFakeMimirClient(), nil
+	})()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"address":   "http://mimir.invalid",
+		"tenant_id": "team-a",
+		"api_user":  "admin",
+		"api_key":   "admin-key",
+		"tenant_credentials": []interface{}{
+			map[string]interface{}{"tenant_id": "team-a", "api_key": "key-a"},
+			map[string]interface{}{"tenant_id": "team-b", "auth_token": "token-b"},
+		},
+	})
+	if diags := p.Configure(context.Background(), config); diags.HasError() {
+		t.Fatal(diags)
+	}
+	c := p.Meta().(*client)
+	for _, tenant := range []string{"team-a", "team-b", "team-c"} {
+		if _, err := c.mimirClientForTenant("mimirtool_ruler_namespace", tenant); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for tenant, want := range map[string]tenantCredentials{
+		"team-a": {key: "key-a"},
+		"team-b": {authToken: "token-b"},
+		"team-c": {user: "admin", key: "admin-key"},
+	} {
+		cfg := configs[tenant]
+		if got := (tenantCredentials{user: cfg.User, key: cfg.Key, authToken: cfg.AuthToken}); got != want {
+			t.Errorf("tenant %q: expected the credentials %+v, got %+v", tenant, want, got)
+		}
+	}
+	if authMechanism(configs["team-b"]) != authBearer || authMechanism(configs["team-a"]) != authBasic {
+		t.Error("expected the auth mechanism of the tenant credentials")
+	}
+
+	config = terraform.NewResourceConfigRaw(map[string]interface{}{
+		"address":            "http://mimir.invalid",
+		"tenant_credentials": []interface{}{map[string]interface{}{"tenant_id": "team-a", "api_key": "key-a", "auth_token": "token-a"}},
+	})
+	p = newProvider("dev", nil)()
+	if diags := p.Configure(context.Background(), config); !diags.HasError() || strings.Contains(fmt.Sprint(diags), "token-a") {
+		t.Fatalf("expected conflicting credentials to be refused without showing them, got %v", diags)
+	}
+}
@@ -0,0 +1,94 @@
+package mimirtool
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// maxRedirects bounds the redirects followed for a request.
+const maxRedirects = 5
+
+// errRedirect is the cause of the redirects which are not followed.
+var errRedirect = errors.New("redirect not followed")
+
+// redirectTransport follows the redirects of Mimir, or of the gateway in front
+// of it, itself: unlike the Go HTTP client it keeps the method, the body and
+// all the headers, among which the credentials and the tenant, whatever the
+// status. Only the redirects to the origin of the request are followed so as
+// not to send the credentials elsewhere.
+type redirectTransport struct {
+	base http.RoundTripper
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for hops := 0; ; hops++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || !isRedirect(resp.StatusCode) {
+			return resp, err
+		}
+		location, err := resp.Location()
+		if err == http.ErrNoLocation {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid redirect of %s %s: %w", req.Method, req.URL.Redacted(), err)
+		}
+
+		if origin(location) != origin(req.URL) {
+			return nil, fmt.Errorf("%w: %s %s was redirected to %s, another origin, which is not followed so as not to send the credentials there: set the provider `address` to it if the redirect is expected", errRedirect, req.Method, req.URL.Redacted(), location.Redacted())
+		}
+		if hops >= maxRedirects {
+			return nil, fmt.Errorf("%w: %s %s was redirected more than %d times", errRedirect, req.Method, req.URL.Redacted(), maxRedirects)
+		}
+		tflog.Debug(req.Context(), "Following a Grafana Mimir redirect", map[string]interface{}{
+			"method": req.Method,
+			"status": resp.StatusCode,
+			"from":   req.URL.Redacted(),
+			"to":     location.Redacted(),
+		})
+
+		next := req.Clone(req.Context())
+		next.URL = location
+		next.Host = ""
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("%w: %s %s was redirected to %s but its body cannot be sent again", errRedirect, req.Method, req.URL.Redacted(), location.Redacted())
+			}
+			if next.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		req = next
+	}
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// origin returns the scheme, host and port of u, the default port of the
+// scheme being explicit.
+func origin(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if scheme == "https" {
+			port = "443"
+		}
+	}
+	return scheme + "://" + net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
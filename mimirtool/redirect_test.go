@@ -0,0 +1,97 @@
+package mimirtool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedirectTransport(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to another origin: %s %s", r.Method, r.URL)
+	}))
+	defer other.Close()
+
+	var status int
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/loop":
+			http.Redirect(w, r, "/loop", status)
+		case r.URL.Path == "/other/rules":
+			http.Redirect(w, r, other.URL+"/prometheus/rules", status)
+		case r.URL.Path == "/absolute/rules":
+			// The same origin, spelled out.
+			http.Redirect(w, r, server.URL+"/prometheus/rules", status)
+		case strings.HasPrefix(r.URL.Path, "/api/prom/"):
+			http.Redirect(w, r, "/prometheus/"+strings.TrimPrefix(r.URL.Path, "/api/prom/"), status)
+		default:
+			body, _ := io.ReadAll(r.Body)
+			fmt.Fprintf(w, "%s %s %s %s %s", r.Method, r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("X-Scope-OrgID"), body)
+		}
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: &redirectTransport{base: http.DefaultTransport}}
+	do := func(path string) (string, error) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, bytes.NewBufferString("groups: []"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Set("X-Scope-OrgID", "tenant")
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	for _, status = range []int{http.StatusMovedPermanently, http.StatusTemporaryRedirect, http.StatusPermanentRedirect} {
+		for _, path := range []string{"/api/prom/rules", "/absolute/rules"} {
+			got, err := do(path)
+			if err != nil {
+				t.Fatalf("%d %s: unexpected error: %s", status, path, err)
+			}
+			if want := "POST /prometheus/rules Bearer token tenant groups: []"; got != want {
+				t.Errorf("%d %s: expected %q, got %q", status, path, want, got)
+			}
+		}
+
+		_, err := do("/other/rules")
+		if !errors.Is(err, errRedirect) || !strings.Contains(err.Error(), "another origin") {
+			t.Errorf("%d: expected the redirect to another origin to be refused, got %v", status, err)
+		}
+		_, err = do("/loop")
+		if !errors.Is(err, errRedirect) || retryable(context.Background(), err, nil) {
+			t.Errorf("%d: expected the redirect loop to be refused, got %v", status, err)
+		}
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		same bool
+	}{
+		{"https://mimir.example.com/api/prom", "https://mimir.example.com:443/prometheus", true},
+		{"http://Mimir.example.com/", "http://mimir.example.com:80/", true},
+		{"https://mimir.example.com/", "http://mimir.example.com/", false},
+		{"https://mimir.example.com/", "https://mimir.example.com:8443/", false},
+		{"https://mimir.example.com/", "https://other.example.com/", false},
+	} {
+		a, _ := http.NewRequest(http.MethodGet, tc.a, nil)
+		b, _ := http.NewRequest(http.MethodGet, tc.b, nil)
+		if same := origin(a.URL) == origin(b.URL); same != tc.same {
+			t.Errorf("%s, %s: expected same origin %t", tc.a, tc.b, tc.same)
+		}
+	}
+}
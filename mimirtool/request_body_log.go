@@ -0,0 +1,61 @@
+package mimirtool
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// maxLoggedBodyBytes bounds the part of the request bodies logged.
+const maxLoggedBodyBytes = 64 << 10
+
+// secretFieldRegexp matches the YAML lines of the fields of the Alertmanager
+// configuration holding secrets, e.g. `api_key` or the `url` of webhooks,
+// capturing what precedes their value. Their `_file` variants only hold
+// paths and are kept.
+var secretFieldRegexp = regexp.MustCompile(`(?im)^(\s*(?:-\s+)?["']?(?:[a-z_]*(?:password|secret|token|api_key|api_url|service_key|routing_key|user_key|webhook_url|credentials)|url)["']?\s*:[ \t]*)\S.*$`)
+
+// redactSecrets replaces the values of the secret fields of body.
+func redactSecrets(body string) string {
+	return secretFieldRegexp.ReplaceAllString(body, "${1}<redacted>")
+}
+
+// requestBodyLogTransport logs at trace level the bodies of the writes to the
+// ruler and the alertmanager, capped and with their secrets redacted. The
+// headers, which carry the credentials, are never logged.
+type requestBodyLogTransport struct {
+	base http.RoundTripper
+}
+
+func (t *requestBodyLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody || req.Method == http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	// The body is read here, send a copy of the request along with it.
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	logged := body
+	if len(logged) > maxLoggedBodyBytes {
+		logged = logged[:maxLoggedBodyBytes]
+	}
+	tflog.Trace(req.Context(), "Request body sent to Grafana Mimir", map[string]interface{}{
+		"method":    req.Method,
+		"url":       req.URL.Redacted(),
+		"size":      len(body),
+		"truncated": len(body) > maxLoggedBodyBytes,
+		"body":      redactSecrets(string(logged)),
+	})
+	return t.base.RoundTrip(req)
+}
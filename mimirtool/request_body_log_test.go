@@ -0,0 +1,55 @@
+package mimirtool
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	body := `alertmanager_config: |
+  global:
+    slack_api_url: https://hooks.slack.com/services/secret
+    smtp_auth_password: "hunter2"
+  receivers:
+    - name: team
+      webhook_configs:
+        - url: https://example.com/hook?token=secret
+      pagerduty_configs:
+        - routing_key: secret
+          client_url: https://example.com
+      opsgenie_configs:
+        - api_key_file: /etc/opsgenie
+template_files:
+  default.tmpl: '{{ define "title" }}Title{{ end }}'
+`
+	got := redactSecrets(body)
+	if strings.Contains(got, "secret\n") || strings.Contains(got, "hunter2") || strings.Contains(got, "token=secret") {
+		t.Fatalf("expected the secrets to be redacted, got:\n%s", got)
+	}
+	for _, kept := range []string{"slack_api_url: <redacted>", "- url: <redacted>", "client_url: https://example.com", "api_key_file: /etc/opsgenie", `default.tmpl: '{{ define "title" }}Title{{ end }}'`} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("expected %q, got:\n%s", kept, got)
+		}
+	}
+}
+
+func TestRequestBodyLogTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer server.Close()
+	httpClient := &http.Client{Transport: &requestBodyLogTransport{base: http.DefaultTransport}}
+
+	resp, err := httpClient.Post(server.URL, "application/yaml", strings.NewReader("name: demo\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "name: demo\n" {
+		t.Fatalf("expected the body to be sent as is, got %q", body)
+	}
+}
@@ -2,19 +2,24 @@ package mimirtool
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-
-	mimirtool "github.com/grafana/mimir/pkg/mimirtool/client"
+	"gopkg.in/yaml.v3"
 )
 
 func resourceAlertManager() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Description: `
 [Official documentation](https://grafana.com/docs/mimir/latest/references/http-api/#alertmanager)
+
+The configuration and its templates are pushed by a single request, a failed or canceled apply leaves both as they were.
 `,
 
 		CreateContext: alertmanagerCreate,
@@ -22,60 +27,355 @@ func resourceAlertManager() *schema.Resource {
 		UpdateContext: alertmanagerCreate, // There is no PUT, the POST is responsible to overwrite the configuration
 		DeleteContext: alertmanagerDelete,
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: alertmanagerImport,
+		},
+		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta any) error {
+			if d.NewValueKnown("config_yaml") && d.NewValueKnown("base_config_yaml") && d.NewValueKnown("environment_patches") && d.NewValueKnown("environment") {
+				alertmanagerConfig, err := alertmanagerConfigYAML(d)
+				if err != nil {
+					return err
+				}
+				if validatorEnabled(d, validatorRootRoute) {
+					if err := checkAlertmanagerRootRoute(alertmanagerConfig); err != nil {
+						return err
+					}
+				}
+				if d.HasChanges("config_yaml", "base_config_yaml", "environment_patches", "environment", "validate", "skip_validation") {
+					var warnings []string
+					if validatorEnabled(d, validatorReceiverCredentials) {
+						for _, warning := range checkAlertmanagerReceivers(alertmanagerConfig) {
+							warnings = append(warnings, warning.Summary+" "+warning.Detail)
+						}
+					}
+					if err := d.SetNew("planned_warnings", warnings); err != nil {
+						return err
+					}
+				}
+			} else if err := d.SetNewComputed("planned_warnings"); err != nil {
+				return err
+			}
+			if d.NewValueKnown("templates_config_yaml") && d.NewValueKnown("template_files") {
+				templates, err := alertmanagerTemplates(d)
+				if err != nil {
+					return err
+				}
+				// The files are not in the configuration, their content is
+				// tracked by its hash.
+				files := stringValueMap(d.Get("template_files").(map[string]interface{}))
+				hashes := make(map[string]string, len(files))
+				for name := range files {
+					hashes[name] = hash(templates[name])
+				}
+				if !reflect.DeepEqual(hashes, stringValueMap(d.Get("template_files_sha256").(map[string]interface{}))) {
+					if err := d.SetNew("template_files_sha256", hashes); err != nil {
+						return err
+					}
+				}
+			} else if err := d.SetNewComputed("template_files_sha256"); err != nil {
+				return err
+			}
+			if d.HasChanges("config_yaml", "templates_config_yaml", "template_files", "template_files_sha256", "base_config_yaml", "environment_patches", "environment") {
+				if err := d.SetNewComputed("content_sha256"); err != nil {
+					return err
+				}
+			}
+			return planChangeSummary(d, "planned_receiver_changes", receiverContents)
 		},
 
 		Schema: map[string]*schema.Schema{
 			"config_yaml": {
-				Description: "The Alertmanager configuration to load in Grafana Mimir as YAML.",
-				Type:        schema.TypeString,
-				Required:    true,
+				Description:  "The Alertmanager configuration to load in Grafana Mimir as YAML. Receivers whose integrations miss the credentials they need (e.g. a Slack configuration without `api_url` nor global `slack_api_url`) are reported by `planned_warnings` when planning and as warnings when applying. The configuration is uploaded as is, fields unknown to the provider are kept. Exactly one of `config_yaml` and `base_config_yaml` must be set.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"config_yaml", "base_config_yaml"},
+			},
+			"base_config_yaml": {
+				Description:  "The Alertmanager configuration shared by several environments as YAML, patched by the one of `environment_patches` selected by `environment` before the upload. The mappings of the patch are merged recursively into the base, its `null` values remove keys and its other values, lists included, replace the base ones. Unlike `config_yaml`, the patched configuration is not stored in the state, only the base and the patches are along with `content_sha256`. The patched configuration is validated as `config_yaml` is. Sensitive, as it may hold the credentials of the receivers.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"environment"},
+			},
+			"environment_patches": {
+				Description:  "The patches of `base_config_yaml` as YAML, by environment. An environment needing no change has an empty patch. Sensitive, as they may hold the credentials of the receivers of the environments.",
+				Type:         schema.TypeMap,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"base_config_yaml"},
+			},
+			"environment": {
+				Description:  "The environment whose patch of `environment_patches` applies to `base_config_yaml`.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"base_config_yaml"},
+			},
+			"resolve_secret_references": {
+				Description: "Resolve the secret references of the configuration when pushing it, so that the secrets stay out of the Terraform configuration and state, which only hold the references. `${env:NAME}` is replaced by the value of the environment variable `NAME` of the provider and `${file:PATH}` by the content of the file at `PATH`, without its trailing newlines. In HCL strings, write them `$${env:NAME}` for Terraform not to interpolate them. The references must resolve when refreshing too, the configuration is pushed again when Grafana Mimir holds another one than they resolve to.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
 			},
 			"templates_config_yaml": {
-				Description: "The templates to load along with the configuration.",
+				Description: "The templates to load along with the configuration, as content by template name. Mimir stores them as files named after the templates, the names must not be empty.",
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+			},
+			"template_files": {
+				Description: "Templates to load along with the configuration, read from files at plan and apply time, as paths by template name. Relative paths are relative to the directory Terraform runs in, use `path.module` to refer to the files of a module. Their names must not be in `templates_config_yaml`. Changes to the content of the files are detected through `template_files_sha256`.",
 				Type:        schema.TypeMap,
 				Elem:        &schema.Schema{Type: schema.TypeString},
 				Optional:    true,
 			},
+			"template_files_sha256": {
+				Description: "SHA-256 of the templates of `template_files` by name: the content of the files when planning, the one of Grafana Mimir once refreshed.",
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+			},
+			"content_sha256": {
+				Description: "SHA-256 of the configuration and its templates, as pushed to Grafana Mimir. With `resolve_secret_references`, the configuration is hashed with its references rather than the secrets. It can be referenced to trigger changes when the configuration changes.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"planned_warnings": {
+				Description: "Warnings of the `receiver_credentials` validator about the planned configuration, so that `terraform plan` shows them: the plan can't report warnings otherwise. They are reported again as warnings when applying. Empty when no change is planned, cleared on refresh.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"planned_receiver_changes": {
+				Description: changeSummaryDescription("receivers"),
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"missing_resource_behavior": missingResourceBehaviorSchema(),
+			"retry":                     retrySchema(),
+			"tenant_id":                 tenantSchema("configuration"),
 		},
 	}
+	for k, v := range validationSchema(alertmanagerValidators) {
+		r.Schema[k] = v
+	}
+	return r
 }
 
-func alertmanagerCreate(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	client := meta.(*client).cli
-	alertmanagerConfig := d.Get("config_yaml").(string)
-	templatesMap := d.Get("templates_config_yaml").(map[string]interface{})
+// alertmanagerImport imports the configuration of the tenant of the ID, either
+// the ID of the resource or the tenant itself. The provider tenant is left
+// out of tenant_id, which would otherwise recreate the resource when the
+// configuration doesn't set it.
+func alertmanagerImport(ctx context.Context, d *schema.ResourceData, meta any) ([]*schema.ResourceData, error) {
+	c := meta.(*client)
+	setImportDefaults(d, resourceAlertManager().Schema)
+	tenant, ok := strings.CutSuffix(d.Id(), "/alertmanager")
+	if !ok && d.Id() == "alertmanager" {
+		tenant = ""
+	}
+	if tenant == c.config.ID {
+		tenant = ""
+	}
+	d.Set("tenant_id", tenant)
+	d.SetId(alertmanagerID(tenant))
+
+	ctx = withTenant(ctx, tenant)
+	client, err := c.tenantClient(ctx, "mimirtool_alertmanager")
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := client.GetAlertmanagerConfig(ctx); errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("tenant %q has no Alertmanager configuration to import", c.tenant(ctx))
+	} else if err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
 
-	templates := stringValueMap(templatesMap)
+// alertmanagerID returns the ID of the configuration of tenant, the provider
+// one when empty.
+func alertmanagerID(tenant string) string {
+	if tenant != "" {
+		return tenant + "/alertmanager"
+	}
+	return "alertmanager"
+}
 
-	err := client.CreateAlertmanagerConfig(ctx, alertmanagerConfig, templates)
+func alertmanagerCreate(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	client, err := meta.(*client).tenantClient(ctx, "mimirtool_alertmanager")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	alertmanagerConfig, err := alertmanagerConfigYAML(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if d.Get("resolve_secret_references").(bool) {
+		if alertmanagerConfig, err = resolveSecretReferences(alertmanagerConfig); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	templates, err := alertmanagerTemplates(d)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	for name, planned := range stringValueMap(d.Get("template_files_sha256").(map[string]interface{})) {
+		if content, ok := templates[name]; ok && hash(content) != planned {
+			return diag.Errorf("the file of template %q changed since the plan, plan again", name)
+		}
+	}
+	size := len(alertmanagerConfig)
+	for _, template := range templates {
+		size += len(template)
+	}
+	if err := checkRequestSize("the alertmanager configuration along with its templates", size, c.config.maxRequestBytes); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var diags diag.Diagnostics
+	if validatorEnabled(d, validatorReceiverCredentials) {
+		diags = append(diags, checkAlertmanagerReceivers(alertmanagerConfig)...)
+	}
+	if validatorEnabled(d, validatorConfigSize) {
+		if err := checkAlertmanagerConfigSize(ctx, c, alertmanagerConfig, templates); err != nil {
+			return append(diags, diag.FromErr(err)...)
+		}
+	}
+
+	// The configuration and its templates are pushed by a single request, so
+	// a failure, e.g. a cancellation, leaves the previous ones in place.
+	err = client.CreateAlertmanagerConfig(ctx, alertmanagerConfig, templates)
+	if err != nil {
+		// Keep the previous state rather than the planned one the SDK would
+		// record on a failed update.
+		d.Partial(true)
+		return append(diags, diag.FromErr(err)...)
+	}
 	// Mimir supports only one alertmanager configuration per tenant as such there is no associated ID
-	d.SetId("alertmanager")
-	return alertmanagerRead(ctx, d, meta)
+	d.SetId(alertmanagerID(d.Get("tenant_id").(string)))
+	return append(diags, alertmanagerReadConfig(ctx, d, meta)...)
+}
+
+// checkAlertmanagerConfigSize fails when the configuration is larger than the
+// tenant limit, which Mimir rejects with an opaque error. It is skipped when
+// the limit cannot be read.
+func checkAlertmanagerConfigSize(ctx context.Context, c *client, cfg string, templates map[string]string) error {
+	body, err := c.apiGet(ctx, "mimirtool_alertmanager", "/api/v1/user_limits", nil)
+	if err != nil {
+		tflog.Debug(ctx, "Unable to read the tenant limits, skipping the configuration size check", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil
+	}
+	var limits struct {
+		MaxConfigSize int `json:"alertmanager_max_config_size_bytes"`
+	}
+	if err := json.Unmarshal(body, &limits); err != nil || limits.MaxConfigSize <= 0 {
+		return nil
+	}
+
+	// Measured as the payload the mimirtool client uploads.
+	payload, err := yaml.Marshal(map[string]interface{}{
+		"template_files":      templates,
+		"alertmanager_config": cfg,
+	})
+	if err != nil {
+		return nil
+	}
+	if len(payload) > limits.MaxConfigSize {
+		return fmt.Errorf("the alertmanager configuration is %d bytes along with its templates, more than the %d bytes allowed for the tenant by `alertmanager_max_config_size_bytes`", len(payload), limits.MaxConfigSize)
+	}
+	return nil
 }
 
 func alertmanagerRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	client := meta.(*client).cli
+	// The summary only describes the plan it was computed for, it is kept
+	// after the apply but not past the next refresh.
+	d.Set("planned_receiver_changes", "")
+	d.Set("planned_warnings", nil)
+	return alertmanagerReadConfig(ctx, d, meta)
+}
+
+// alertmanagerReadConfig downloads the configuration.
+func alertmanagerReadConfig(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	client, err := meta.(*client).tenantClient(ctx, "mimirtool_alertmanager")
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	alertmanagerConfig, templates, err := client.GetAlertmanagerConfig(ctx)
-	if errors.Is(err, mimirtool.ErrResourceNotFound) {
-		// need to tell terraform the resource does not exist
-		tflog.Info(ctx, "No alertmanager mimir side")
-		d.SetId("")
+	if errors.Is(err, ErrNotFound) {
+		return handleMissingResource(ctx, d, "The alertmanager configuration")
 	} else if err != nil {
 		return diag.FromErr(err)
 	}
-	d.Set("config_yaml", alertmanagerConfig)
-	d.Set("templates_config_yaml", templates)
+	resolve := d.Get("resolve_secret_references").(bool)
+	// The hash of a configuration whose secrets are resolved is the one of
+	// its references, for the state not to hold a hash of the secrets.
+	hashedConfig := alertmanagerConfig
+	if resolve {
+		// A configuration failing to render is cleared below.
+		hashedConfig, _ = alertmanagerConfigYAML(d)
+	}
+	if d.Get("base_config_yaml").(string) == "" && !resolve {
+		d.Set("config_yaml", alertmanagerConfig)
+	} else if expected, err := resolvedAlertmanagerConfigYAML(d, resolve); err != nil || expected != alertmanagerConfig {
+		// The configuration is not stored, as it comes from its environment
+		// or holds secrets: clear its source instead so that the next plan
+		// pushes it again.
+		tflog.Info(ctx, "The alertmanager configuration differs from the one of its source", map[string]interface{}{
+			"environment":               d.Get("environment").(string),
+			"resolve_secret_references": resolve,
+		})
+		if d.Get("base_config_yaml").(string) != "" {
+			d.Set("base_config_yaml", "")
+		} else {
+			d.Set("config_yaml", "")
+		}
+	}
+	// The templates of files are stored as their hash.
+	files := d.Get("template_files").(map[string]interface{})
+	inline := make(map[string]string, len(templates))
+	fileHashes := make(map[string]string, len(files))
+	for name, content := range templates {
+		if _, ok := files[name]; ok {
+			fileHashes[name] = hash(content)
+		} else {
+			inline[name] = content
+		}
+	}
+	d.Set("templates_config_yaml", inline)
+	d.Set("template_files_sha256", fileHashes)
+	d.Set("content_sha256", alertmanagerContentHash(hashedConfig, templates))
 	return nil
 }
 
+// resolvedAlertmanagerConfigYAML returns the configuration of d, with its
+// secret references resolved when resolve is set.
+func resolvedAlertmanagerConfigYAML(d attributeGetter, resolve bool) (string, error) {
+	cfg, err := alertmanagerConfigYAML(d)
+	if err != nil || !resolve {
+		return cfg, err
+	}
+	return resolveSecretReferences(cfg)
+}
+
+// alertmanagerContentHash returns the SHA-256 of the payload the mimirtool
+// client uploads, whose templates are sorted by name.
+func alertmanagerContentHash(cfg string, templates map[string]string) string {
+	payload, _ := yaml.Marshal(map[string]interface{}{
+		"template_files":      templates,
+		"alertmanager_config": cfg,
+	})
+	return hash(string(payload))
+}
+
 func alertmanagerDelete(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := meta.(*client).cli
-	err := client.DeleteAlermanagerConfig(ctx)
+	client, err := meta.(*client).tenantClient(ctx, "mimirtool_alertmanager")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = client.DeleteAlermanagerConfig(ctx)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -1,9 +1,16 @@
 package mimirtool
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func TestAccResourceAlertmanager(t *testing.T) {
@@ -50,3 +57,109 @@ receivers:
 const testAccResourceAlertmanagerTemplate = `{{ define "__alertmanager" }}AlertManager{{ end }}
 {{ define "__alertmanagerURL" }}{{ .ExternalURL }}/#/alerts?receiver={{ .Receiver | urlquery }}{{ end }}
 `
+
+func TestCheckAlertmanagerConfigSize(t *testing.T) {
+	limits := `{"alertmanager_max_config_size_bytes": 100}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/user_limits" || limits == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(limits))
+	}))
+	defer server.Close()
+
+	c := &client{cli: newFakeMimirClient()}
+	c.config.Address = server.URL
+	ctx := context.Background()
+
+	if err := checkAlertmanagerConfigSize(ctx, c, "route: {}", nil); err != nil {
+		t.Fatalf("unexpected error for a small configuration: %s", err)
+	}
+	err := checkAlertmanagerConfigSize(ctx, c, strings.Repeat("#", 200), nil)
+	if err == nil || !strings.Contains(err.Error(), "100 bytes allowed") {
+		t.Fatalf("expected the configuration to be too large, got: %v", err)
+	}
+
+	// Without limits API, the check is skipped.
+	limits = ""
+	if err := checkAlertmanagerConfigSize(ctx, c, strings.Repeat("#", 200), nil); err != nil {
+		t.Fatalf("expected the check to be skipped, got: %s", err)
+	}
+}
+
+func TestAlertmanagerUnknownFields(t *testing.T) {
+	config, err := os.ReadFile("testdata/alertmanager_unknown_fields.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceAlertManager().Schema, map[string]interface{}{
+		"config_yaml": string(config),
+	})
+	fake := newFakeMimirClient()
+	if diags := alertmanagerCreate(context.Background(), d, &client{cli: fake}); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if fake.alertmanagerConfig != string(config) {
+		t.Fatalf("the configuration was altered on upload:\n%s", fake.alertmanagerConfig)
+	}
+	if got := d.Get("config_yaml").(string); got != string(config) {
+		t.Fatalf("the configuration was altered on read:\n%s", got)
+	}
+}
+
+func TestAlertmanagerImport(t *testing.T) {
+	fakes := map[string]*fakeMimirClient{"team-a": newFakeMimirClient(), "team-b": newFakeMimirClient()}
+	p := newProvider("dev", func(cfg clientConfig) (mimirClientInterface, error) {
+		return fakes[cfg.ID], nil
+	})()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{"address": "http://mimir.invalid", "tenant_id": "team-a"})
+	if diags := p.Configure(context.Background(), config); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	d := resourceAlertManager().TestResourceData()
+	d.SetId("team-b")
+	if _, err := alertmanagerImport(context.Background(), d, p.Meta()); err == nil || !strings.Contains(err.Error(), `tenant "team-b" has no Alertmanager configuration`) {
+		t.Fatalf("expected the import of a missing configuration to fail, got %v", err)
+	}
+
+	fakes["team-a"].CreateAlertmanagerConfig(context.Background(), "route:\n  receiver: default\n", nil)
+	fakes["team-b"].CreateAlertmanagerConfig(context.Background(), "route:\n  receiver: team-b\n", nil)
+	for id, want := range map[string]struct{ id, tenant string }{
+		"team-b":              {"team-b/alertmanager", "team-b"},
+		"team-b/alertmanager": {"team-b/alertmanager", "team-b"},
+		"team-a":              {"alertmanager", ""},
+		"alertmanager":        {"alertmanager", ""},
+	} {
+		d := resourceAlertManager().TestResourceData()
+		d.SetId(id)
+		imported, err := alertmanagerImport(context.Background(), d, p.Meta())
+		if err != nil {
+			t.Fatalf("%s: %s", id, err)
+		}
+		if imported[0].Id() != want.id || imported[0].Get("tenant_id") != want.tenant {
+			t.Errorf("%s: expected the ID %q and tenant %q, got %q and %q", id, want.id, want.tenant, imported[0].Id(), imported[0].Get("tenant_id"))
+		}
+	}
+}
+
+func TestAlertmanagerPlanRootRoute(t *testing.T) {
+	r := resourceAlertManager()
+	config := "route:\n  receiver: tema\nreceivers:\n  - name: team\n"
+	_, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"config_yaml": config,
+	}), &client{})
+	if err == nil || !strings.Contains(err.Error(), `receiver "tema"`) {
+		t.Fatalf("expected the plan to fail on the root route, got: %v", err)
+	}
+
+	_, err = r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"config_yaml":     config,
+		"skip_validation": []interface{}{validatorRootRoute},
+	}), &client{})
+	if err != nil {
+		t.Fatalf("expected the skipped check not to fail the plan, got: %s", err)
+	}
+}
@@ -0,0 +1,309 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"gopkg.in/yaml.v3"
+)
+
+// ruleGroupPlaceholderName names the groups whose name is not known yet, it
+// is not part of their canonical content.
+const ruleGroupPlaceholderName = "group"
+
+func resourceRuleGroup() *schema.Resource {
+	return &schema.Resource{
+		Description: `
+[Official documentation](https://grafana.com/docs/mimir/latest/references/http-api/#set-rule-group)
+
+Manages a single rule group of a namespace through the rule group endpoints of the ruler, so that several teams own the groups of a namespace independently. The other groups of the namespace are left as they are.
+
+A ` + "`mimirtool_ruler_namespace`" + ` owns all the groups of its namespace and deletes the ones missing from its ` + "`config_yaml`" + `: don't manage the groups of such a namespace with this resource. The creation fails when the group already exists, import it instead.
+`,
+
+		CreateContext: ruleGroupCreate,
+		ReadContext:   ruleGroupRead,
+		UpdateContext: ruleGroupUpdate,
+		DeleteContext: ruleGroupDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: ruleGroupImport,
+		},
+		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta any) error {
+			if c, ok := meta.(*client); ok && d.NewValueKnown("namespace") {
+				if err := c.checkNamespaceName(d.Get("namespace").(string)); err != nil {
+					return err
+				}
+			}
+			// The group is validated under its name, once known, so that the
+			// errors refer to it.
+			if !d.NewValueKnown("name") || !d.NewValueKnown("config_yaml") {
+				return nil
+			}
+			group, err := ruleGroupFromYAML(d.Get("name").(string), d.Get("config_yaml").(string))
+			if err != nil {
+				return err
+			}
+			if c, ok := meta.(*client); ok {
+				return c.rulerLimits.check(rules.RuleNamespace{Groups: []rwrulefmt.RuleGroup{group}})
+			}
+			return nil
+		},
+
+		Schema: map[string]*schema.Schema{
+			"namespace": {
+				Description: "The name of the namespace of the group. Changing it recreates the group.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			"name": {
+				Description:  "The name of the group. Changing it recreates the group.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"config_yaml": {
+				Description:      "The group definition as YAML, an item of the `groups` of a namespace without its `name`: its `rules` along with e.g. its `interval`.",
+				Type:             schema.TypeString,
+				Required:         true,
+				StateFunc:        normalizeRuleGroupYAML,
+				ValidateDiagFunc: validateRuleGroupYAML,
+				DiffSuppressFunc: diffRuleGroupYAML,
+			},
+			"missing_resource_behavior": missingResourceBehaviorSchema(),
+			"retry":                     retrySchema(),
+			"tenant_id":                 tenantSchema("rule group"),
+		},
+	}
+}
+
+// ruleGroupID returns the ID of the group name of namespace, prefixed with
+// its tenant when it is not the provider one.
+func ruleGroupID(tenant string, namespace string, name string) string {
+	if tenant != "" {
+		return tenant + "/" + namespace + "/" + name
+	}
+	return namespace + "/" + name
+}
+
+// ruleGroupNamespaceYAML returns the YAML of a namespace holding the group of
+// configYAML, named name, so that it goes through the parsing of the
+// namespaces.
+func ruleGroupNamespaceYAML(name string, configYAML string) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(configYAML), &doc); err != nil {
+		return "", err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return "", errors.New("not a definition of a rule group, expected a mapping with the `rules` of the group")
+	}
+	group := doc.Content[0]
+	for i := 0; i < len(group.Content); i += 2 {
+		if group.Content[i].Value == "name" {
+			return "", errors.New("the group definition sets a `name`, set it with the `name` attribute instead")
+		}
+	}
+	named := &yaml.Node{Kind: yaml.MappingNode, Content: append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "name"},
+		{Kind: yaml.ScalarNode, Value: name, Style: yaml.DoubleQuotedStyle},
+	}, group.Content...)}
+	namespace := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "groups"},
+		{Kind: yaml.SequenceNode, Content: []*yaml.Node{named}},
+	}}
+	out, err := yaml.Marshal(namespace)
+	return string(out), err
+}
+
+// ruleGroupFromYAML returns the validated group of configYAML, named name.
+func ruleGroupFromYAML(name string, configYAML string) (rwrulefmt.RuleGroup, error) {
+	namespaceYAML, err := ruleGroupNamespaceYAML(name, configYAML)
+	if err != nil {
+		return rwrulefmt.RuleGroup{}, err
+	}
+	parsed := parseNamespace(namespaceYAML)
+	// The mimirtool client drops the fields it doesn't know, the namespaces
+	// push them apart.
+	for _, fields := range parsed.groupFields() {
+		keys := make([]string, 0, len(fields)/2)
+		for i := 0; i < len(fields); i += 2 {
+			keys = append(keys, fields[i].Value)
+		}
+		return rwrulefmt.RuleGroup{}, fmt.Errorf("the group fields %s are not supported by mimirtool_rule_group, use a mimirtool_ruler_namespace", strings.Join(keys, ", "))
+	}
+	ruleNamespace, err := parsed.validatedNamespace()
+	if err != nil {
+		return rwrulefmt.RuleGroup{}, err
+	}
+	// The parsed namespace is shared, the caller may modify the group.
+	return copyRuleNamespace(ruleNamespace).Groups[0], nil
+}
+
+// ruleGroupYAML returns the canonical YAML of group, without its name.
+func ruleGroupYAML(group rwrulefmt.RuleGroup) string {
+	ruleNamespace := copyRuleNamespace(rules.RuleNamespace{Groups: []rwrulefmt.RuleGroup{group}})
+	lintExpressions(ruleNamespace)
+	var node yaml.Node
+	if err := node.Encode(ruleNamespace.Groups[0]); err != nil {
+		return ""
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == "name" {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			break
+		}
+	}
+	out, _ := yaml.Marshal(&node)
+	return string(out)
+}
+
+func normalizeRuleGroupYAML(config any) string {
+	group, err := ruleGroupFromYAML(ruleGroupPlaceholderName, config.(string))
+	if err != nil {
+		return config.(string)
+	}
+	return ruleGroupYAML(group)
+}
+
+func validateRuleGroupYAML(config any, k cty.Path) diag.Diagnostics {
+	if _, err := ruleGroupNamespaceYAML(ruleGroupPlaceholderName, config.(string)); err != nil {
+		return diag.Diagnostics{
+			diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Rule group definition is not valid.",
+				Detail:        err.Error(),
+				AttributePath: k,
+			},
+		}
+	}
+	return nil
+}
+
+func diffRuleGroupYAML(_, oldValue, newValue string, _ *schema.ResourceData) bool {
+	return oldValue == newValue || normalizeRuleGroupYAML(oldValue) == normalizeRuleGroupYAML(newValue)
+}
+
+// ruleGroupImport accepts the namespace/group and tenant/namespace/group
+// forms. The groups of a tenant other than the provider one are imported with
+// their tenant_id.
+func ruleGroupImport(ctx context.Context, d *schema.ResourceData, meta any) ([]*schema.ResourceData, error) {
+	c := meta.(*client)
+	var tenant, namespace, name string
+	switch parts := strings.Split(d.Id(), "/"); len(parts) {
+	case 2:
+		namespace, name = parts[0], parts[1]
+	case 3:
+		tenant, namespace, name = parts[0], parts[1], parts[2]
+	}
+	if namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid import ID %q, expected <namespace>/<group> or <tenant>/<namespace>/<group>, the names must not hold slashes", d.Id())
+	}
+	if tenant == c.config.ID {
+		tenant = ""
+	}
+	if err := c.checkNamespaceName(namespace); err != nil {
+		return nil, err
+	}
+	setImportDefaults(d, resourceRuleGroup().Schema)
+	d.Set("namespace", namespace)
+	d.Set("name", name)
+	d.Set("tenant_id", tenant)
+	d.SetId(ruleGroupID(tenant, namespace, name))
+	return []*schema.ResourceData{d}, nil
+}
+
+func ruleGroupCreate(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	client, err := meta.(*client).tenantClient(ctx, "mimirtool_rule_group")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace, name := d.Get("namespace").(string), d.Get("name").(string)
+	// Creating the group over an existing one would take it over silently.
+	if _, err := getRuleGroup(ctx, client, namespace, name); err == nil {
+		return diag.Errorf("rule group %q already exists in namespace %q, import it rather than creating it", name, namespace)
+	} else if !errors.Is(err, ErrNotFound) {
+		return diag.FromErr(err)
+	}
+	if diags := ruleGroupWrite(ctx, d, client); diags.HasError() {
+		return diags
+	}
+	d.SetId(ruleGroupID(d.Get("tenant_id").(string), namespace, name))
+	return ruleGroupRead(ctx, d, meta)
+}
+
+// getRuleGroup returns the group name of namespace. It is read through
+// ListRules, as GetRuleGroup of the mimirtool client prints the groups it
+// gets to the standard output, where they would end up in the logs.
+func getRuleGroup(ctx context.Context, client mimirClientInterface, namespace, name string) (*rwrulefmt.RuleGroup, error) {
+	groups, err := client.ListRules(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups[namespace] {
+		if group.Name == name {
+			return &group, nil
+		}
+	}
+	return nil, fmt.Errorf("rule group %q of namespace %q: %w", name, namespace, ErrNotFound)
+}
+
+// ruleGroupWrite pushes the group of d.
+func ruleGroupWrite(ctx context.Context, d *schema.ResourceData, client mimirClientInterface) diag.Diagnostics {
+	group, err := ruleGroupFromYAML(d.Get("name").(string), d.Get("config_yaml").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := client.CreateRuleGroup(ctx, d.Get("namespace").(string), group); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func ruleGroupRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	client, err := meta.(*client).tenantClient(ctx, "mimirtool_rule_group")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace, name := d.Get("namespace").(string), d.Get("name").(string)
+	group, err := getRuleGroup(ctx, client, namespace, name)
+	if errors.Is(err, ErrNotFound) {
+		return handleMissingResource(ctx, d, fmt.Sprintf("Rule group %q of namespace %q", name, namespace))
+	} else if err != nil {
+		return diag.FromErr(err)
+	}
+	d.Set("config_yaml", ruleGroupYAML(*group))
+	return nil
+}
+
+func ruleGroupUpdate(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	client, err := meta.(*client).tenantClient(ctx, "mimirtool_rule_group")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if diags := ruleGroupWrite(ctx, d, client); diags.HasError() {
+		return diags
+	}
+	return ruleGroupRead(ctx, d, meta)
+}
+
+func ruleGroupDelete(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	client, err := meta.(*client).tenantClient(ctx, "mimirtool_rule_group")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = client.DeleteRuleGroup(ctx, d.Get("namespace").(string), d.Get("name").(string))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return diag.FromErr(err)
+	}
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,103 @@
+package mimirtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// noGetRuleGroupClient fails the test on GetRuleGroup, which prints the
+// groups it gets to the standard output.
+type noGetRuleGroupClient struct {
+	*fakeMimirClient
+	t *testing.T
+}
+
+func (c noGetRuleGroupClient) GetRuleGroup(ctx context.Context, namespace string, groupName string) (*rwrulefmt.RuleGroup, error) {
+	c.t.Errorf("unexpected GetRuleGroup of %q in namespace %q", groupName, namespace)
+	return c.fakeMimirClient.GetRuleGroup(ctx, namespace, groupName)
+}
+
+func TestRuleGroupLifecycle(t *testing.T) {
+	fake := newFakeMimirClient()
+	meta := &client{cli: noGetRuleGroupClient{fake, t}}
+	other := rwrulefmt.RuleGroup{}
+	other.Name = "owned-by-team-b"
+	fake.CreateRuleGroup(context.Background(), "shared", other)
+
+	d := schema.TestResourceDataRaw(t, resourceRuleGroup().Schema, map[string]interface{}{
+		"namespace": "shared",
+		"name":      "team-a",
+		"config_yaml": `interval: 1m
+rules:
+  - record: job:up:sum
+    expr: sum by (job) (up)
+`,
+	})
+	if diags := ruleGroupCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Id() != "shared/team-a" || !strings.Contains(d.Get("config_yaml").(string), "record: job:up:sum") || strings.Contains(d.Get("config_yaml").(string), "name:") {
+		t.Fatalf("unexpected state %q: %q", d.Id(), d.Get("config_yaml"))
+	}
+	if remote, _ := fake.ListRules(context.Background(), "shared"); len(remote["shared"]) != 2 {
+		t.Fatalf("expected the other groups of the namespace to be kept, got %v", remote)
+	}
+	if diags := ruleGroupCreate(context.Background(), d, meta); !diags.HasError() || !strings.Contains(diags[0].Summary, "already exists") {
+		t.Fatalf("expected the creation over an existing group to be refused, got %v", diags)
+	}
+
+	d.Set("config_yaml", "rules:\n  - record: job:up:count\n    expr: count by (job) (up)\n")
+	if diags := ruleGroupUpdate(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if group, _ := fake.GetRuleGroup(context.Background(), "shared", "team-a"); group == nil || group.Rules[0].Record.Value != "job:up:count" || group.Interval != 0 {
+		t.Fatalf("expected the group to be replaced, got %+v", group)
+	}
+
+	// A group deleted out of band is removed from the state.
+	fake.DeleteRuleGroup(context.Background(), "shared", "team-a")
+	if diags := ruleGroupRead(context.Background(), d, meta); diags.HasError() || d.Id() != "" {
+		t.Fatalf("expected the group to be removed from the state, got %q, %v", d.Id(), diags)
+	}
+
+	if _, err := ruleGroupFromYAML("team-a", "name: team-a\nrules: []\n"); err == nil || !strings.Contains(err.Error(), "`name` attribute") {
+		t.Errorf("expected a name in the definition to be refused, got %v", err)
+	}
+	if _, err := ruleGroupFromYAML("team-a", "- record: a\n"); err == nil || !strings.Contains(err.Error(), "not a definition of a rule group") {
+		t.Errorf("expected a list to be refused, got %v", err)
+	}
+	if !diffRuleGroupYAML("", "rules:\n- record: a\n  expr: up\n", "rules:\n  - expr:   up\n    record: a\n", nil) {
+		t.Error("expected formatting changes to be suppressed")
+	}
+}
+
+func TestRuleGroupImport(t *testing.T) {
+	meta := &client{}
+	meta.config.ID = "team-a"
+	for id, want := range map[string]struct{ id, tenant, namespace, name string }{
+		"shared/alerts":        {"shared/alerts", "", "shared", "alerts"},
+		"team-a/shared/alerts": {"shared/alerts", "", "shared", "alerts"},
+		"team-b/shared/alerts": {"team-b/shared/alerts", "team-b", "shared", "alerts"},
+	} {
+		d := resourceRuleGroup().TestResourceData()
+		d.SetId(id)
+		imported, err := ruleGroupImport(context.Background(), d, meta)
+		if err != nil {
+			t.Fatalf("%s: %s", id, err)
+		}
+		if got := imported[0]; got.Id() != want.id || got.Get("tenant_id") != want.tenant || got.Get("namespace") != want.namespace || got.Get("name") != want.name {
+			t.Errorf("%s: unexpected import %q, tenant %q, namespace %q, name %q", id, got.Id(), got.Get("tenant_id"), got.Get("namespace"), got.Get("name"))
+		}
+	}
+	for _, id := range []string{"alerts", "shared/", "a/b/c/d"} {
+		d := resourceRuleGroup().TestResourceData()
+		d.SetId(id)
+		if _, err := ruleGroupImport(context.Background(), d, meta); err == nil {
+			t.Errorf("%s: expected an invalid import ID error", id)
+		}
+	}
+}
@@ -1,23 +1,37 @@
 package mimirtool
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/grafana/mimir/pkg/mimirtool/rules"
 	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
 	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v3"
 )
 
 func resourceRulerNamespace() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Description: `
 [Official documentation](https://grafana.com/docs/mimir/latest/references/http-api/#ruler)
+
+The groups are pushed one by one. When a push fails or the apply is canceled, the groups already pushed are recorded in the state, or rolled back with the provider ` + "`rollback_on_failure`" + `, so that a plain re-apply completes the change.
+
+The resource owns all the groups of the namespace: the groups missing from ` + "`config_yaml`" + ` are deleted, don't manage some of them with ` + "`mimirtool_rule_group`" + ` resources.
 `,
 
 		CreateContext: rulerNamespaceCreate,
@@ -25,7 +39,46 @@ func resourceRulerNamespace() *schema.Resource {
 		UpdateContext: rulerNamespaceUpdate,
 		DeleteContext: rulerNamespaceDelete,
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: rulerNamespaceImport,
+		},
+		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta any) error {
+			if c, ok := meta.(*client); ok && d.NewValueKnown("namespace") {
+				if err := c.checkNamespaceName(d.Get("namespace").(string)); err != nil {
+					return err
+				}
+			}
+			if _, err := expandRuleTransforms(d.Get("transform").([]interface{})); err != nil {
+				return err
+			}
+			if d.NewValueKnown("config_yaml") {
+				// The validation of config_yaml only decodes it, it can't tell
+				// whether the promql validator is skipped.
+				ruleNamespace, err := getRuleNamespaceFromYAML(ctx, namespacesOf(meta), d.Get("config_yaml").(string), validatorEnabled(d, validatorPromQL))
+				if err != nil {
+					return err
+				}
+				if c, ok := meta.(*client); ok {
+					if err := checkNamespaceRulerLimits(ctx, c, d, ruleNamespace); err != nil {
+						return err
+					}
+				}
+			}
+			if c, ok := meta.(*client); ok {
+				if err := planNamespaceWarnings(ctx, c, d); err != nil {
+					return err
+				}
+			}
+			if d.HasChanges("config_yaml", "transform") {
+				if err := d.SetNewComputed("content_sha256"); err != nil {
+					return err
+				}
+			}
+			if err := planChangeSummary(d, "planned_group_changes", ruleGroupContents); err != nil {
+				return err
+			}
+			return planContentDiff(d, "planned_diff", func(configYAML string) string {
+				return namespacesOf(meta).parse(configYAML).normalizedYAML()
+			})
 		},
 
 		Schema: map[string]*schema.Schema{
@@ -42,25 +95,286 @@ func resourceRulerNamespace() *schema.Resource {
 				DiffSuppressFunc: diffNamespaceYAML,
 				Required:         true,
 			},
+			"depends_on_namespaces": {
+				Description: "Namespaces of the same tenant whose recording rules the rules of this namespace use. As a best effort, when the provider is already writing some of them as it starts writing this namespace, it waits for these writes to complete first, so that its rules are less likely to evaluate without the series they read. This doesn't order the writes: a dependency whose write starts later is not waited for, and Terraform doesn't order the resources along this attribute. Use `depends_on` for the dependencies to be written first.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+			},
 			"strict_recording_rule_check": {
 				Description: "Fails rules checks that do not match best practices exactly. See: https://prometheus.io/docs/practices/rules/",
 				Type:        schema.TypeBool,
 				Optional:    true,
 				Default:     false,
 			},
+			"extended_validation": {
+				Description: "Run additional checks on the rules, reported as warnings: alerts whose `for` is shorter than the evaluation interval of their group, and selectors using `@ start()`, `@ end()`, a fixed `@` timestamp or a negative `offset` beyond the group `query_offset`, which misbehave in rule evaluation.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"require_for_multiple_of_interval": {
+				Description: "Warn about the alerts whose `for` is not a multiple of the evaluation interval of their group, 1 minute when it sets none. The ruler only checks `for` at evaluations, so such alerts fire up to one interval after it elapsed.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"check_recording_rule_cardinality": {
+				Description: "Warn about the recording rules which may produce high-cardinality output: those keeping all the labels of a selector, as they don't aggregate it with e.g. `sum by (...)` or `sum without (...)`. Selectors of recording rules, whose names hold colons, are not reported. This is a heuristic, tuned with `high_cardinality_metrics`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"high_cardinality_metrics": {
+				Description: "Regular expressions matching the whole names of the metrics `check_recording_rule_cardinality` reports the selectors of, e.g. `container_.*`. Every metric when empty.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsValidRegExp,
+				},
+			},
+			"duplicate_alert_names": {
+				Description:  "How alerts defined in several groups of the namespace are reported: `warn` or `error`, which fails before any group is pushed. Defining an alert several times within a group, or recording rules in several groups, is allowed.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      duplicateAlertNamesWarn,
+				ValidateFunc: validation.StringInSlice([]string{duplicateAlertNamesWarn, duplicateAlertNamesError}, false),
+			},
+			"allowed_annotation_domains": {
+				Description: "Domains the links of the alerts annotations listed by `annotation_link_keys` must point at, the domain itself or one of its subdomains. An alert linking elsewhere, or through a link whose host can't be told such as a relative or templated one, fails the apply before any group is pushed. The links are checked once `transform` applies. No check when empty.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+			},
+			"annotation_link_keys": {
+				Description: "Annotations of the alerts holding links checked against `allowed_annotation_domains`, `runbook_url` and `dashboard_url` when empty.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+			},
+			"forbidden_label_combinations": {
+				Description: "Combinations of label keys the rules must not carry all together, e.g. both `team` and `squad`. A rule carrying one fails the apply before any group is pushed. The labels are checked once `transform` applies.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"labels": {
+							Description: "The label keys forbidden together, at least two.",
+							Type:        schema.TypeList,
+							Required:    true,
+							MinItems:    2,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validation.StringIsNotWhiteSpace,
+							},
+						},
+						"rule_type": {
+							Description:  "The rules the combination is forbidden for: `all`, `alerting` or `recording`.",
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      ruleTypeAll,
+							ValidateFunc: validation.StringInSlice([]string{ruleTypeAll, ruleTypeAlerting, ruleTypeRecording}, false),
+						},
+					},
+				},
+			},
+			"transform": ruleTransformSchema(),
+			"normalize_expr": {
+				Description: "Push the rule expressions in the canonical form of the PromQL parser, the one `config_yaml` and `content_sha256` always use, so that Grafana Mimir holds them as the state does whatever their formatting in the source. Expressions the parser rejects are pushed as written, with a warning.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"check_evaluation": {
+				Description: "Once the groups are pushed, query their health once through the Prometheus rules API and fail the apply when a rule of one of them already fails to evaluate, reporting its last error. The groups stay pushed. Rules the ruler has not evaluated yet are not checked, this is only a quick gate for rulers evaluating shortly after the upload.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"fail_on_server_normalization": {
+				Description: "Once the groups are pushed, read them back and fail the apply, showing the differences, when Grafana Mimir holds a group differing from the one uploaded, both in canonical form: Mimir transformed it on upload. Formatting differences are not reported. Unlike the provider `verify_tenant`, which checks that the writes landed in the tenant, this flags what Mimir changed. The groups stay pushed.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"auto_split_groups": {
+				Description: "Split the groups of more than `max_rules_per_group` rules, or the `ruler_max_rules_per_rule_group` limit of the tenant when unset, into groups named after them with a `-1`, `-2`, ... suffix before the upload, rather than having Grafana Mimir reject them. The split is deterministic: the rules keep their order and the ones depending on the output of a recording rule of the group stay in its group when they fit. `config_yaml` keeps the source groups as long as Mimir holds their split. Split groups are evaluated independently and concurrently: a rule using the recording of a rule moved to another group sees its output of the previous evaluation, and the `limit` of the group applies to each of them.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"max_rules_per_group": {
+				Description:  "The number of rules past which `auto_split_groups` splits a group. Defaults to the `ruler_max_rules_per_rule_group` limit of the tenant, as reported by the tenant limits API.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"preserve_field_order": {
+				Description: "Store `config_yaml` in the state with the fields in the order of the source rather than in the canonical order, so that state reviews match the source files. The canonical order is used when the source is not known, e.g. after an import.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"significant_group_order": {
+				Description: "Plan an update when only the order of the groups in `config_yaml` changes, which is ignored otherwise. The update pushes the groups again in the new order. Mimir doesn't keep the order of the groups, the state keeps the order of the source as long as Mimir holds the same groups.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"remote_hash": {
+				Description: "Hash of the namespace content as of the last full read from Grafana Mimir. Kept as is when the provider `fast_refresh` skips downloading the content.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"last_modified": {
+				Description: "Time the namespace was last modified as of the last full read, RFC 3339, when reported by a `Last-Modified` header of the ruler configuration API. Grafana Mimir itself doesn't report it, some gateways in front of it do. Empty otherwise. Kept as is when the provider `fast_refresh` skips downloading the content.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"content_sha256": {
+				Description: "SHA-256 of the canonical namespace content, as pushed to Grafana Mimir. Unlike `config_yaml` it doesn't depend on the field order nor the formatting of the source, it can be referenced to trigger changes when the rules change.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"planned_group_changes": {
+				Description: changeSummaryDescription("rule groups"),
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"planned_diff": {
+				Description: "Unified diff of the canonical namespace content planned by the change of `config_yaml`, so that `terraform plan` shows which rules change rather than the whole configuration. Formatting and field order changes of the source don't show. Empty when no semantic change is planned, cleared on refresh.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"planned_warnings": {
+				Description: "Warnings of the optional checks of the rules, e.g. `extended_validation`, about the planned `config_yaml`, so that `terraform plan` shows them: the plan can't report warnings otherwise. They are reported again as warnings when applying. Empty when no change is planned, cleared on refresh.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"missing_resource_behavior": missingResourceBehaviorSchema(),
+			"retry":                     retrySchema(),
+			"tenant_id":                 tenantSchema("namespace"),
 		},
 	}
+	for k, v := range validationSchema(rulerNamespaceValidators) {
+		r.Schema[k] = v
+	}
+	return r
 }
 
-func getRuleNamespaceFromYAML(ctx context.Context, configYAML string) (rules.RuleNamespace, error) {
+// Formats of the ID of ruler namespaces.
+const (
+	idSchemeNamespace       = "namespace"
+	idSchemeTenantNamespace = "tenant/namespace"
+)
+
+// rulerNamespaceID returns the ID of namespace following the provider
+// id_scheme. The namespace scheme hashes the name, as it always did. The
+// namespaces of a tenant other than the provider one, as set by the tenant_id
+// of their resource, always follow the tenant/namespace scheme.
+func rulerNamespaceID(c *client, tenant string, namespace string) string {
+	if tenant != "" {
+		return tenant + "/" + namespace
+	}
+	if c.idScheme == idSchemeTenantNamespace {
+		return c.config.ID + "/" + namespace
+	}
+	return hash(namespace)
+}
+
+// checkNamespaceName returns an error when namespace doesn't match the
+// namespace_name_pattern of the provider.
+func (c *client) checkNamespaceName(namespace string) error {
+	if c.namespaceNamePattern == nil || c.namespaceNamePattern.MatchString(namespace) {
+		return nil
+	}
+	pattern := strings.TrimSuffix(strings.TrimPrefix(c.namespaceNamePattern.String(), "^(?:"), ")$")
+	return fmt.Errorf("namespace %q doesn't follow the naming convention of the provider: it must match the namespace_name_pattern %q", namespace, pattern)
+}
+
+// rulerNamespaceImport accepts both the namespace name and the
+// tenant/namespace forms. The namespaces of a tenant other than the provider
+// one are imported with their tenant_id.
+func rulerNamespaceImport(ctx context.Context, d *schema.ResourceData, meta any) ([]*schema.ResourceData, error) {
+	c := meta.(*client)
+	tenant, namespace := parseRulerNamespaceImportID(c, d.Id())
+	if namespace == "" {
+		return nil, fmt.Errorf("invalid import ID %q, expected <namespace>, /<namespace> or <tenant>/<namespace>", d.Id())
+	}
+	if err := c.checkNamespaceName(namespace); err != nil {
+		return nil, err
+	}
+	setImportDefaults(d, resourceRulerNamespace().Schema)
+	d.Set("namespace", namespace)
+	d.Set("tenant_id", tenant)
+	d.SetId(rulerNamespaceID(c, tenant, namespace))
+	return []*schema.ResourceData{d}, nil
+}
+
+// parseRulerNamespaceImportID returns the tenant and the namespace of the
+// import ID id, the tenant being empty for the provider one. Tenant IDs can't
+// hold slashes, so the ID is split at its first one: <tenant>/<namespace>.
+// A leading slash, as in /<namespace>, stands for the provider tenant so that
+// the namespaces holding slashes can be imported without naming it, and an ID
+// without slashes is a namespace of the provider tenant.
+func parseRulerNamespaceImportID(c *client, id string) (tenant string, namespace string) {
+	prefix, namespace, ok := strings.Cut(id, "/")
+	if !ok {
+		return "", id
+	}
+	if prefix == "" || prefix == c.config.ID {
+		return "", namespace
+	}
+	return prefix, namespace
+}
+
+// getRuleNamespaceFromYAML returns the namespace of configYAML, as memoized
+// by namespaces, its rules validated when promql is set. It is shared and must
+// not be modified.
+func getRuleNamespaceFromYAML(ctx context.Context, namespaces *namespaceCache, configYAML string, promql bool) (rules.RuleNamespace, error) {
+	if !promql {
+		return namespaces.parse(configYAML).uncheckedNamespace()
+	}
+	return namespaces.parse(configYAML).validatedNamespace()
+}
+
+// parseRuleNamespace parses configYAML as the mimirtool parser does, the
+// validation of the rules, which parses their expressions, is skipped unless
+// validate is set.
+func parseRuleNamespace(configYAML string, validate bool) (rules.RuleNamespace, error) {
 	var ruleNamespace rules.RuleNamespace
 	// We pass only one ruleGroup while ParseBytes return an array, we only need the first element
-	ruleNamespaces, err := rules.ParseBytes([]byte(configYAML))
-	if err != nil {
-		return ruleNamespace, fmt.Errorf("failed to parse namespace definition:\n%s", err)
+	// The parser rejects the fields it doesn't know, they are handled apart.
+	if err := newYAMLSource(configYAML).ruleGroupsError(); err != nil {
+		return ruleNamespace, err
+	}
+	parsed := withoutUnknownGroupFields(configYAML)
+	var ruleNamespaces []rules.RuleNamespace
+	var errs []error
+	if validate {
+		ruleNamespaces, errs = rules.ParseBytes([]byte(parsed))
+	} else {
+		ruleNamespaces, errs = decodeRuleNamespaces([]byte(parsed))
+	}
+	if len(errs) > 0 {
+		return ruleNamespace, namespaceParseError(configYAML, parsed, errs)
 	}
 
 	if len(ruleNamespaces) > 1 {
+		if separators := newYAMLSource(configYAML).separators; len(separators) > 0 {
+			return ruleNamespace, fmt.Errorf("namespace definition contains %d fragments, the second one starting on line %d, more than one namespace is not supported", len(ruleNamespaces), separators[0])
+		}
 		return ruleNamespace, fmt.Errorf("namespace definition contains more than one namespace which is not supported")
 	}
 	if len(ruleNamespaces) == 1 {
@@ -69,6 +383,27 @@ func getRuleNamespaceFromYAML(ctx context.Context, configYAML string) (rules.Rul
 	return ruleNamespace, fmt.Errorf("no namespace definition found")
 }
 
+// decodeRuleNamespaces decodes the namespaces of content as rules.ParseBytes
+// does, rejecting the unknown fields, without validating them.
+func decodeRuleNamespaces(content []byte) ([]rules.RuleNamespace, []error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	var ruleNamespaces []rules.RuleNamespace
+	for {
+		var ruleNamespace rules.RuleNamespace
+		err := decoder.Decode(&ruleNamespace)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, []error{err}
+		}
+		ruleNamespaces = append(ruleNamespaces, ruleNamespace)
+	}
+	return ruleNamespaces, nil
+}
+
 func checkRecordingRules(ruleNamespace rules.RuleNamespace, strict bool) error {
 	invalidRulesCount := ruleNamespace.CheckRecordingRules(strict)
 	if invalidRulesCount > 0 {
@@ -78,7 +413,10 @@ func checkRecordingRules(ruleNamespace rules.RuleNamespace, strict bool) error {
 }
 
 func getRuleNamespacesFromMimir(ctx context.Context, d *schema.ResourceData, meta any) ([]rwrulefmt.RuleGroup, error) {
-	client := meta.(*client).cli
+	client, err := meta.(*client).tenantClient(ctx, "mimirtool_ruler_namespace")
+	if err != nil {
+		return nil, err
+	}
 	namespace := d.Get("namespace").(string)
 	// namespace is required as per the definition above as such we have either nothing or one namespace
 
@@ -90,78 +428,480 @@ func getRuleNamespacesFromMimir(ctx context.Context, d *schema.ResourceData, met
 }
 
 func rulerNamespaceCreate(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	client := meta.(*client).cli
+	_, diags := rulerNamespaceWrite(ctx, d, meta)
+	if diags.HasError() || !d.Get("check_evaluation").(bool) {
+		return diags
+	}
+	return append(diags, checkRuleGroupsEvaluation(ctx, d, meta)...)
+}
+
+// rulerNamespaceWrite pushes the groups of the namespace and reads it back. It
+// returns the namespace as pushed, once transformed and split.
+func rulerNamespaceWrite(ctx context.Context, d *schema.ResourceData, meta any) (pushed rules.RuleNamespace, diags diag.Diagnostics) {
+	c := meta.(*client)
+	client, err := meta.(*client).tenantClient(ctx, "mimirtool_ruler_namespace")
+	if err != nil {
+		return pushed, diag.FromErr(err)
+	}
 	namespace := d.Get("namespace").(string)
+	tenant := c.tenant(ctx)
+	var dependencies []string
+	for _, dependency := range d.Get("depends_on_namespaces").([]interface{}) {
+		dependencies = append(dependencies, tenant+"/"+dependency.(string))
+	}
+	finish, err := c.namespaceWrites.start(ctx, tenant+"/"+namespace, dependencies)
+	defer finish()
+	if err != nil {
+		return pushed, diag.FromErr(err)
+	}
 	ruleGroup := d.Get("config_yaml").(string)
+	if err := checkRequestSize(fmt.Sprintf("the configuration of namespace %q", namespace), len(ruleGroup), c.config.maxRequestBytes); err != nil {
+		return pushed, diag.FromErr(err)
+	}
 	strictRecordingRuleCheck := d.Get("strict_recording_rule_check").(bool)
 
-	ruleNamespace, err := getRuleNamespaceFromYAML(ctx, ruleGroup)
+	ruleNamespace, err := getRuleNamespaceFromYAML(ctx, c.namespaces, ruleGroup, validatorEnabled(d, validatorPromQL))
 	if err != nil {
-		return diag.FromErr(err)
+		return pushed, diag.FromErr(err)
 	}
 
-	err = checkRecordingRules(ruleNamespace, strictRecordingRuleCheck)
+	if validatorEnabled(d, validatorRecordingRules) {
+		err = checkRecordingRules(ruleNamespace, strictRecordingRuleCheck)
+		if err != nil {
+			return pushed, diag.FromErr(err)
+		}
+	}
+
+	if validatorEnabled(d, validatorAlertNames) {
+		severity := diag.Warning
+		if d.Get("duplicate_alert_names").(string) == duplicateAlertNamesError {
+			severity = diag.Error
+		}
+		diags = checkDuplicateAlertNames(ruleNamespace, severity)
+		if diags.HasError() {
+			return pushed, diags
+		}
+	}
+	diags = append(diags, rulerNamespaceLints(ctx, c, client, d, namespace, ruleNamespace)...)
+
+	transforms, err := expandRuleTransforms(d.Get("transform").([]interface{}))
 	if err != nil {
-		return diag.FromErr(err)
+		return pushed, append(diags, diag.FromErr(err)...)
+	}
+	// The checks below still run on the source, which the user can fix.
+	pushed = transformNamespace(ruleNamespace, transforms)
+	if d.Get("normalize_expr").(bool) {
+		var unparsed diag.Diagnostics
+		pushed, unparsed = canonicalExpressions(pushed)
+		diags = append(diags, unparsed...)
+	}
+	if checks := checkTransformedNamespace(d, pushed); checks.HasError() {
+		return pushed, append(diags, checks...)
+	}
+	fields := c.namespaces.parse(ruleGroup).groupFields()
+	if d.Get("auto_split_groups").(bool) {
+		limit, err := ruleGroupSplitLimit(ctx, c, d)
+		if err != nil {
+			return pushed, append(diags, diag.FromErr(err)...)
+		}
+		var origins map[string]string
+		var split diag.Diagnostics
+		pushed, origins, split = splitRuleGroups(pushed, limit)
+		if diags = append(diags, split...); split.HasError() {
+			return pushed, diags
+		}
+		fields = splitGroupFields(fields, origins)
 	}
 
-	for _, group := range ruleNamespace.Groups {
-		err = client.CreateRuleGroup(ctx, namespace, group)
+	var previous []rwrulefmt.RuleGroup
+	if c.rollbackOnFailure {
+		remote, err := client.ListRules(ctx, namespace)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return pushed, diag.Errorf("unable to capture the content of namespace %q for rollback_on_failure: %s", namespace, err)
+		}
+		previous = remote[namespace]
+	}
+
+	for i, group := range pushed.Groups {
+		if groupFields, ok := fields[group.Name]; ok {
+			err = createRuleGroupWithFields(ctx, c, namespace, group, groupFields)
+		} else {
+			err = client.CreateRuleGroup(ctx, namespace, group)
+		}
 		if err != nil {
-			return diag.FromErr(err)
+			return pushed, append(diags, rulerNamespaceWriteFailed(ctx, c, client, d, previous, pushed.Groups[:i], group, err)...)
 		}
 	}
 
-	d.SetId(hash(namespace))
-	return rulerNamespaceRead(ctx, d, meta)
+	d.SetId(rulerNamespaceID(c, d.Get("tenant_id").(string), namespace))
+	waitForRuleGroups(ctx, client, namespace, pushed.Groups)
+	if d.Get("fail_on_server_normalization").(bool) {
+		if normalized := checkServerNormalization(ctx, client, namespace, pushed.Groups); normalized.HasError() {
+			return pushed, append(diags, append(normalized, rulerNamespaceReadFull(ctx, d, meta)...)...)
+		}
+	}
+	if d.Get("preserve_field_order").(bool) {
+		// The planned value went through normalizeNamespaceYAML, only the raw
+		// configuration still has the source order.
+		if raw := d.GetRawConfig().GetAttr("config_yaml"); raw.IsKnown() && !raw.IsNull() {
+			d.Set("config_yaml", orderYAMLLike(d.Get("config_yaml").(string), raw.AsString()))
+		}
+	}
+
+	// Always read the full content back after a write, even with fast_refresh.
+	return pushed, append(diags, rulerNamespaceReadFull(ctx, d, meta)...)
 }
 
-func rulerNamespaceRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
-	var diags diag.Diagnostics
-	client := meta.(*client).cli
+// rulerConfigPath is the path of the ruler configuration API for namespace.
+func rulerConfigPath(c *client, namespace string) string {
+	return c.config.prometheusHTTPPrefix + "/config/v1/rules/" + url.PathEscape(namespace)
+}
+
+// createRuleGroupWithFields writes a group having fields unknown to the
+// mimirtool client, which would drop them.
+func createRuleGroupWithFields(ctx context.Context, c *client, namespace string, group rwrulefmt.RuleGroup, fields groupFields) error {
+	payload, err := groupYAMLWithFields(group, fields)
+	if err != nil {
+		return err
+	}
+	_, err = c.apiRequest(ctx, "mimirtool_ruler_namespace", http.MethodPost, rulerConfigPath(c, namespace), http.Header{"Content-Type": []string{"application/yaml"}}, payload)
+	return err
+}
+
+// rollbackTimeout bounds the rollback of the groups written by an operation,
+// which runs even when the operation has been canceled.
+const rollbackTimeout = 30 * time.Second
+
+// rulerNamespaceWriteFailed handles the failure, e.g. a cancellation, of the
+// write of the group failed once the groups written have been. With
+// rollback_on_failure they are rolled back. Otherwise the state of an existing
+// namespace records them, rather than the planned content the SDK would keep,
+// and a full read is forced on the next refresh, so that a plain re-apply
+// pushes the remaining groups. A namespace being created is left out of the
+// state, re-applying writes all of its groups again.
+func rulerNamespaceWriteFailed(ctx context.Context, c *client, client mimirClientInterface, d *schema.ResourceData, previous []rwrulefmt.RuleGroup, written []rwrulefmt.RuleGroup, failed rwrulefmt.RuleGroup, err error) diag.Diagnostics {
+	diags := diag.FromErr(err)
 	namespace := d.Get("namespace").(string)
+	old, _ := d.GetChange("config_yaml")
 
-	remoteNamespaceRuleGroup, err := client.ListRules(ctx, namespace)
+	if c.rollbackOnFailure {
+		// The operation may have been canceled, the rollback has to run anyway.
+		rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+		defer cancel()
+		// The failed write may have been applied nonetheless.
+		rollbackDiags := rollbackRuleGroups(rollbackCtx, client, namespace, previous, append(slices.Clip(written), failed))
+		diags = append(diags, rollbackDiags...)
+		if len(rollbackDiags) == 0 {
+			written = nil
+		}
+	}
+
+	if d.Id() != "" {
+		// The failed write is left out, so that the next plan pushes it again.
+		d.Set("config_yaml", withRuleGroups(old.(string), written))
+		d.Set("remote_hash", "")
+	}
+	return diags
+}
+
+// withRuleGroups returns configYAML with groups replacing or added to its
+// groups of the same name.
+func withRuleGroups(configYAML string, groups []rwrulefmt.RuleGroup) string {
+	if len(groups) == 0 {
+		return configYAML
+	}
+	ruleNamespace, err := parseNamespace(configYAML).decodedNamespace()
 	if err != nil {
-		return diag.FromErr(err)
+		return configYAML
+	}
+	// The memoized namespace is shared, work on a copy of its groups.
+	merged := slices.Clone(ruleNamespace.Groups)
+	for _, group := range groups {
+		if i := slices.IndexFunc(merged, func(g rwrulefmt.RuleGroup) bool { return g.Name == group.Name }); i != -1 {
+			merged[i] = group
+		} else {
+			merged = append(merged, group)
+		}
+	}
+	res, err := yaml.Marshal(rules.RuleNamespace{Groups: merged})
+	if err != nil {
+		return configYAML
+	}
+	return string(res)
+}
+
+// rollbackRuleGroups restores the groups written to namespace as they were in
+// previous, deleting the ones which didn't exist. Failures are reported as
+// warnings, along with the groups left as written.
+func rollbackRuleGroups(ctx context.Context, client mimirClientInterface, namespace string, previous []rwrulefmt.RuleGroup, written []rwrulefmt.RuleGroup) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, group := range written {
+		var err error
+		if i := slices.IndexFunc(previous, func(g rwrulefmt.RuleGroup) bool { return g.Name == group.Name }); i != -1 {
+			err = client.CreateRuleGroup(ctx, namespace, previous[i])
+		} else {
+			err = client.DeleteRuleGroup(ctx, namespace, group.Name)
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Unable to roll back a rule group.",
+				Detail:   fmt.Sprintf("Group %q of namespace %q is left as written: %s", group.Name, namespace, err),
+			})
+		}
 	}
-	// Mimir top level key is the namespace name while in the YAML definition the top level key is groups
-	// Let's rename the key to be able to have a nice difference
-	remoteNamespaceRuleGroup["groups"] = remoteNamespaceRuleGroup[namespace]
-	delete(remoteNamespaceRuleGroup, namespace)
+	tflog.Info(ctx, "Rolled back the rule groups written", map[string]interface{}{
+		"namespace": namespace,
+		"groups":    len(written),
+		"failures":  len(diags),
+	})
+	return diags
+}
 
-	configYAML, err := yaml.Marshal(remoteNamespaceRuleGroup)
+// waitForRuleGroups waits for groups to be visible in namespace before they get
+// read back, as the ruler replicas of HA deployments may not have synced the
+// write yet. It gives up silently after readAfterWriteTimeout.
+func waitForRuleGroups(ctx context.Context, client mimirClientInterface, namespace string, groups []rwrulefmt.RuleGroup) {
+	visible := waitUntil(ctx, readAfterWriteTimeout, func() bool {
+		remote, err := client.ListRules(ctx, namespace)
+		if err != nil {
+			return false
+		}
+		for _, group := range groups {
+			i := slices.IndexFunc(remote[namespace], func(g rwrulefmt.RuleGroup) bool { return g.Name == group.Name })
+			if i == -1 || rules.CompareGroups(group, remote[namespace][i]) != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if !visible {
+		tflog.Warn(ctx, "Rule groups written are still not visible, reading them anyway", map[string]interface{}{
+			"namespace": namespace,
+		})
+	}
+}
+
+// validateNamespaceDependencies checks the alerts of ruleNamespace against the
+// recording rules of every namespace of the tenant.
+func validateNamespaceDependencies(ctx context.Context, client mimirClientInterface, namespace string, ruleNamespace rules.RuleNamespace) diag.Diagnostics {
+	tenantGroups, err := client.ListRules(ctx, "")
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.Diagnostics{
+			diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Unable to check recording rules dependencies.",
+				Detail:   err.Error(),
+			},
+		}
+	}
+	// The remote version of this namespace may still hold groups about to be removed.
+	delete(tenantGroups, namespace)
+	return checkRuleDependencies(ruleNamespace, tenantGroups)
+}
+
+// rulerNamespaceLints returns the warnings of the optional checks enabled on
+// the resource of d about ruleNamespace. The rule dependencies are only
+// checked, against the other namespaces of the tenant, when client is set.
+func rulerNamespaceLints(ctx context.Context, c *client, client mimirClientInterface, d attributeGetter, namespace string, ruleNamespace rules.RuleNamespace) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if client != nil && c.validateRuleDependencies && validatorEnabled(d, validatorRuleDependencies) {
+		diags = append(diags, validateNamespaceDependencies(ctx, client, namespace, ruleNamespace)...)
+	}
+	if d.Get("extended_validation").(bool) && validatorEnabled(d, validatorForDurations) {
+		diags = append(diags, checkAlertForDurations(ruleNamespace)...)
+	}
+	if d.Get("require_for_multiple_of_interval").(bool) && validatorEnabled(d, validatorForMultiples) {
+		diags = append(diags, checkAlertForMultiples(ruleNamespace)...)
+	}
+	if d.Get("extended_validation").(bool) && validatorEnabled(d, validatorQueryModifiers) {
+		diags = append(diags, checkQueryModifiers(ruleNamespace)...)
+	}
+	if d.Get("check_recording_rule_cardinality").(bool) && validatorEnabled(d, validatorCardinality) {
+		diags = append(diags, checkRecordingRuleCardinality(ruleNamespace, expandHighCardinalityMetrics(d.Get("high_cardinality_metrics").([]interface{})))...)
+	}
+	if c.warnDeprecated && validatorEnabled(d, validatorDeprecations) {
+		diags = append(diags, checkDeprecations(ruleNamespace)...)
 	}
-	d.Set("config_yaml", normalizeNamespaceYAML(string(configYAML)))
 	return diags
 }
 
-func rulerNamespaceUpdate(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+// checkTransformedNamespace checks the links of the annotations against the
+// allowed_annotation_domains of d, and the labels of the rules against its
+// forbidden_label_combinations, on the namespace as transformed.
+func checkTransformedNamespace(d attributeGetter, transformed rules.RuleNamespace) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := meta.(*client).cli
+	if domains := expandStringList(d.Get("allowed_annotation_domains").([]interface{})); len(domains) > 0 {
+		keys := expandStringList(d.Get("annotation_link_keys").([]interface{}))
+		if len(keys) == 0 {
+			keys = defaultAnnotationLinkKeys
+		}
+		diags = append(diags, checkAnnotationLinks(transformed, keys, domains)...)
+	}
+	return append(diags, checkForbiddenLabelCombinations(transformed, expandLabelCombinations(d.Get("forbidden_label_combinations").([]interface{})))...)
+}
+
+// planNamespaceWarnings sets planned_warnings to the warnings of
+// rulerNamespaceLints about the planned config_yaml.
+func planNamespaceWarnings(ctx context.Context, c *client, d *schema.ResourceDiff) error {
+	if !d.HasChanges("config_yaml", "validate", "skip_validation", "extended_validation", "require_for_multiple_of_interval", "check_recording_rule_cardinality", "high_cardinality_metrics") {
+		return nil
+	}
+	if !d.NewValueKnown("config_yaml") {
+		return d.SetNewComputed("planned_warnings")
+	}
+	ruleNamespace, err := getRuleNamespaceFromYAML(ctx, c.namespaces, d.Get("config_yaml").(string), validatorEnabled(d, validatorPromQL))
+	if err != nil {
+		return nil
+	}
+	var client mimirClientInterface
+	if d.NewValueKnown("tenant_id") {
+		// Without a client, e.g. with an unreachable Mimir, the dependencies
+		// are only checked when applying.
+		client, _ = c.tenantClient(withTenant(ctx, d.Get("tenant_id").(string)), "mimirtool_ruler_namespace")
+	}
+	var warnings []string
+	for _, warning := range rulerNamespaceLints(ctx, c, client, d, d.Get("namespace").(string), ruleNamespace) {
+		warnings = append(warnings, warning.Summary+" "+warning.Detail)
+	}
+	return d.SetNew("planned_warnings", warnings)
+}
+
+func rulerNamespaceRead(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	// The summary only describes the plan it was computed for, it is kept
+	// after the apply but not past the next refresh.
+	d.Set("planned_group_changes", "")
+	d.Set("planned_diff", "")
+	d.Set("planned_warnings", nil)
+	if c.fastRefresh && d.Get("remote_hash").(string) != "" {
+		unchanged, err := rulerNamespaceGroupsUnchanged(ctx, c, d)
+		if err != nil {
+			tflog.Debug(ctx, "Unable to list rule groups, falling back to a full read", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else if unchanged {
+			return nil
+		}
+	}
+	return rulerNamespaceReadFull(ctx, d, meta)
+}
+
+// rulerNamespaceGroupsUnchanged tells whether the groups of the namespace,
+// listed through the Prometheus rules API, are the ones of the state.
+func rulerNamespaceGroupsUnchanged(ctx context.Context, c *client, d *schema.ResourceData) (bool, error) {
 	namespace := d.Get("namespace").(string)
-	ruleGroup := d.Get("config_yaml").(string)
-	strictRecordingRuleCheck := d.Get("strict_recording_rule_check").(bool)
+	path := c.config.prometheusHTTPPrefix + "/api/v1/rules?exclude_alerts=true&file=" + url.QueryEscape(namespace)
+	body, err := c.apiGet(ctx, "mimirtool_ruler_namespace", path, nil)
+	if err != nil {
+		return false, err
+	}
+	var res struct {
+		Data struct {
+			Groups []struct {
+				Name string `json:"name"`
+				File string `json:"file"`
+			} `json:"groups"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return false, err
+	}
+	var remote []string
+	for _, group := range res.Data.Groups {
+		if group.File == namespace {
+			remote = append(remote, group.Name)
+		}
+	}
 
-	errDiag := rulerNamespaceCreate(ctx, d, meta)
-	if errDiag != nil {
-		return errDiag
+	var state rules.RuleNamespace
+	if err := yaml.Unmarshal([]byte(d.Get("config_yaml").(string)), &state); err != nil {
+		return false, err
 	}
-	// Clean up the rules which need to be updated have been so with rulerNamespaceCreate,
-	// we still need to delete the rules which have been removed from the definition.
-	ruleNamespace, err := getRuleNamespaceFromYAML(ctx, ruleGroup)
+	local := make([]string, 0, len(state.Groups))
+	for _, group := range state.Groups {
+		local = append(local, group.Name)
+	}
+	slices.Sort(remote)
+	slices.Sort(local)
+	return slices.Equal(remote, local), nil
+}
+
+// rulerNamespaceReadFull downloads the content of the namespace.
+func rulerNamespaceReadFull(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := meta.(*client)
+	client, err := meta.(*client).tenantClient(ctx, "mimirtool_ruler_namespace")
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = checkRecordingRules(ruleNamespace, strictRecordingRuleCheck)
+	namespace := d.Get("namespace").(string)
+
+	ctx, modified := withLastModified(ctx)
+	remoteNamespaceRuleGroup, err := client.ListRules(ctx, namespace)
+	if errors.Is(err, ErrNotFound) || (err == nil && len(remoteNamespaceRuleGroup[namespace]) == 0) {
+		return handleMissingResource(ctx, d, fmt.Sprintf("Namespace %q", namespace))
+	} else if err != nil {
+		return diag.FromErr(err)
+	}
+	// The groups are normalized as read, rather than going through their YAML.
+	normalized := normalizeRuleNamespace(rules.RuleNamespace{Groups: remoteNamespaceRuleGroup[namespace]})
+	// The mimirtool client drops the group fields it doesn't know, read them
+	// apart when the state has some.
+	if len(c.namespaces.parse(d.Get("config_yaml").(string)).groupFields()) > 0 {
+		raw, err := c.apiGet(ctx, "mimirtool_ruler_namespace", rulerConfigPath(c, namespace), nil)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		normalized = withUnknownGroupFields(normalized, unknownGroupFields(string(raw), namespace))
+	}
+	d.Set("content_sha256", hash(normalized))
+	// The state keeps the source of transformed content, as long as Mimir
+	// holds what the transforms make of it.
+	autoSplit := d.Get("auto_split_groups").(bool)
+	if transforms, err := expandRuleTransforms(d.Get("transform").([]interface{})); err == nil && (len(transforms) > 0 || autoSplit) {
+		source := d.Get("config_yaml").(string)
+		transformed, err := transformedNamespaceYAML(source, transforms)
+		if err == nil && autoSplit {
+			transformed, err = splitNamespaceYAML(ctx, c, d, transformed)
+		}
+		if err == nil && transformed == normalized {
+			normalized = c.namespaces.parse(source).normalizedYAML()
+		}
+	}
+	if d.Get("significant_group_order").(bool) {
+		normalized = orderGroupsLike(normalized, d.Get("config_yaml").(string))
+	}
+	if d.Get("preserve_field_order").(bool) {
+		normalized = orderYAMLLike(normalized, d.Get("config_yaml").(string))
+	}
+	d.Set("config_yaml", normalized)
+	d.Set("remote_hash", hash(normalized))
+	d.Set("last_modified", modified.String())
+	// Migrate IDs created with another id_scheme.
+	if id := rulerNamespaceID(c, d.Get("tenant_id").(string), namespace); d.Id() != id {
+		d.SetId(id)
+	}
+	return diags
+}
+
+func rulerNamespaceUpdate(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	client, err := c.tenantClient(ctx, "mimirtool_ruler_namespace")
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	namespace := d.Get("namespace").(string)
 
+	pushed, diags := rulerNamespaceWrite(ctx, d, meta)
+	if diags.HasError() {
+		return diags
+	}
+	// Clean up the rules which need to be updated have been so with rulerNamespaceWrite,
+	// we still need to delete the rules which have been removed from the definition.
+	// Split groups are uploaded under their new names.
 	var nsGroupNames []string
-	for _, group := range ruleNamespace.Groups {
+	for _, group := range pushed.Groups {
 		nsGroupNames = append(nsGroupNames, group.Name)
 	}
 
@@ -178,21 +918,29 @@ func rulerNamespaceUpdate(ctx context.Context, d *schema.ResourceData, meta any)
 	// All groups present in Mimir but not in the YAML definition must be deleted
 	for _, name := range currentGroupsNames {
 		if !slices.Contains(nsGroupNames, name) {
-			errRaw := client.DeleteRuleGroup(ctx, namespace, name)
+			err = client.DeleteRuleGroup(ctx, namespace, name)
 			if err != nil {
-				return diag.FromErr(errRaw)
+				// Make sure the next refresh notices the groups left.
+				d.Set("remote_hash", "")
+				return append(diags, diag.FromErr(err)...)
 			}
 		}
 	}
+	if d.Get("check_evaluation").(bool) {
+		diags = append(diags, checkRuleGroupsEvaluation(ctx, d, meta)...)
+	}
 	return diags
 }
 
 func rulerNamespaceDelete(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := meta.(*client).cli
+	client, err := meta.(*client).tenantClient(ctx, "mimirtool_ruler_namespace")
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	namespace := d.Get("namespace").(string)
 
-	err := client.DeleteNamespace(ctx, namespace)
+	err = client.DeleteNamespace(ctx, namespace)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -203,12 +951,13 @@ func rulerNamespaceDelete(ctx context.Context, d *schema.ResourceData, meta any)
 
 // Borrowed from https://github.com/grafana/terraform-provider-grafana/blob/master/grafana/resource_dashboard.go
 func normalizeNamespaceYAML(config any) string {
-	configYAML := config.(string)
-	var ruleNamespace rules.RuleNamespace
-
-	yaml.Unmarshal([]byte(configYAML), &ruleNamespace)
-	ruleNamespace.LintExpressions(rules.MimirBackend)
+	return parseNamespace(config.(string)).normalizedYAML()
+}
 
+// normalizeRuleNamespace lints the expressions of ruleNamespace, which it
+// modifies, and returns its canonical YAML.
+func normalizeRuleNamespace(ruleNamespace rules.RuleNamespace) string {
+	lintExpressions(ruleNamespace)
 	namespaceBytes, _ := yaml.Marshal(ruleNamespace)
 	return string(namespaceBytes)
 }
@@ -216,7 +965,9 @@ func normalizeNamespaceYAML(config any) string {
 func validateNamespaceYAML(config any, k cty.Path) diag.Diagnostics {
 	var diags diag.Diagnostics
 	configYAML := config.(string)
-	_, err := getRuleNamespaceFromYAML(context.Background(), configYAML)
+	// The rules are validated by CustomizeDiff, unless the promql validator
+	// is skipped.
+	ruleNamespace, err := getRuleNamespaceFromYAML(context.Background(), nil, configYAML, false)
 	if err != nil {
 		return diag.Diagnostics{
 			diag.Diagnostic{
@@ -227,32 +978,53 @@ func validateNamespaceYAML(config any, k cty.Path) diag.Diagnostics {
 			},
 		}
 	}
+	for _, group := range ruleNamespace.Groups {
+		if group.Interval == 0 {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Warning,
+				Summary:       "Rule group has no evaluation interval.",
+				Detail:        fmt.Sprintf("Group %q doesn't set `interval`, it is evaluated at the default interval of the ruler, %s unless the tenant overrides it.", group.Name, defaultEvaluationInterval),
+				AttributePath: k,
+			})
+		}
+	}
 	return diags
 }
 
-func diffNamespaceYAML(_, oldValue, newValue string, _ *schema.ResourceData) bool {
-	var (
-		oldConfig rules.RuleNamespace
-		newConfig rules.RuleNamespace
-		err       error
-	)
+func diffNamespaceYAML(_, oldValue, newValue string, d *schema.ResourceData) bool {
+	if oldValue == newValue {
+		return true
+	}
+	oldNamespace, newNamespace := parseNamespace(oldValue), parseNamespace(newValue)
 
 	// If we cannot unmarshal, as we cannot return an error, let's say there is a difference
-	err = yaml.Unmarshal([]byte(newValue), &newConfig)
+	newConfig, err := newNamespace.decodedNamespace()
 	if err != nil {
 		log.Printf("[ERROR] new ConfigYAML: %s", newValue)
 		log.Printf("[ERROR] failed to unmarshal new ConfigYAML: %s", err.Error())
 		return false
 	}
-	err = yaml.Unmarshal([]byte(oldValue), &oldConfig)
+	oldConfig, err := oldNamespace.decodedNamespace()
 	if err != nil {
 		log.Printf("[ERROR] old ConfigYAML: %s", oldValue)
 		log.Printf("[ERROR] failed to unmarshal old ConfigYAML: %s", err.Error())
 		return false
 	}
 
+	if d != nil && d.Get("significant_group_order").(bool) && !slices.Equal(ruleGroupNames(oldConfig), ruleGroupNames(newConfig)) {
+		return false
+	}
 	return rules.CompareNamespaces(
 		oldConfig,
 		newConfig,
-	).State == rules.Unchanged
+	).State == rules.Unchanged && unknownGroupFieldsEqual(oldNamespace.groupFields(), newNamespace.groupFields())
+}
+
+// ruleGroupNames returns the names of the groups of namespace, in order.
+func ruleGroupNames(namespace rules.RuleNamespace) []string {
+	names := make([]string, 0, len(namespace.Groups))
+	for _, group := range namespace.Groups {
+		names = append(names, group.Name)
+	}
+	return names
 }
@@ -1,10 +1,21 @@
 package mimirtool
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"gopkg.in/yaml.v3"
 )
 
 func TestAccResourceNamespace(t *testing.T) {
@@ -34,6 +45,52 @@ func TestAccResourceNamespace(t *testing.T) {
 	})
 }
 
+func TestRulerNamespaceReadMissing(t *testing.T) {
+	for behavior, wantErr := range map[string]bool{
+		missingResourceRecreate: false,
+		missingResourceError:    true,
+	} {
+		d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{
+			"namespace":                 "demo",
+			"config_yaml":               testAccResourceNamespaceYaml,
+			"missing_resource_behavior": behavior,
+		})
+		d.SetId(hash("demo"))
+
+		diags := rulerNamespaceRead(context.Background(), d, &client{cli: newFakeMimirClient()})
+		if diags.HasError() != wantErr {
+			t.Fatalf("%s: unexpected diagnostics: %v", behavior, diags)
+		}
+		if !wantErr && d.Id() != "" {
+			t.Fatalf("%s: expected the resource to be removed from the state", behavior)
+		}
+	}
+}
+
+func TestValidateNamespaceYAML(t *testing.T) {
+	diags := validateNamespaceYAML("groups:\n  - name: a\n    interval: 30s\n    rules: []\n  - name: b\n    rules: []\n", nil)
+	if diags.HasError() || len(diags) != 1 || !strings.Contains(diags[0].Detail, `Group "b" doesn't set `+"`interval`") {
+		t.Fatalf("expected a warning about the interval of group b, got %v", diags)
+	}
+	if diags := validateNamespaceYAML("{}\n", nil); !diags.HasError() || !strings.Contains(diags[0].Detail, "got an empty mapping") {
+		t.Fatalf("expected an empty mapping to be refused, got %v", diags)
+	}
+}
+
+func TestParseRuleNamespaceSkipsPromQL(t *testing.T) {
+	repeated := "groups:\n  - name: a\n    rules: []\n  - name: a\n    rules: []\n"
+	if _, err := parseRuleNamespace(repeated, true); err == nil {
+		t.Fatal("expected the validation to refuse the repeated group")
+	}
+	if _, err := parseRuleNamespace(repeated, false); err != nil {
+		t.Fatalf("expected the namespace to decode without validation, got: %s", err)
+	}
+	unknown := "groups:\n  - name: a\n    rules:\n      - record: a\n        exp: up\n"
+	if _, err := parseRuleNamespace(unknown, false); err == nil || !strings.Contains(err.Error(), "exp") {
+		t.Fatalf("expected the unknown field to be refused without validation, got: %v", err)
+	}
+}
+
 func TestAccResourceNamespaceRename(t *testing.T) {
 	resource.UnitTest(t, resource.TestCase{
 		PreCheck:          func() { testAccPreCheck(t) },
@@ -226,3 +283,410 @@ const testAccResourceNamespaceQuotingExpected = `groups:
                   LABELS = {{ $labels }}
             summary: Host high CPU load (instance {{ $labels.instance }})
 `
+
+func TestWaitForRuleGroups(t *testing.T) {
+	fake := newFakeMimirClient()
+	group := rwrulefmt.RuleGroup{}
+	group.Name = "demo"
+	if err := fake.CreateRuleGroup(context.Background(), "demo", group); err != nil {
+		t.Fatal(err)
+	}
+	fake.staleReads = 2
+
+	waitForRuleGroups(context.Background(), fake, "demo", []rwrulefmt.RuleGroup{group})
+	if fake.staleReads != 0 {
+		t.Fatalf("expected stale reads to be retried, %d left", fake.staleReads)
+	}
+	remote, _ := fake.ListRules(context.Background(), "demo")
+	if len(remote["demo"]) != 1 {
+		t.Fatalf("expected the group to be visible, got %v", remote)
+	}
+}
+
+func TestRulerNamespaceIDScheme(t *testing.T) {
+	fake := newFakeMimirClient()
+	group := rwrulefmt.RuleGroup{}
+	group.Name = "group"
+	fake.CreateRuleGroup(context.Background(), "demo", group)
+
+	meta := &client{cli: fake, idScheme: idSchemeTenantNamespace}
+	meta.config.ID = "team-a"
+
+	for _, importID := range []string{"demo", "team-a/demo", "/demo"} {
+		d := resourceRulerNamespace().TestResourceData()
+		d.SetId(importID)
+		imported, err := rulerNamespaceImport(context.Background(), d, meta)
+		if err != nil {
+			t.Fatalf("%s: %s", importID, err)
+		}
+		if imported[0].Id() != "team-a/demo" || imported[0].Get("namespace") != "demo" {
+			t.Fatalf("%s: unexpected import result, id=%q namespace=%q", importID, imported[0].Id(), imported[0].Get("namespace"))
+		}
+	}
+
+	// IDs of the namespace scheme are migrated on refresh.
+	d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{"namespace": "demo"})
+	d.SetId(hash("demo"))
+	if diags := rulerNamespaceRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Id() != "team-a/demo" {
+		t.Fatalf("expected the ID to be migrated, got %q", d.Id())
+	}
+}
+
+func TestRulerNamespaceFastRefresh(t *testing.T) {
+	groups := `{"status":"success","data":{"groups":[{"name":"group","file":"demo","rules":[]}]}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(groups))
+	}))
+	defer server.Close()
+
+	fake := newFakeMimirClient()
+	// Any full read fails, so that the test tells whether one happened.
+	fake.err = errors.New("unexpected full read")
+	meta := &client{cli: fake, fastRefresh: true}
+	meta.config.Address = server.URL
+
+	d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{
+		"namespace":   "demo",
+		"config_yaml": "groups:\n  - name: group\n    rules: []\n",
+	})
+	d.SetId(hash("demo"))
+	d.Set("remote_hash", "cached")
+	if diags := rulerNamespaceRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("expected the full read to be skipped: %v", diags)
+	}
+	if d.Get("remote_hash") != "cached" {
+		t.Fatalf("expected the cached hash to be kept, got %q", d.Get("remote_hash"))
+	}
+
+	groups = `{"status":"success","data":{"groups":[{"name":"other","file":"demo","rules":[]}]}}`
+	if diags := rulerNamespaceRead(context.Background(), d, meta); !diags.HasError() {
+		t.Fatal("expected a full read when the groups changed")
+	}
+}
+
+func TestRulerNamespaceRollbackOnFailure(t *testing.T) {
+	fake := newFakeMimirClient()
+	var previous rwrulefmt.RuleGroup
+	previous.Name = "first"
+	previous.Limit = 10
+	fake.CreateRuleGroup(context.Background(), "demo", previous)
+	fake.groupErrs = map[string]error{"third": errors.New("rejected")}
+
+	meta := &client{cli: fake, rollbackOnFailure: true}
+	d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{
+		"namespace":   "demo",
+		"config_yaml": "groups:\n  - name: first\n    rules: []\n  - name: second\n    rules: []\n  - name: third\n    rules: []\n",
+	})
+	diags := rulerNamespaceCreate(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatal("expected the failed write to be reported")
+	}
+	if len(diags) != 1 {
+		t.Fatalf("expected the rollback to succeed, got %v", diags)
+	}
+
+	remote, _ := fake.ListRules(context.Background(), "demo")
+	if len(remote["demo"]) != 1 || remote["demo"][0].Name != "first" || remote["demo"][0].Limit != previous.Limit {
+		t.Fatalf("expected the namespace to be restored, got %v", remote["demo"])
+	}
+}
+
+// testRulerNamespaceUpdateData returns the data of an update of the namespace
+// demo from the oldYAML state to the newYAML configuration.
+func testRulerNamespaceUpdateData(t *testing.T, meta *client, oldYAML, newYAML string) *schema.ResourceData {
+	t.Helper()
+	r := resourceRulerNamespace()
+	state := &terraform.InstanceState{ID: hash("demo"), Attributes: map[string]string{
+		"id":          hash("demo"),
+		"namespace":   "demo",
+		"config_yaml": oldYAML,
+		"remote_hash": hash(oldYAML),
+	}}
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"namespace":   "demo",
+		"config_yaml": newYAML,
+	}), meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := schema.InternalMap(r.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestRulerNamespaceUpdateCanceled(t *testing.T) {
+	group := func(name, version string) string {
+		return fmt.Sprintf("  - name: %s\n    rules:\n      - record: %s:version\n        expr: vector(%s)\n", name, name, version)
+	}
+	oldYAML := normalizeNamespaceYAML("groups:\n" + group("a", "1") + group("b", "1"))
+	newYAML := "groups:\n" + group("a", "2") + group("b", "2") + group("c", "2")
+	remoteGroups := func(fake *fakeMimirClient) string {
+		remote, _ := fake.ListRules(context.Background(), "demo")
+		content, _ := yaml.Marshal(rules.RuleNamespace{Groups: remote["demo"]})
+		return string(content)
+	}
+
+	for _, rollback := range []bool{false, true} {
+		fake := newFakeMimirClient()
+		ruleNamespace, _ := getRuleNamespaceFromYAML(context.Background(), nil, oldYAML, true)
+		for _, g := range ruleNamespace.Groups {
+			fake.CreateRuleGroup(context.Background(), "demo", g)
+		}
+		meta := &client{cli: fake, rollbackOnFailure: rollback}
+
+		// Cancel the apply while pushing the second group.
+		ctx, cancel := context.WithCancel(context.Background())
+		writes := 0
+		fake.onWrite = func() {
+			if writes++; writes == 2 {
+				cancel()
+			}
+		}
+		d := testRulerNamespaceUpdateData(t, meta, oldYAML, newYAML)
+		if diags := rulerNamespaceUpdate(ctx, d, meta); !diags.HasError() {
+			t.Fatalf("rollback=%t: expected the cancellation to be reported", rollback)
+		}
+		fake.onWrite = nil
+
+		state := d.Get("config_yaml").(string)
+		if rollback {
+			if !diffNamespaceYAML("config_yaml", oldYAML, remoteGroups(fake), nil) || state != oldYAML {
+				t.Fatalf("expected the namespace and the state to be rolled back, got remote:\n%s\nstate:\n%s", remoteGroups(fake), state)
+			}
+		} else if !diffNamespaceYAML("config_yaml", state, remoteGroups(fake), nil) || d.Get("remote_hash") != "" {
+			t.Fatalf("expected the state to record the groups written, got remote:\n%s\nstate:\n%s", remoteGroups(fake), state)
+		}
+
+		// A plain re-apply pushes the remaining groups.
+		d = testRulerNamespaceUpdateData(t, meta, state, newYAML)
+		if diags := rulerNamespaceUpdate(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("rollback=%t: unexpected error on re-apply: %v", rollback, diags)
+		}
+		if !diffNamespaceYAML("config_yaml", normalizeNamespaceYAML(newYAML), remoteGroups(fake), nil) {
+			t.Fatalf("rollback=%t: expected the re-apply to converge, got:\n%s", rollback, remoteGroups(fake))
+		}
+	}
+}
+
+func TestRulerNamespaceContentSHA256(t *testing.T) {
+	fake := newFakeMimirClient()
+	group := rwrulefmt.RuleGroup{}
+	group.Name = "group"
+	fake.CreateRuleGroup(context.Background(), "demo", group)
+
+	sums := map[bool]string{}
+	for _, preserve := range []bool{false, true} {
+		d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{
+			"namespace":            "demo",
+			"config_yaml":          "groups:\n  - rules: []\n    name: group\n",
+			"preserve_field_order": preserve,
+		})
+		d.SetId(hash("demo"))
+		if diags := rulerNamespaceRead(context.Background(), d, &client{cli: fake}); diags.HasError() {
+			t.Fatal(diags)
+		}
+		sums[preserve] = d.Get("content_sha256").(string)
+	}
+	if sums[false] == "" || sums[false] != sums[true] {
+		t.Fatalf("expected the hash not to depend on the field order, got %v", sums)
+	}
+}
+
+func TestRulerNamespaceNamePattern(t *testing.T) {
+	meta := &client{cli: newFakeMimirClient(), namespaceNamePattern: regexp.MustCompile(`^(?:[a-z]+-[a-z]+)$`)}
+	if err := meta.checkNamespaceName("sre-alerts"); err != nil {
+		t.Fatal(err)
+	}
+	for _, namespace := range []string{"alerts", "sre-alerts-old", "xsre-alerts "} {
+		if err := meta.checkNamespaceName(namespace); err == nil || !strings.Contains(err.Error(), `namespace_name_pattern "[a-z]+-[a-z]+"`) {
+			t.Errorf("%q: expected a naming convention error, got %v", namespace, err)
+		}
+	}
+
+	d := resourceRulerNamespace().TestResourceData()
+	d.SetId("Alerts")
+	if _, err := rulerNamespaceImport(context.Background(), d, meta); err == nil {
+		t.Error("expected the import of a non-conforming namespace to fail")
+	}
+	if err := (&client{}).checkNamespaceName("Alerts"); err != nil {
+		t.Errorf("expected no check without pattern, got %v", err)
+	}
+}
+
+func TestRulerNamespaceTenantOverride(t *testing.T) {
+	fakes := map[string]*fakeMimirClient{"team-a": newFakeMimirClient(), "team-b": newFakeMimirClient()}
+	built := map[string]int{}
+	p := newProvider("dev", func(cfg clientConfig) (mimirClientInterface, error) {
+		built[cfg.ID]++
+		return fakes[cfg.ID], nil
+	})()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{"address": "http://mimir.invalid", "tenant_id": "team-a"})
+	if diags := p.Configure(context.Background(), config); diags.HasError() {
+		t.Fatal(diags)
+	}
+	r := p.ResourcesMap["mimirtool_ruler_namespace"]
+
+	for i := 0; i < 2; i++ {
+		d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+			"namespace":   "demo",
+			"tenant_id":   "team-b",
+			"config_yaml": "groups:\n  - name: group\n    rules: []\n",
+		})
+		if diags := r.CreateContext(context.Background(), d, p.Meta()); diags.HasError() {
+			t.Fatal(diags)
+		}
+		if d.Id() != "team-b/demo" {
+			t.Fatalf("expected the ID to hold the tenant, got %q", d.Id())
+		}
+	}
+	if remote, _ := fakes["team-b"].ListRules(context.Background(), "demo"); len(remote["demo"]) != 1 {
+		t.Errorf("expected the namespace to be written for team-b, got %v", remote)
+	}
+	if remote, _ := fakes["team-a"].ListRules(context.Background(), "demo"); len(remote["demo"]) != 0 {
+		t.Errorf("expected nothing written for the provider tenant, got %v", remote)
+	}
+	if built["team-b"] != 1 {
+		t.Errorf("expected the client of team-b to be built once, got %d", built["team-b"])
+	}
+	if !r.Schema["tenant_id"].ForceNew {
+		t.Error("expected a tenant change to recreate the namespace")
+	}
+
+	d := r.TestResourceData()
+	d.SetId("team-b/demo")
+	imported, err := rulerNamespaceImport(context.Background(), d, p.Meta())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported[0].Get("tenant_id") != "team-b" || imported[0].Get("namespace") != "demo" || imported[0].Id() != "team-b/demo" {
+		t.Errorf("unexpected import result, id=%q tenant=%q namespace=%q", imported[0].Id(), imported[0].Get("tenant_id"), imported[0].Get("namespace"))
+	}
+}
+
+func TestRulerNamespaceImportRead(t *testing.T) {
+	source := `groups:
+  - name: group
+    interval: 1m
+    rules:
+      - alert: Down
+        expr: up == 0
+        for: 5m
+`
+	var group rwrulefmt.RuleGroup
+	if err := yaml.Unmarshal([]byte(`
+name: group
+interval: 1m
+rules:
+  - alert: Down
+    expr: up == 0
+    for: 5m
+`), &group); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeMimirClient()
+	fake.CreateRuleGroup(context.Background(), "demo", group)
+	meta := &client{cli: fake}
+	meta.config.ID = "team-a"
+
+	d := resourceRulerNamespace().TestResourceData()
+	d.SetId("team-a/demo")
+	imported, err := rulerNamespaceImport(context.Background(), d, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d = imported[0]
+	if diags := rulerNamespaceRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatal(diags)
+	}
+	configYAML := d.Get("config_yaml").(string)
+	if d.Get("namespace") != "demo" || d.Get("tenant_id") != "" || d.Get("content_sha256") != hash(configYAML) {
+		t.Fatalf("unexpected imported state: namespace %v, tenant %v, config_yaml %q", d.Get("namespace"), d.Get("tenant_id"), configYAML)
+	}
+	// The configuration the namespace was written from plans no change.
+	if !diffNamespaceYAML("config_yaml", configYAML, source, nil) {
+		t.Errorf("expected no change to be planned, the imported content is %q", configYAML)
+	}
+
+	d = resourceRulerNamespace().TestResourceData()
+	d.SetId("missing")
+	imported, err = rulerNamespaceImport(context.Background(), d, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := rulerNamespaceRead(context.Background(), imported[0], meta); diags.HasError() || imported[0].Id() != "" {
+		t.Errorf("expected a missing namespace to be removed for Terraform to report it, got %v, id %q", diags, imported[0].Id())
+	}
+}
+
+func TestRulerNamespaceSignificantGroupOrder(t *testing.T) {
+	reordered := `groups:
+  - name: b
+    rules:
+      - record: b
+        expr: up
+  - name: a
+    rules:
+      - record: a
+        expr: up
+`
+	ordered := `groups:
+  - name: a
+    rules:
+      - record: a
+        expr: up
+  - name: b
+    rules:
+      - record: b
+        expr: up
+`
+	fake := newFakeMimirClient()
+	ruleNamespace, _ := getRuleNamespaceFromYAML(context.Background(), nil, ordered, true)
+	for _, group := range ruleNamespace.Groups {
+		fake.CreateRuleGroup(context.Background(), "demo", group)
+	}
+	remote := normalizeNamespaceYAML(ordered)
+
+	d := resourceRulerNamespace().TestResourceData()
+	d.SetId("demo")
+	d.Set("namespace", "demo")
+	d.Set("config_yaml", normalizeNamespaceYAML(reordered))
+	if !diffNamespaceYAML("config_yaml", remote, reordered, d) {
+		t.Error("expected the order of the groups to be ignored by default")
+	}
+
+	d.Set("significant_group_order", true)
+	if diffNamespaceYAML("config_yaml", remote, reordered, d) {
+		t.Error("expected a change of the order of the groups to be planned")
+	}
+	// Mimir doesn't keep the order, the state keeps the one of the source.
+	if diags := rulerNamespaceRead(context.Background(), d, &client{cli: fake}); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if state := d.Get("config_yaml").(string); state != normalizeNamespaceYAML(reordered) {
+		t.Errorf("expected the state to keep the order of the source, got %q", state)
+	}
+}
+
+func TestRulerNamespacePlannedWarnings(t *testing.T) {
+	r := resourceRulerNamespace()
+	config := "groups:\n  - name: a\n    interval: 5m\n    rules:\n      - alert: Down\n        expr: up == 0\n        for: 1m\n"
+	diff, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"namespace":           "demo",
+		"config_yaml":         config,
+		"extended_validation": true,
+	}), &client{cli: newFakeMimirClient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if warning := diff.Attributes["planned_warnings.0"]; warning == nil || !strings.Contains(warning.New, `Alert "Down" of group "a" waits for 1m`) {
+		t.Fatalf("expected the plan to hold the warning about the `for` of the alert, got %v", warning)
+	}
+	if count := diff.Attributes["planned_warnings.#"]; count == nil || count.New != "1" {
+		t.Fatalf("expected a single planned warning, got %v", count)
+	}
+}
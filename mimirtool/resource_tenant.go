@@ -0,0 +1,60 @@
+package mimirtool
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// tenantSchema is the tenant_id of the resources, which overrides the
+// provider one for their object.
+func tenantSchema(object string) *schema.Schema {
+	return &schema.Schema{
+		Description: "The tenant of the " + object + ", overriding the provider `tenant_id` so that a single provider manages several tenants. The clients of the tenants are built once and shared by their resources, they use the `tenant_credentials` of the tenant, the provider credentials otherwise. The ID of the resource holds the tenant. Changing it recreates the resource.",
+		Type:        schema.TypeString,
+		Optional:    true,
+		ForceNew:    true,
+	}
+}
+
+type tenantKey struct{}
+
+// withTenant returns ctx along with the tenant the operation works for.
+func withTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// tenant returns the tenant of the operation of ctx, the provider one unless
+// its resource overrides it.
+func (c *client) tenant(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantKey{}).(string); ok && tenant != "" {
+		return tenant
+	}
+	return c.config.ID
+}
+
+// tenantClient returns the client of the tenant of the operation of ctx.
+func (c *client) tenantClient(ctx context.Context, resource string) (mimirClientInterface, error) {
+	return c.mimirClientForTenant(resource, c.tenant(ctx))
+}
+
+// applyResourceTenant makes the operations of r work for the tenant_id of
+// their resource.
+func applyResourceTenant(r *schema.Resource) {
+	if _, ok := r.Schema["tenant_id"]; !ok {
+		return
+	}
+	wrap := func(f func(context.Context, *schema.ResourceData, any) diag.Diagnostics) func(context.Context, *schema.ResourceData, any) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+			return f(withTenant(ctx, d.Get("tenant_id").(string)), d, meta)
+		}
+	}
+	r.CreateContext = wrap(r.CreateContext)
+	r.ReadContext = wrap(r.ReadContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+}
@@ -0,0 +1,324 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// Bounds of the retry settings.
+const (
+	maxRetryAttempts = 20
+	minRetryBackoff  = 10 * time.Millisecond
+	maxRetryBackoff  = 10 * time.Minute
+)
+
+// retryPolicy tells how the calls to Mimir failing with a transient error are
+// retried.
+type retryPolicy struct {
+	// maxAttempts bounds the attempts of a call, 1 disables the retries.
+	maxAttempts int
+	// backoff is the delay before the first retry, doubled on each retry up to
+	// maxBackoff.
+	backoff    time.Duration
+	maxBackoff time.Duration
+	// statuses are the HTTP statuses retried, the default ones when nil.
+	statuses *retryableStatuses
+	// source tells where the policy comes from, for the logs.
+	source string
+}
+
+// Modes of the retryable status codes.
+const (
+	retryableStatusesExtend  = "extend"
+	retryableStatusesReplace = "replace"
+)
+
+// retryableStatuses are the HTTP statuses of the transient errors of Mimir,
+// the codes being added to the default ones, 429 and the 5xx ones, unless
+// replace is set.
+type retryableStatuses struct {
+	codes   map[int]bool
+	replace bool
+}
+
+// newRetryableStatuses returns the statuses of the settings, nil for the
+// default ones.
+func newRetryableStatuses(codes []int, mode string) *retryableStatuses {
+	if len(codes) == 0 && mode != retryableStatusesReplace {
+		return nil
+	}
+	s := &retryableStatuses{codes: make(map[int]bool, len(codes)), replace: mode == retryableStatusesReplace}
+	for _, code := range codes {
+		s.codes[code] = true
+	}
+	return s
+}
+
+// retryable tells whether status is transient.
+func (s *retryableStatuses) retryable(status int) bool {
+	byDefault := status == 429 || status >= 500
+	if s == nil {
+		return byDefault
+	}
+	return s.codes[status] || (!s.replace && byDefault)
+}
+
+type retryPolicyKey struct{}
+
+// withRetryPolicy returns ctx along with the retry policy of the operation.
+func withRetryPolicy(ctx context.Context, policy retryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+// retryPolicyFrom returns the retry policy of the operation of ctx, or def.
+func retryPolicyFrom(ctx context.Context, def retryPolicy) retryPolicy {
+	if policy, ok := ctx.Value(retryPolicyKey{}).(retryPolicy); ok {
+		return policy
+	}
+	return def
+}
+
+// retryable tells whether err, returned by an attempt of the call of ctx, is
+// transient: one of statuses, by default rate limiting or a server error, a
+// network error or the timeout of the attempt.
+func retryable(ctx context.Context, err error, statuses *retryableStatuses) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, errRedirect) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		// Only the attempt timed out, e.g. on the timeout of the HTTP client,
+		// when the call still has time.
+		return ctx.Err() == nil
+	}
+	var tooLarge bodyTooLargeError
+	var missingComponent *componentError
+	if errors.As(err, &tooLarge) || errors.As(err, &missingComponent) {
+		return false
+	}
+	if match := httpStatusRegexp.FindStringSubmatch(err.Error()); match != nil {
+		status, _ := strconv.Atoi(match[1])
+		return statuses.retryable(status)
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return statuses.retryable(429)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retry runs f, the call operation, until it succeeds, fails with an error
+// which is not transient or the attempts of policy are exhausted. The retries
+// are counted in stats, and the error of a retried call tells how many
+// attempts were made.
+func retry(ctx context.Context, policy retryPolicy, stats *apiStats, operation string, f func() error) error {
+	backoff := policy.backoff
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= policy.maxAttempts || !retryable(ctx, err, policy.statuses) {
+			return attemptsError(err, attempt)
+		}
+		tflog.Debug(ctx, "Retrying a failed Grafana Mimir call", map[string]interface{}{
+			"operation":    operation,
+			"attempt":      attempt,
+			"max_attempts": policy.maxAttempts,
+			"backoff":      backoff.String(),
+			"policy":       policy.source,
+			"error":        err.Error(),
+		})
+		stats.add(ctx, countRetries, 1)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return attemptsError(err, attempt)
+		}
+		backoff = min(2*backoff, policy.maxBackoff)
+	}
+}
+
+// attemptsError returns err with the number of attempts of the call, when it
+// was retried.
+func attemptsError(err error, attempts int) error {
+	if err == nil || attempts == 1 {
+		return err
+	}
+	return fmt.Errorf("%w (gave up after %d attempts)", err, attempts)
+}
+
+// defaultRetryMaxAttempts is the default of retry_max_attempts.
+const defaultRetryMaxAttempts = 3
+
+// retryMaxAttemptsDefault returns the default of retry_max_attempts, read from
+// the environment. MIMIR_CLIENT_RETRIES counts the retries rather than the
+// attempts.
+func retryMaxAttemptsDefault() (any, error) {
+	if v, err := schema.MultiEnvDefaultFunc([]string{"MIMIRTOOL_RETRY_MAX_ATTEMPTS", "MIMIR_RETRY_MAX_ATTEMPTS"}, nil)(); v != nil || err != nil {
+		return v, err
+	}
+	if v := os.Getenv("MIMIR_CLIENT_RETRIES"); v != "" {
+		retries, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MIMIR_CLIENT_RETRIES: %w", err)
+		}
+		return retries + 1, nil
+	}
+	return defaultRetryMaxAttempts, nil
+}
+
+// providerRetrySettings returns the attempts, the backoff and the maximum
+// backoff set on the provider of d. The deprecated retries,
+// retry_backoff_seconds, retry_min_wait and retry_max_wait stand for
+// retry_max_attempts, retry_backoff and retry_max_backoff when set, so that a
+// single set of settings is in effect.
+func providerRetrySettings(d *schema.ResourceData) (int, string, string) {
+	maxAttempts := d.Get("retry_max_attempts").(int)
+	if v, ok := d.GetOk("retries"); ok || configured(d, "retries") {
+		maxAttempts = v.(int) + 1
+	}
+	backoff := d.Get("retry_backoff").(string)
+	if v, ok := d.GetOk("retry_backoff_seconds"); ok {
+		backoff = time.Duration(v.(float64) * float64(time.Second)).String()
+	}
+	if v, ok := d.GetOk("retry_min_wait"); ok {
+		backoff = v.(string)
+	}
+	maxBackoff := d.Get("retry_max_backoff").(string)
+	if v, ok := d.GetOk("retry_max_wait"); ok {
+		maxBackoff = v.(string)
+	}
+	return maxAttempts, backoff, maxBackoff
+}
+
+// configured tells whether the attribute key is set in the configuration of
+// d, even to its zero value.
+func configured(d *schema.ResourceData, key string) bool {
+	raw := d.GetRawConfig()
+	return raw.IsKnown() && !raw.IsNull() && !raw.GetAttr(key).IsNull()
+}
+
+// validateRetryBackoff validates a retry delay.
+func validateRetryBackoff(value any, k cty.Path) diag.Diagnostics {
+	d, err := time.ParseDuration(value.(string))
+	if err == nil && (d < minRetryBackoff || d > maxRetryBackoff) {
+		err = fmt.Errorf("expected between %s and %s, got %s", minRetryBackoff, maxRetryBackoff, d)
+	}
+	if err != nil {
+		return diag.Diagnostics{
+			diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid retry delay.",
+				Detail:        err.Error(),
+				AttributePath: k,
+			},
+		}
+	}
+	return nil
+}
+
+// newRetryPolicy returns the policy of the settings, a maxBackoff shorter than
+// backoff is raised to it.
+func newRetryPolicy(source string, maxAttempts int, backoff, maxBackoff string, statuses *retryableStatuses) (retryPolicy, error) {
+	policy := retryPolicy{maxAttempts: maxAttempts, statuses: statuses, source: source}
+	var err error
+	if policy.backoff, err = time.ParseDuration(backoff); err != nil {
+		return policy, fmt.Errorf("invalid retry backoff: %w", err)
+	}
+	if policy.maxBackoff, err = time.ParseDuration(maxBackoff); err != nil {
+		return policy, fmt.Errorf("invalid retry max_backoff: %w", err)
+	}
+	policy.maxBackoff = max(policy.maxBackoff, policy.backoff)
+	return policy, nil
+}
+
+// retrySchema is the block of a resource overriding the provider retry
+// settings for its operations.
+func retrySchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Override the provider retry settings for the operations of this resource, the settings left unset keep the provider ones.",
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"max_attempts": {
+					Description:  fmt.Sprintf("The attempts of a call failing with a transient error, between 1, which disables the retries, and %d.", maxRetryAttempts),
+					Type:         schema.TypeInt,
+					Optional:     true,
+					ValidateFunc: validation.IntBetween(1, maxRetryAttempts),
+				},
+				"backoff": {
+					Description:      fmt.Sprintf("The delay before the first retry, doubled on each retry, between %s and %s.", minRetryBackoff, maxRetryBackoff),
+					Type:             schema.TypeString,
+					Optional:         true,
+					ValidateDiagFunc: validateRetryBackoff,
+				},
+				"max_backoff": {
+					Description:      fmt.Sprintf("The maximum delay between two retries, between %s and %s. It is raised to `backoff` when shorter.", minRetryBackoff, maxRetryBackoff),
+					Type:             schema.TypeString,
+					Optional:         true,
+					ValidateDiagFunc: validateRetryBackoff,
+				},
+			},
+		},
+	}
+}
+
+// resourceRetryPolicy returns the retry policy of the resource of d, its retry
+// block overriding def.
+func resourceRetryPolicy(d *schema.ResourceData, def retryPolicy) retryPolicy {
+	blocks, _ := d.Get("retry").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return def
+	}
+	block := blocks[0].(map[string]interface{})
+	policy := def
+	policy.source = "resource"
+	if v, _ := block["max_attempts"].(int); v > 0 {
+		policy.maxAttempts = v
+	}
+	if v, err := time.ParseDuration(block["backoff"].(string)); err == nil {
+		policy.backoff = v
+	}
+	if v, err := time.ParseDuration(block["max_backoff"].(string)); err == nil {
+		policy.maxBackoff = v
+	}
+	policy.maxBackoff = max(policy.maxBackoff, policy.backoff)
+	return policy
+}
+
+// applyRetryPolicy makes the operations of r, which has a retry block, run
+// with the retry policy it sets.
+func applyRetryPolicy(r *schema.Resource) {
+	wrap := func(f func(context.Context, *schema.ResourceData, any) diag.Diagnostics) func(context.Context, *schema.ResourceData, any) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+			if c, ok := meta.(*client); ok {
+				policy := resourceRetryPolicy(d, c.retry)
+				tflog.Debug(ctx, "Retry policy of the operation", map[string]interface{}{
+					"policy":       policy.source,
+					"max_attempts": policy.maxAttempts,
+					"backoff":      policy.backoff.String(),
+					"max_backoff":  policy.maxBackoff.String(),
+				})
+				ctx = withRetryPolicy(ctx, policy)
+			}
+			return f(ctx, d, meta)
+		}
+	}
+	r.CreateContext = wrap(r.CreateContext)
+	r.ReadContext = wrap(r.ReadContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+}
@@ -0,0 +1,155 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRetry(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 3, backoff: time.Millisecond, maxBackoff: time.Millisecond}
+	for _, tc := range []struct {
+		err      error
+		attempts int
+	}{
+		{&apiError{class: ErrRateLimited, err: errors.New("server returned HTTP status: 429 Too Many Requests")}, 3},
+		{errors.New("server returned HTTP status: 503 Service Unavailable, body: \"\""), 3},
+		{errors.New("server returned HTTP status: 400 Bad Request, body: \"\""), 1},
+		{fmt.Errorf("write: %w", context.Canceled), 1},
+	} {
+		var stats apiStats
+		attempts := 0
+		err := retry(context.Background(), policy, &stats, "test", func() error {
+			attempts++
+			return tc.err
+		})
+		if !errors.Is(err, tc.err) || attempts != tc.attempts || stats.retries.Load() != int64(tc.attempts-1) {
+			t.Errorf("%s: expected %d attempts, got %d (%d retries counted), err %v", tc.err, tc.attempts, attempts, stats.retries.Load(), err)
+		}
+		if retried := strings.Contains(err.Error(), fmt.Sprintf("after %d attempts", tc.attempts)); retried != (tc.attempts > 1) {
+			t.Errorf("%s: expected the error to tell the attempts only when retried, got %v", tc.err, err)
+		}
+	}
+}
+
+func TestProviderRetrySettings(t *testing.T) {
+	for _, name := range []string{"MIMIR_CLIENT_RETRIES", "MIMIRTOOL_RETRY_MAX_ATTEMPTS", "MIMIR_RETRY_MAX_ATTEMPTS"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	providerSchema := New("test")().Schema
+	d := schema.TestResourceDataRaw(t, providerSchema, map[string]interface{}{})
+	if attempts, backoff, maxBackoff := providerRetrySettings(d); attempts != 3 || backoff != "1s" || maxBackoff != "30s" {
+		t.Fatalf("expected 3 attempts after 1s up to 30s by default, got %d attempts after %s up to %s", attempts, backoff, maxBackoff)
+	}
+	d = schema.TestResourceDataRaw(t, providerSchema, map[string]interface{}{"retries": 4, "retry_backoff_seconds": 0.5})
+	if attempts, backoff, _ := providerRetrySettings(d); attempts != 5 || backoff != "500ms" {
+		t.Fatalf("expected the deprecated settings to set 5 attempts after 500ms, got %d attempts after %s", attempts, backoff)
+	}
+	d = schema.TestResourceDataRaw(t, providerSchema, map[string]interface{}{"retry_min_wait": "2s", "retry_max_wait": "1m"})
+	if _, backoff, maxBackoff := providerRetrySettings(d); backoff != "2s" || maxBackoff != "1m" {
+		t.Fatalf("expected the waits to set the backoff from 2s up to 1m, got %s up to %s", backoff, maxBackoff)
+	}
+	t.Setenv("MIMIR_CLIENT_RETRIES", "5")
+	d = schema.TestResourceDataRaw(t, providerSchema, map[string]interface{}{})
+	if attempts, _, _ := providerRetrySettings(d); attempts != 6 {
+		t.Fatalf("expected MIMIR_CLIENT_RETRIES to set 6 attempts, got %d", attempts)
+	}
+	t.Setenv("MIMIR_RETRY_MAX_ATTEMPTS", "2")
+	d = schema.TestResourceDataRaw(t, providerSchema, map[string]interface{}{})
+	if attempts, _, _ := providerRetrySettings(d); attempts != 2 {
+		t.Fatalf("expected MIMIR_RETRY_MAX_ATTEMPTS to take precedence, got %d", attempts)
+	}
+}
+
+func TestRetryAttemptTimeout(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 3, backoff: time.Millisecond, maxBackoff: time.Millisecond}
+	attempts := 0
+	err := retry(context.Background(), policy, &apiStats{}, "test", func() error {
+		attempts++
+		return fmt.Errorf("attempt: %w", context.DeadlineExceeded)
+	})
+	if attempts != 3 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the timed out attempts to be retried, got %d attempts, err %v", attempts, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	attempts = 0
+	retry(ctx, policy, &apiStats{}, "test", func() error {
+		attempts++
+		return ctx.Err()
+	})
+	if attempts != 1 {
+		t.Fatalf("expected the call out of time not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestRetryableStatuses(t *testing.T) {
+	rateLimited := &apiError{class: ErrRateLimited, err: errors.New("server returned HTTP status: 429 Too Many Requests")}
+	cdnError := errors.New("server returned HTTP status: 522 <none>, body: \"\"")
+	conflict := errors.New("server returned HTTP status: 409 Conflict, body: \"\"")
+	for _, tc := range []struct {
+		name     string
+		statuses *retryableStatuses
+		err      error
+		want     bool
+	}{
+		{name: "default rate limited", err: rateLimited, want: true},
+		{name: "default server error", err: cdnError, want: true},
+		{name: "default client error", err: conflict, want: false},
+		{name: "extended", statuses: newRetryableStatuses([]int{409}, retryableStatusesExtend), err: conflict, want: true},
+		{name: "extended keeps defaults", statuses: newRetryableStatuses([]int{409}, retryableStatusesExtend), err: rateLimited, want: true},
+		{name: "replaced", statuses: newRetryableStatuses([]int{522}, retryableStatusesReplace), err: cdnError, want: true},
+		{name: "replaced drops defaults", statuses: newRetryableStatuses([]int{522}, retryableStatusesReplace), err: rateLimited, want: false},
+		{name: "replaced by none", statuses: newRetryableStatuses(nil, retryableStatusesReplace), err: cdnError, want: false},
+	} {
+		if got := retryable(context.Background(), tc.err, tc.statuses); got != tc.want {
+			t.Errorf("%s: expected retryable=%t, got %t", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestResourceRetryPolicy(t *testing.T) {
+	provider := retryPolicy{maxAttempts: 3, backoff: time.Second, maxBackoff: 30 * time.Second, source: "provider"}
+
+	d := schema.TestResourceDataRaw(t, resourceAlertManager().Schema, map[string]interface{}{})
+	if policy := resourceRetryPolicy(d, provider); policy != provider {
+		t.Fatalf("expected the provider policy, got %+v", policy)
+	}
+
+	d = schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{
+		"retry": []interface{}{map[string]interface{}{"max_attempts": 10, "backoff": "1m"}},
+	})
+	expected := retryPolicy{maxAttempts: 10, backoff: time.Minute, maxBackoff: time.Minute, source: "resource"}
+	if policy := resourceRetryPolicy(d, provider); policy != expected {
+		t.Fatalf("expected %+v, got %+v", expected, policy)
+	}
+}
+
+func TestAPIClientRetryPolicy(t *testing.T) {
+	fake := newFakeMimirClient()
+	fake.err = &apiError{class: ErrRateLimited, err: errors.New("server returned HTTP status: 429 Too Many Requests")}
+	var stats apiStats
+	cli := newAPIClient(fake, apiClientOptions{
+		retry: retryPolicy{maxAttempts: 2, backoff: time.Millisecond, maxBackoff: time.Millisecond},
+		stats: &stats,
+	})
+
+	cli.ListRules(context.Background(), "demo")
+	if stats.lists.Load() != 2 {
+		t.Fatalf("expected the provider policy to apply, got %d calls", stats.lists.Load())
+	}
+	ctx := withRetryPolicy(context.Background(), retryPolicy{maxAttempts: 5, backoff: time.Millisecond, maxBackoff: time.Millisecond})
+	cli.ListRules(ctx, "demo")
+	if stats.lists.Load() != 7 {
+		t.Fatalf("expected the policy of the operation to apply, got %d calls", stats.lists.Load()-2)
+	}
+}
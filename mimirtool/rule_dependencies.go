@@ -0,0 +1,103 @@
+package mimirtool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// recordedMetrics returns the names of the metrics produced by the recording
+// rules of the given groups.
+func recordedMetrics(groups []rwrulefmt.RuleGroup) map[string]struct{} {
+	metrics := make(map[string]struct{})
+	for _, group := range groups {
+		for _, rule := range group.Rules {
+			if rule.Record.Value != "" {
+				metrics[rule.Record.Value] = struct{}{}
+			}
+		}
+	}
+	return metrics
+}
+
+// referencedMetrics returns the sorted, deduplicated metric names selected by
+// a PromQL expression.
+func referencedMetrics(expr string) ([]string, error) {
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{})
+	parser.Inspect(node, func(n parser.Node, _ []parser.Node) error {
+		vs, ok := n.(*parser.VectorSelector)
+		if !ok {
+			return nil
+		}
+		if vs.Name != "" {
+			seen[vs.Name] = struct{}{}
+			return nil
+		}
+		for _, m := range vs.LabelMatchers {
+			if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+				seen[m.Value] = struct{}{}
+			}
+		}
+		return nil
+	})
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// looksRecorded tells whether a metric name follows the level:metric:operations
+// naming convention of recording rules outputs.
+func looksRecorded(name string) bool {
+	return strings.Contains(name, ":")
+}
+
+// checkRuleDependencies warns about alerts of ruleNamespace referencing metrics
+// which look like recording rules outputs but aren't recorded by any group in
+// tenantGroups, which holds every namespace of the tenant keyed by name.
+func checkRuleDependencies(ruleNamespace rules.RuleNamespace, tenantGroups map[string][]rwrulefmt.RuleGroup) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	recorded := recordedMetrics(ruleNamespace.Groups)
+	for _, groups := range tenantGroups {
+		for name := range recordedMetrics(groups) {
+			recorded[name] = struct{}{}
+		}
+	}
+
+	for _, group := range ruleNamespace.Groups {
+		for _, rule := range group.Rules {
+			if rule.Alert.Value == "" {
+				continue
+			}
+			// Invalid expressions are reported by the validation, not here.
+			metrics, err := referencedMetrics(rule.Expr.Value)
+			if err != nil {
+				continue
+			}
+			for _, metric := range metrics {
+				if _, ok := recorded[metric]; ok || !looksRecorded(metric) {
+					continue
+				}
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Warning,
+					Summary:  "Alert references an undefined recording rule.",
+					Detail:   fmt.Sprintf("Alert %q of group %q uses %q which looks like a recording rule output, but no recording rule of the tenant defines it.", rule.Alert.Value, group.Name, metric),
+				})
+			}
+		}
+	}
+	return diags
+}
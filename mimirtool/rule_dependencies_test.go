@@ -0,0 +1,46 @@
+package mimirtool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"gopkg.in/yaml.v3"
+)
+
+func mustRuleNamespace(t *testing.T, configYAML string) rules.RuleNamespace {
+	t.Helper()
+	var ruleNamespace rules.RuleNamespace
+	if err := yaml.Unmarshal([]byte(configYAML), &ruleNamespace); err != nil {
+		t.Fatal(err)
+	}
+	return ruleNamespace
+}
+
+func TestCheckRuleDependencies(t *testing.T) {
+	alerts := mustRuleNamespace(t, `groups:
+  - name: alerts
+    rules:
+      - alert: HighLatency
+        expr: job:request_latency_seconds:p99 > 1 and on (job) job:requests:rate5m > 10
+      - alert: Down
+        expr: up == 0
+`)
+	recording := mustRuleNamespace(t, `groups:
+  - name: recording
+    rules:
+      - record: job:request_latency_seconds:p99
+        expr: histogram_quantile(0.99, sum by (le, job) (rate(request_latency_seconds_bucket[5m])))
+`)
+
+	diags := checkRuleDependencies(alerts, map[string][]rwrulefmt.RuleGroup{
+		"recording": recording.Groups,
+	})
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly one warning, got %d: %v", len(diags), diags)
+	}
+	if !strings.Contains(diags[0].Detail, "job:requests:rate5m") {
+		t.Fatalf("warning should name the dangling metric, got: %s", diags[0].Detail)
+	}
+}
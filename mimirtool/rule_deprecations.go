@@ -0,0 +1,102 @@
+package mimirtool
+
+import (
+	"fmt"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// ruleDeprecation is a rule construct deprecated by Mimir or Prometheus, along
+// with how to migrate away from it.
+type ruleDeprecation struct {
+	// construct names the deprecated construct in warnings.
+	construct string
+	// since is the release which deprecated the construct.
+	since string
+	// migration tells what to use instead.
+	migration string
+	// group matches the groups using the construct, when set.
+	group func(group rwrulefmt.RuleGroup) bool
+	// function is the name of the deprecated PromQL function, when set.
+	function string
+}
+
+// ruleDeprecations lists the deprecations known as of the vendored Mimir and
+// Prometheus versions, keep it in sync when upgrading them.
+var ruleDeprecations = []ruleDeprecation{
+	{
+		construct: "group field `evaluation_delay`",
+		since:     "Grafana Mimir 2.13",
+		migration: "use `query_offset`, which has the same meaning",
+		group:     func(group rwrulefmt.RuleGroup) bool { return group.EvaluationDelay != nil },
+	},
+	{
+		construct: "PromQL function `holt_winters`",
+		since:     "Prometheus 3.0",
+		migration: "use `double_exponential_smoothing` once running Prometheus 3.0 based versions, where it is experimental",
+		function:  "holt_winters",
+	},
+}
+
+// checkDeprecations warns about the groups and rules of ruleNamespace using
+// deprecated constructs. Invalid expressions are reported by the validation,
+// not here.
+func checkDeprecations(ruleNamespace rules.RuleNamespace) diag.Diagnostics {
+	var diags diag.Diagnostics
+	warn := func(deprecation ruleDeprecation, object string) {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Rule uses a deprecated construct.",
+			Detail:   fmt.Sprintf("%s uses the %s, deprecated since %s: %s.", object, deprecation.construct, deprecation.since, deprecation.migration),
+		})
+	}
+
+	for _, group := range ruleNamespace.Groups {
+		for _, deprecation := range ruleDeprecations {
+			if deprecation.group != nil && deprecation.group(group) {
+				warn(deprecation, fmt.Sprintf("Group %q", group.Name))
+			}
+		}
+		for _, rule := range group.Rules {
+			functions, err := calledFunctions(rule.Expr.Value)
+			if err != nil {
+				continue
+			}
+			for _, deprecation := range ruleDeprecations {
+				if _, ok := functions[deprecation.function]; ok && deprecation.function != "" {
+					warn(deprecation, fmt.Sprintf("Rule %q of group %q", ruleName(rule), group.Name))
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// calledFunctions returns the names of the functions called by a PromQL
+// expression.
+func calledFunctions(expr string) (map[string]struct{}, error) {
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	functions := make(map[string]struct{})
+	parser.Inspect(node, func(n parser.Node, _ []parser.Node) error {
+		if call, ok := n.(*parser.Call); ok && call.Func != nil {
+			functions[call.Func.Name] = struct{}{}
+		}
+		return nil
+	})
+	return functions, nil
+}
+
+// ruleName returns the name of the alert or the recorded metric of rule.
+func ruleName(rule rulefmt.RuleNode) string {
+	if rule.Alert.Value != "" {
+		return rule.Alert.Value
+	}
+	return rule.Record.Value
+}
@@ -0,0 +1,48 @@
+package mimirtool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckDeprecatedGroupFields(t *testing.T) {
+	ruleNamespace := mustRuleNamespace(t, `groups:
+  - name: delayed
+    evaluation_delay: 1m
+    rules:
+      - record: job:up:sum
+        expr: sum by (job) (up)
+  - name: offset
+    query_offset: 1m
+    rules:
+      - record: job:up:max
+        expr: max by (job) (up)
+`)
+
+	diags := checkDeprecations(ruleNamespace)
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 warning, got %v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, `Group "delayed"`) || !strings.Contains(diags[0].Detail, "`query_offset`") {
+		t.Errorf("unexpected warning: %s", diags[0].Detail)
+	}
+}
+
+func TestCheckDeprecatedFunctions(t *testing.T) {
+	ruleNamespace := mustRuleNamespace(t, `groups:
+  - name: forecast
+    rules:
+      - record: job:load:smoothed
+        expr: holt_winters(node_load1[10m], 0.5, 0.5)
+      - alert: HighLoad
+        expr: rate(node_load1[5m]) > 1
+`)
+
+	diags := checkDeprecations(ruleNamespace)
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 warning, got %v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, `Rule "job:load:smoothed"`) || !strings.Contains(diags[0].Detail, "`double_exponential_smoothing`") {
+		t.Errorf("unexpected warning: %s", diags[0].Detail)
+	}
+}
@@ -0,0 +1,72 @@
+package mimirtool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"golang.org/x/exp/slices"
+)
+
+// checkRuleGroupsEvaluation reports the groups of the namespace of d having
+// rules whose last evaluation failed, as told by a single query of the
+// Prometheus rules API.
+func checkRuleGroupsEvaluation(ctx context.Context, d *schema.ResourceData, meta any) diag.Diagnostics {
+	c := meta.(*client)
+	namespace := d.Get("namespace").(string)
+	ruleNamespace, err := getRuleNamespaceFromYAML(ctx, c.namespaces, d.Get("config_yaml").(string), validatorEnabled(d, validatorPromQL))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	groups := make([]string, 0, len(ruleNamespace.Groups))
+	for _, group := range ruleNamespace.Groups {
+		groups = append(groups, group.Name)
+	}
+
+	path := c.config.prometheusHTTPPrefix + "/api/v1/rules?file=" + url.QueryEscape(namespace)
+	body, err := c.apiGet(ctx, "mimirtool_ruler_namespace", path, nil)
+	if err != nil {
+		return diag.Errorf("unable to check the evaluation of the rule groups of namespace %q: %s", namespace, err)
+	}
+	var res struct {
+		Data struct {
+			Groups []struct {
+				Name  string `json:"name"`
+				File  string `json:"file"`
+				Rules []struct {
+					Name      string `json:"name"`
+					Health    string `json:"health"`
+					LastError string `json:"lastError"`
+				} `json:"rules"`
+			} `json:"groups"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return diag.Errorf("unable to check the evaluation of the rule groups of namespace %q: %s", namespace, err)
+	}
+
+	var diags diag.Diagnostics
+	for _, group := range res.Data.Groups {
+		if group.File != namespace || !slices.Contains(groups, group.Name) {
+			continue
+		}
+		var failures []string
+		for _, rule := range group.Rules {
+			if rule.Health == "err" {
+				failures = append(failures, fmt.Sprintf("rule %q: %s", rule.Name, rule.LastError))
+			}
+		}
+		if len(failures) > 0 {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("Rule group %q of namespace %q fails to evaluate.", group.Name, namespace),
+				Detail:   strings.Join(failures, "\n"),
+			})
+		}
+	}
+	return diags
+}
@@ -0,0 +1,55 @@
+package mimirtool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestCheckRuleGroupsEvaluation(t *testing.T) {
+	rules := `{"status":"success","data":{"groups":[
+		{"name":"failing","file":"demo","rules":[
+			{"name":"up:sum","health":"ok","lastError":""},
+			{"name":"errors:rate5m","health":"err","lastError":"many-to-many matching not allowed"}
+		]},
+		{"name":"pending","file":"demo","rules":[{"name":"latency:p99","health":"unknown","lastError":""}]},
+		{"name":"failing","file":"other","rules":[{"name":"other","health":"err","lastError":"other namespace"}]}
+	]}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prometheus/api/v1/rules" || r.URL.Query().Get("file") != "demo" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(rules))
+	}))
+	defer server.Close()
+
+	meta := &client{cli: newFakeMimirClient()}
+	meta.config.Address = server.URL
+	meta.config.prometheusHTTPPrefix = "/prometheus"
+	d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{
+		"namespace":        "demo",
+		"config_yaml":      "groups:\n  - name: failing\n    rules:\n      - record: up:sum\n        expr: sum(up)\n      - record: errors:rate5m\n        expr: rate(errors[5m])\n  - name: pending\n    rules:\n      - record: latency:p99\n        expr: histogram_quantile(0.99, latency)\n",
+		"check_evaluation": true,
+	})
+
+	diags := rulerNamespaceCreate(context.Background(), d, meta)
+	if len(diags) != 1 || !diags.HasError() {
+		t.Fatalf("expected one group to fail, got %v", diags)
+	}
+	if !strings.Contains(diags[0].Summary, `"failing"`) || diags[0].Detail != `rule "errors:rate5m": many-to-many matching not allowed` {
+		t.Fatalf("expected the failing rule and its last error, got %s: %s", diags[0].Summary, diags[0].Detail)
+	}
+	if d.Id() == "" {
+		t.Fatal("expected the groups to stay pushed")
+	}
+
+	rules = strings.ReplaceAll(rules, `"health":"err"`, `"health":"ok"`)
+	if diags := checkRuleGroupsEvaluation(context.Background(), d, meta); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+}
@@ -0,0 +1,211 @@
+package mimirtool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/prometheus/prometheus/model/rulefmt"
+)
+
+// ruleGroupSplitLimit returns the number of rules past which auto_split_groups
+// splits a group: max_rules_per_group, or the ruler_max_rules_per_rule_group
+// limit of the tenant. 0 means no limit.
+func ruleGroupSplitLimit(ctx context.Context, c *client, d attributeGetter) (int, error) {
+	if limit := d.Get("max_rules_per_group").(int); limit > 0 {
+		return limit, nil
+	}
+	// The limit is read once per tenant by the provider instance, rather
+	// than by every namespace of the operation.
+	tenant := c.tenant(ctx)
+	c.tenantMu.Lock()
+	limit, ok := c.splitLimits[tenant]
+	c.tenantMu.Unlock()
+	if ok {
+		return limit, nil
+	}
+	body, err := c.apiGet(ctx, "mimirtool_ruler_namespace", "/api/v1/user_limits", nil)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read the ruler_max_rules_per_rule_group limit of the tenant for auto_split_groups, set max_rules_per_group: %w", err)
+	}
+	var limits struct {
+		MaxRulesPerRuleGroup int `json:"ruler_max_rules_per_rule_group"`
+	}
+	if err := json.Unmarshal(body, &limits); err != nil {
+		return 0, fmt.Errorf("unexpected tenant limits for auto_split_groups, set max_rules_per_group: %w", err)
+	}
+	c.tenantMu.Lock()
+	defer c.tenantMu.Unlock()
+	if c.splitLimits == nil {
+		c.splitLimits = map[string]int{}
+	}
+	c.splitLimits[tenant] = limits.MaxRulesPerRuleGroup
+	return limits.MaxRulesPerRuleGroup, nil
+}
+
+// splitRuleGroups returns a copy of ruleNamespace whose groups of more than limit
+// rules are split into groups of at most limit rules, named after the group
+// with a "-1", "-2", ... suffix, along with the original name of the groups
+// split by their new name. The rules depending on the output of a recording
+// rule of the group are kept along with it when they fit, each group keeps
+// the order of its rules. Groups are kept as is with a limit of 0.
+func splitRuleGroups(ruleNamespace rules.RuleNamespace, limit int) (rules.RuleNamespace, map[string]string, diag.Diagnostics) {
+	res := copyRuleNamespace(ruleNamespace)
+	origins := map[string]string{}
+	if limit <= 0 {
+		return res, origins, nil
+	}
+	names := map[string]bool{}
+	for _, group := range res.Groups {
+		names[group.Name] = true
+	}
+
+	var diags diag.Diagnostics
+	groups := make([]rwrulefmt.RuleGroup, 0, len(res.Groups))
+	for _, group := range res.Groups {
+		if len(group.Rules) <= limit {
+			groups = append(groups, group)
+			continue
+		}
+		chunks, kept := splitRules(group.Rules, limit)
+		if !kept {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Dependent rules split across groups.",
+				Detail:   fmt.Sprintf("Group %q has a chain of more than %d rules depending on each other's recordings, they are split across several groups and evaluated independently.", group.Name, limit),
+			})
+		}
+		for i, chunk := range chunks {
+			split := group
+			split.Name = fmt.Sprintf("%s-%d", group.Name, i+1)
+			split.Rules = chunk
+			if names[split.Name] {
+				return res, nil, append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Unable to split rule group.",
+					Detail:   fmt.Sprintf("Group %q is split into %q, which is the name of another group of the namespace. Rename one of them.", group.Name, split.Name),
+				})
+			}
+			names[split.Name] = true
+			origins[split.Name] = group.Name
+			groups = append(groups, split)
+		}
+	}
+	res.Groups = groups
+	return res, origins, diags
+}
+
+// splitRules splits rules into chunks of at most limit rules, keeping the rules
+// depending on each other's recordings in the same chunk, and tells whether
+// they all could.
+func splitRules(ruleNodes []rulefmt.RuleNode, limit int) ([][]rulefmt.RuleNode, bool) {
+	// parent is a union-find of the rules depending on each other.
+	parent := make([]int, len(ruleNodes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	recorders := map[string][]int{}
+	for i, rule := range ruleNodes {
+		// Expressions which cannot be parsed depend on nothing, Mimir
+		// rejects them anyway.
+		metrics, _ := referencedMetrics(rule.Expr.Value)
+		for _, metric := range metrics {
+			for _, recorder := range recorders[metric] {
+				parent[find(i)] = find(recorder)
+			}
+		}
+		if rule.Record.Value != "" {
+			recorders[rule.Record.Value] = append(recorders[rule.Record.Value], i)
+		}
+	}
+
+	// The components are packed in the order of their first rule.
+	var order []int
+	components := map[int][]int{}
+	for i := range ruleNodes {
+		root := find(i)
+		if _, ok := components[root]; !ok {
+			order = append(order, root)
+		}
+		components[root] = append(components[root], i)
+	}
+	kept := true
+	var chunks [][]int
+	var current []int
+	for _, root := range order {
+		component := components[root]
+		if len(current)+len(component) > limit && len(current) > 0 {
+			chunks = append(chunks, current)
+			current = nil
+		}
+		for len(component) > limit {
+			kept = false
+			chunks = append(chunks, component[:limit])
+			component = component[limit:]
+		}
+		current = append(current, component...)
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+
+	res := make([][]rulefmt.RuleNode, 0, len(chunks))
+	for _, chunk := range chunks {
+		sort.Ints(chunk)
+		nodes := make([]rulefmt.RuleNode, 0, len(chunk))
+		for _, i := range chunk {
+			nodes = append(nodes, ruleNodes[i])
+		}
+		res = append(res, nodes)
+	}
+	return res, kept
+}
+
+// splitGroupFields returns fields along with the fields of the groups split
+// under their new name, as given by origins.
+func splitGroupFields(fields map[string]groupFields, origins map[string]string) map[string]groupFields {
+	if len(fields) == 0 || len(origins) == 0 {
+		return fields
+	}
+	res := make(map[string]groupFields, len(fields)+len(origins))
+	for name, f := range fields {
+		res[name] = f
+	}
+	for name, origin := range origins {
+		if f, ok := fields[origin]; ok {
+			res[name] = f
+		}
+	}
+	return res
+}
+
+// splitNamespaceYAML returns the canonical configYAML with its groups split as
+// auto_split_groups uploads them.
+func splitNamespaceYAML(ctx context.Context, c *client, d *schema.ResourceData, configYAML string) (string, error) {
+	limit, err := ruleGroupSplitLimit(ctx, c, d)
+	if err != nil {
+		return "", err
+	}
+	p := c.namespaces.parse(configYAML)
+	ruleNamespace, err := p.decodedNamespace()
+	if err != nil {
+		return "", err
+	}
+	split, origins, diags := splitRuleGroups(ruleNamespace, limit)
+	if diags.HasError() {
+		return "", fmt.Errorf("%s", diags[len(diags)-1].Detail)
+	}
+	return withUnknownGroupFields(normalizeRuleNamespace(split), splitGroupFields(p.groupFields(), origins)), nil
+}
@@ -0,0 +1,136 @@
+package mimirtool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func groupRuleNames(t *testing.T, namespace string, limit int) map[string][]string {
+	t.Helper()
+	split, _, diags := splitRuleGroups(mustRuleNamespace(t, namespace), limit)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	res := map[string][]string{}
+	for _, group := range split.Groups {
+		for _, rule := range group.Rules {
+			res[group.Name] = append(res[group.Name], rule.Alert.Value+rule.Record.Value)
+		}
+	}
+	return res
+}
+
+func TestSplitRuleGroups(t *testing.T) {
+	names := groupRuleNames(t, `groups:
+  - name: small
+    rules:
+      - alert: A
+        expr: up == 0
+  - name: big
+    rules:
+      - alert: B
+        expr: up == 0
+      - alert: C
+        expr: up == 0
+      - alert: D
+        expr: up == 0
+`, 2)
+	if len(names) != 3 || strings.Join(names["small"], ",") != "A" || strings.Join(names["big-1"], ",") != "B,C" || strings.Join(names["big-2"], ",") != "D" {
+		t.Errorf("unexpected groups: %v", names)
+	}
+	if names := groupRuleNames(t, `groups:
+  - name: big
+    rules:
+      - alert: B
+        expr: up == 0
+      - alert: C
+        expr: up == 0
+`, 0); len(names) != 1 || len(names["big"]) != 2 {
+		t.Errorf("unexpected groups without limit: %v", names)
+	}
+}
+
+func TestSplitRuleGroupsDependencies(t *testing.T) {
+	// up:sum and its alert are kept together, the other rules fill the groups
+	// in order.
+	names := groupRuleNames(t, `groups:
+  - name: big
+    rules:
+      - alert: A
+        expr: up == 0
+      - record: up:sum
+        expr: sum(up)
+      - alert: B
+        expr: up == 0
+      - alert: NoTargets
+        expr: up:sum == 0
+`, 2)
+	if strings.Join(names["big-1"], ",") != "A" || strings.Join(names["big-2"], ",") != "up:sum,NoTargets" || strings.Join(names["big-3"], ",") != "B" {
+		t.Errorf("unexpected groups: %v", names)
+	}
+}
+
+func TestSplitRuleGroupsCollision(t *testing.T) {
+	_, _, diags := splitRuleGroups(mustRuleNamespace(t, `groups:
+  - name: big
+    rules:
+      - alert: A
+        expr: up == 0
+      - alert: B
+        expr: up == 0
+  - name: big-2
+    rules:
+      - alert: C
+        expr: up == 0
+`), 1)
+	if !diags.HasError() || !strings.Contains(diags[len(diags)-1].Detail, `split into "big-2"`) {
+		t.Errorf("expected a collision error, got %v", diags)
+	}
+}
+
+func TestSplitGroupFields(t *testing.T) {
+	fields := map[string]groupFields{"big": make(groupFields, 1)}
+	res := splitGroupFields(fields, map[string]string{"big-1": "big", "big-2": "big", "other-1": "other"})
+	if len(res) != 3 || res["big-1"] == nil || res["big-2"] == nil {
+		t.Errorf("unexpected fields: %v", res)
+	}
+}
+
+func TestRuleGroupSplitLimitCache(t *testing.T) {
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := r.Header.Get("X-Scope-OrgID")
+		calls[tenant]++
+		if tenant == "team-b" {
+			w.Write([]byte(`{"ruler_max_rules_per_rule_group": 5}`))
+			return
+		}
+		w.Write([]byte(`{"ruler_max_rules_per_rule_group": 20}`))
+	}))
+	defer server.Close()
+
+	c := &client{cli: newFakeMimirClient()}
+	c.config.Address = server.URL
+	c.config.ID = "team-a"
+	d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{})
+	for _, tc := range []struct {
+		tenant string
+		want   int
+	}{{"", 20}, {"team-b", 5}, {"", 20}, {"team-b", 5}} {
+		limit, err := ruleGroupSplitLimit(withTenant(context.Background(), tc.tenant), c, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if limit != tc.want {
+			t.Errorf("tenant %q: expected a limit of %d, got %d", tc.tenant, tc.want, limit)
+		}
+	}
+	if calls["team-a"] != 1 || calls["team-b"] != 1 {
+		t.Errorf("expected the limits of each tenant to be read once, got %v", calls)
+	}
+}
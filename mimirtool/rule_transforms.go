@@ -0,0 +1,182 @@
+package mimirtool
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/prometheus/prometheus/model/rulefmt"
+)
+
+// Types of the rule transforms.
+const (
+	transformSetLabels         = "set_labels"
+	transformSetAnnotations    = "set_annotations"
+	transformReplaceLabel      = "replace_label"
+	transformReplaceAnnotation = "replace_annotation"
+)
+
+// ruleTransform rewrites a rule, whose labels and annotations are its own.
+type ruleTransform func(rule *rulefmt.RuleNode)
+
+func ruleTransformSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Transformations applied in order to every rule before the upload, e.g. to add the labels of an environment or to rewrite runbook URLs. `config_yaml` keeps the source, `content_sha256` is the one of the transformed content. " +
+			"`" + transformSetLabels + "` sets the labels of `params` on every rule, `" + transformSetAnnotations + "` sets the annotations of `params` on every alert. " +
+			"`" + transformReplaceLabel + "` and `" + transformReplaceAnnotation + "` replace the matches of the `regex` parameter in the value of the label or annotation `name` by `replacement`, which may reference the groups of the regex as `$1`.",
+		Type:     schema.TypeList,
+		Optional: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"type": {
+					Description:  "The transformation: `" + strings.Join([]string{transformSetLabels, transformSetAnnotations, transformReplaceLabel, transformReplaceAnnotation}, "`, `") + "`.",
+					Type:         schema.TypeString,
+					Required:     true,
+					ValidateFunc: validation.StringInSlice([]string{transformSetLabels, transformSetAnnotations, transformReplaceLabel, transformReplaceAnnotation}, false),
+				},
+				"params": {
+					Description: "The parameters of the transformation.",
+					Type:        schema.TypeMap,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+					Optional:    true,
+				},
+			},
+		},
+	}
+}
+
+// expandRuleTransforms returns the transforms of the transform blocks of src.
+func expandRuleTransforms(src []interface{}) ([]ruleTransform, error) {
+	transforms := make([]ruleTransform, 0, len(src))
+	for i, block := range src {
+		block, _ := block.(map[string]interface{})
+		if block == nil {
+			continue
+		}
+		kind, _ := block["type"].(string)
+		params := stringValueMap(block["params"].(map[string]interface{}))
+		transform, err := newRuleTransform(kind, params)
+		if err != nil {
+			return nil, fmt.Errorf("transform %d (%s): %w", i, kind, err)
+		}
+		transforms = append(transforms, transform)
+	}
+	return transforms, nil
+}
+
+func newRuleTransform(kind string, params map[string]string) (ruleTransform, error) {
+	switch kind {
+	case transformSetLabels:
+		return func(rule *rulefmt.RuleNode) {
+			rule.Labels = withValues(rule.Labels, params)
+		}, nil
+	case transformSetAnnotations:
+		return func(rule *rulefmt.RuleNode) {
+			// Recording rules have no annotations.
+			if rule.Alert.Value != "" {
+				rule.Annotations = withValues(rule.Annotations, params)
+			}
+		}, nil
+	case transformReplaceLabel, transformReplaceAnnotation:
+		for param := range params {
+			if param != "name" && param != "regex" && param != "replacement" {
+				return nil, fmt.Errorf("unknown parameter %q, expected name, regex and replacement", param)
+			}
+		}
+		if params["name"] == "" || params["regex"] == "" {
+			return nil, fmt.Errorf("the name and regex parameters are required")
+		}
+		re, err := regexp.Compile(params["regex"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+		name, replacement := params["name"], params["replacement"]
+		replace := func(values map[string]string) {
+			if value, ok := values[name]; ok {
+				values[name] = re.ReplaceAllString(value, replacement)
+			}
+		}
+		if kind == transformReplaceLabel {
+			return func(rule *rulefmt.RuleNode) { replace(rule.Labels) }, nil
+		}
+		return func(rule *rulefmt.RuleNode) { replace(rule.Annotations) }, nil
+	}
+	return nil, fmt.Errorf("unknown transform type")
+}
+
+// withValues returns values along with the ones of set, which prevail.
+func withValues(values, set map[string]string) map[string]string {
+	if len(set) == 0 {
+		return values
+	}
+	if values == nil {
+		values = make(map[string]string, len(set))
+	}
+	for k, v := range set {
+		values[k] = v
+	}
+	return values
+}
+
+// transformNamespace returns a copy of ruleNamespace whose rules went through
+// transforms in order, ruleNamespace is left as is.
+func transformNamespace(ruleNamespace rules.RuleNamespace, transforms []ruleTransform) rules.RuleNamespace {
+	if len(transforms) == 0 {
+		return ruleNamespace
+	}
+	res := copyRuleNamespace(ruleNamespace)
+	for _, group := range res.Groups {
+		for j := range group.Rules {
+			for _, transform := range transforms {
+				transform(&group.Rules[j])
+			}
+		}
+	}
+	return res
+}
+
+// copyRuleNamespace returns a copy of ruleNamespace whose rules, along with
+// their labels and annotations, can be modified.
+func copyRuleNamespace(ruleNamespace rules.RuleNamespace) rules.RuleNamespace {
+	res := ruleNamespace
+	res.Groups = make([]rwrulefmt.RuleGroup, len(ruleNamespace.Groups))
+	for i, group := range ruleNamespace.Groups {
+		group.Rules = make([]rulefmt.RuleNode, len(group.Rules))
+		for j, rule := range ruleNamespace.Groups[i].Rules {
+			rule.Labels = copyStringMap(rule.Labels)
+			rule.Annotations = copyStringMap(rule.Annotations)
+			group.Rules[j] = rule
+		}
+		res.Groups[i] = group
+	}
+	return res
+}
+
+func copyStringMap(src map[string]string) map[string]string {
+	if src == nil {
+		return nil
+	}
+	dst := make(map[string]string, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+// transformedNamespaceYAML returns the canonical form of configYAML once
+// transformed, as read back from Mimir.
+func transformedNamespaceYAML(configYAML string, transforms []ruleTransform) (string, error) {
+	p := parseNamespace(configYAML)
+	if len(transforms) == 0 {
+		return p.normalizedYAML(), nil
+	}
+	ruleNamespace, err := p.decodedNamespace()
+	if err != nil {
+		return "", err
+	}
+	return withUnknownGroupFields(normalizeRuleNamespace(transformNamespace(ruleNamespace, transforms)), p.groupFields()), nil
+}
@@ -0,0 +1,90 @@
+package mimirtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestTransformNamespace(t *testing.T) {
+	source := mustRuleNamespace(t, `groups:
+  - name: group
+    rules:
+      - record: job:up:sum
+        expr: sum by (job) (up)
+        labels:
+          env: dev
+      - alert: Down
+        expr: up == 0
+        annotations:
+          runbook_url: http://wiki.old/down
+`)
+	transforms, err := expandRuleTransforms([]interface{}{
+		map[string]interface{}{"type": transformSetLabels, "params": map[string]interface{}{"env": "prod", "team": "sre"}},
+		map[string]interface{}{"type": transformReplaceLabel, "params": map[string]interface{}{"name": "env", "regex": "^prod$", "replacement": "production"}},
+		map[string]interface{}{"type": transformSetAnnotations, "params": map[string]interface{}{"owner": "sre"}},
+		map[string]interface{}{"type": transformReplaceAnnotation, "params": map[string]interface{}{"name": "runbook_url", "regex": `^http://wiki\.old/(.*)$`, "replacement": "https://wiki.new/$1"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transformed := transformNamespace(source, transforms)
+	record, alert := transformed.Groups[0].Rules[0], transformed.Groups[0].Rules[1]
+	if record.Labels["env"] != "production" || record.Labels["team"] != "sre" || record.Annotations != nil {
+		t.Errorf("unexpected recording rule: labels %v, annotations %v", record.Labels, record.Annotations)
+	}
+	if alert.Annotations["owner"] != "sre" || alert.Annotations["runbook_url"] != "https://wiki.new/down" {
+		t.Errorf("unexpected alert annotations: %v", alert.Annotations)
+	}
+	if source.Groups[0].Rules[0].Labels["env"] != "dev" || source.Groups[0].Rules[1].Annotations["owner"] != "" {
+		t.Error("the source namespace was modified")
+	}
+}
+
+func TestExpandRuleTransformsErrors(t *testing.T) {
+	for _, tc := range []struct {
+		params   map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"regex": "x"}, "the name and regex parameters are required"},
+		{map[string]interface{}{"name": "env", "regex": "("}, "invalid regex"},
+		{map[string]interface{}{"name": "env", "regex": "x", "other": "x"}, `unknown parameter "other"`},
+	} {
+		_, err := expandRuleTransforms([]interface{}{
+			map[string]interface{}{"type": transformReplaceLabel, "params": tc.params},
+		})
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%v: expected %q, got %v", tc.params, tc.expected, err)
+		}
+	}
+}
+
+func TestRulerNamespaceTransform(t *testing.T) {
+	fake := newFakeMimirClient()
+	d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{
+		"namespace":   "demo",
+		"config_yaml": "groups:\n  - name: group\n    rules:\n      - record: job:up:sum\n        expr: sum by (job) (up)\n",
+		"transform": []interface{}{
+			map[string]interface{}{"type": transformSetLabels, "params": map[string]interface{}{"env": "prod"}},
+		},
+	})
+	source := normalizeNamespaceYAML(d.Get("config_yaml"))
+	d.Set("config_yaml", source)
+	if diags := rulerNamespaceCreate(context.Background(), d, &client{cli: fake}); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	remote, _ := fake.ListRules(context.Background(), "demo")
+	if labels := remote["demo"][0].Rules[0].Labels; labels["env"] != "prod" {
+		t.Fatalf("expected the transformed rule to be pushed, got labels %v", labels)
+	}
+	if d.Get("config_yaml") != source {
+		t.Fatalf("expected the state to keep the source, got:\n%s", d.Get("config_yaml"))
+	}
+	if d.Get("content_sha256") == hash(source) {
+		t.Fatal("expected the hash of the transformed content")
+	}
+}
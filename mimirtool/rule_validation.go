@@ -0,0 +1,401 @@
+package mimirtool
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// defaultEvaluationInterval is the evaluation interval of the Mimir ruler for
+// groups which don't set one.
+const defaultEvaluationInterval = time.Minute
+
+// checkAlertForDurations warns about alerts whose `for` is shorter than the
+// evaluation interval of their group, which makes them fire on their first
+// evaluation.
+func checkAlertForDurations(ruleNamespace rules.RuleNamespace) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, group := range ruleNamespace.Groups {
+		interval := time.Duration(group.Interval)
+		if interval == 0 {
+			interval = defaultEvaluationInterval
+		}
+		for _, rule := range group.Rules {
+			forDuration := time.Duration(rule.For)
+			// Without `for`, firing on the first evaluation is intended.
+			if rule.Alert.Value == "" || forDuration == 0 || forDuration >= interval {
+				continue
+			}
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Alert `for` is shorter than the group interval.",
+				Detail:   fmt.Sprintf("Alert %q of group %q waits for %s but the group is evaluated every %s, so it fires on its first evaluation.", rule.Alert.Value, group.Name, forDuration, interval),
+			})
+		}
+	}
+	return diags
+}
+
+// checkAlertForMultiples warns about alerts whose `for` is not a multiple of
+// the evaluation interval of their group, so that they fire at the first
+// evaluation after it rather than right when it elapses.
+func checkAlertForMultiples(ruleNamespace rules.RuleNamespace) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, group := range ruleNamespace.Groups {
+		interval := time.Duration(group.Interval)
+		if interval == 0 {
+			interval = defaultEvaluationInterval
+		}
+		for _, rule := range group.Rules {
+			forDuration := time.Duration(rule.For)
+			if rule.Alert.Value == "" || forDuration%interval == 0 {
+				continue
+			}
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Alert `for` is not a multiple of the group interval.",
+				Detail:   fmt.Sprintf("Alert %q of group %q waits for %s but the group is evaluated every %s, so it fires after %s.", rule.Alert.Value, group.Name, forDuration, interval, (forDuration/interval+1)*interval),
+			})
+		}
+	}
+	return diags
+}
+
+// checkQueryModifiers warns about the rules whose selectors use `@` or
+// `offset` modifiers in a way which misbehaves in rule evaluation. Large
+// positive offsets are not reported, as comparing with the previous day or
+// week is a common and valid use. Invalid expressions are reported by the
+// validation, not here.
+func checkQueryModifiers(ruleNamespace rules.RuleNamespace) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, group := range ruleNamespace.Groups {
+		// The ruler evaluates the rules of groups with a query offset that
+		// much in the past, leaving room for negative offsets.
+		var delay time.Duration
+		if group.QueryOffset != nil {
+			delay = time.Duration(*group.QueryOffset)
+		} else if group.EvaluationDelay != nil {
+			delay = time.Duration(*group.EvaluationDelay)
+		}
+		for _, rule := range group.Rules {
+			issues, err := queryModifierIssues(rule.Expr.Value, delay)
+			if err != nil {
+				continue
+			}
+			for _, issue := range issues {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Warning,
+					Summary:  "Rule uses a query modifier which misbehaves in rule evaluation.",
+					Detail:   fmt.Sprintf("Rule %q of group %q %s.", ruleName(rule), group.Name, issue),
+				})
+			}
+		}
+	}
+	return diags
+}
+
+// queryModifierIssues describes the `@` and `offset` modifiers of expr which
+// misbehave when evaluated by a group with a query offset of delay.
+func queryModifierIssues(expr string, delay time.Duration) ([]string, error) {
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	var issues []string
+	check := func(timestamp *int64, startOrEnd parser.ItemType, offset time.Duration) {
+		switch {
+		case startOrEnd == parser.START || startOrEnd == parser.END:
+			// Rules are instant queries, whose start and end are both the
+			// evaluation time.
+			issues = append(issues, "uses `@ start()` or `@ end()`, which are the evaluation time in rules: remove the modifier")
+		case timestamp != nil:
+			issues = append(issues, fmt.Sprintf("uses `@ %s`, which evaluates the same samples forever until they leave the retention: remove the modifier or use `offset`", time.UnixMilli(*timestamp).UTC().Format(time.RFC3339)))
+		}
+		if offset < 0 && -offset > delay {
+			issues = append(issues, fmt.Sprintf("uses `offset -%s`, which selects samples newer than the evaluation time, never ingested yet: use a positive offset or the group `query_offset`", -offset))
+		}
+	}
+	parser.Inspect(node, func(n parser.Node, _ []parser.Node) error {
+		switch n := n.(type) {
+		case *parser.VectorSelector:
+			check(n.Timestamp, n.StartOrEnd, n.OriginalOffset)
+		case *parser.SubqueryExpr:
+			check(n.Timestamp, n.StartOrEnd, n.OriginalOffset)
+		}
+		return nil
+	})
+	return issues, nil
+}
+
+// labelDroppingFunctions are the functions whose output doesn't carry the
+// labels of their arguments.
+var labelDroppingFunctions = map[string]bool{
+	"absent":           true,
+	"absent_over_time": true,
+	"scalar":           true,
+	"time":             true,
+	"vector":           true,
+}
+
+// checkRecordingRuleCardinality warns about the recording rules whose output
+// keeps all the labels of a selector of one of metrics, every metric when
+// empty, as they record as many series as they read. Selectors of recording
+// rules, whose names hold colons, are expected to be aggregated already and
+// are not reported. This is a heuristic: it can't tell how many series a
+// selector actually matches.
+func checkRecordingRuleCardinality(ruleNamespace rules.RuleNamespace, metrics []*regexp.Regexp) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, group := range ruleNamespace.Groups {
+		for _, rule := range group.Rules {
+			if rule.Record.Value == "" {
+				continue
+			}
+			node, err := parser.ParseExpr(rule.Expr.Value)
+			if err != nil {
+				continue
+			}
+			var selectors []string
+			for _, selector := range labelPreservingSelectors(node) {
+				if highCardinalityMetric(selector.Name, metrics) {
+					selectors = append(selectors, selector.String())
+				}
+			}
+			if len(selectors) == 0 {
+				continue
+			}
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Recording rule may produce high-cardinality output.",
+				Detail:   fmt.Sprintf("Recording rule %q of group %q keeps all the labels of %s, which are not aggregated with e.g. `sum by (...)` or `sum without (...)`, so it may record as many series as it reads.", rule.Record.Value, group.Name, strings.Join(selectors, ", ")),
+			})
+		}
+	}
+	return diags
+}
+
+// labelPreservingSelectors returns the selectors of node whose labels all
+// reach its output.
+func labelPreservingSelectors(node parser.Node) []*parser.VectorSelector {
+	switch n := node.(type) {
+	case *parser.VectorSelector:
+		return []*parser.VectorSelector{n}
+	case *parser.AggregateExpr:
+		// Only the aggregations selecting series keep their labels.
+		switch n.Op {
+		case parser.TOPK, parser.BOTTOMK, parser.LIMITK, parser.LIMIT_RATIO:
+			return labelPreservingSelectors(n.Expr)
+		}
+		return nil
+	case *parser.Call:
+		if labelDroppingFunctions[n.Func.Name] {
+			return nil
+		}
+	}
+	var selectors []*parser.VectorSelector
+	for _, child := range parser.Children(node) {
+		selectors = append(selectors, labelPreservingSelectors(child)...)
+	}
+	return selectors
+}
+
+// highCardinalityMetric tells whether the selectors of the metric name are
+// reported by checkRecordingRuleCardinality.
+func highCardinalityMetric(name string, metrics []*regexp.Regexp) bool {
+	if strings.Contains(name, ":") {
+		return false
+	}
+	if len(metrics) == 0 {
+		return true
+	}
+	for _, metric := range metrics {
+		if metric.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandHighCardinalityMetrics returns the high_cardinality_metrics patterns,
+// which match whole metric names.
+func expandHighCardinalityMetrics(patterns []interface{}) []*regexp.Regexp {
+	metrics := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		metrics = append(metrics, regexp.MustCompile("^(?:"+pattern.(string)+")$"))
+	}
+	return metrics
+}
+
+const (
+	// duplicateAlertNamesWarn reports alerts defined in several groups as
+	// warnings.
+	duplicateAlertNamesWarn = "warn"
+	// duplicateAlertNamesError fails the apply of alerts defined in several
+	// groups.
+	duplicateAlertNamesError = "error"
+)
+
+// checkDuplicateAlertNames reports the alerts whose name is defined in several
+// groups of the namespace with severity, as their series collide in the
+// ALERTS metric and their notifications can't be told apart. The same name
+// within a group, e.g. for several thresholds, and recording rules are fine.
+func checkDuplicateAlertNames(ruleNamespace rules.RuleNamespace, severity diag.Severity) diag.Diagnostics {
+	var names []string
+	groups := map[string][]string{}
+	for _, group := range ruleNamespace.Groups {
+		for _, rule := range group.Rules {
+			name := rule.Alert.Value
+			if name == "" {
+				continue
+			}
+			if _, ok := groups[name]; !ok {
+				names = append(names, name)
+			}
+			if n := len(groups[name]); n == 0 || groups[name][n-1] != group.Name {
+				groups[name] = append(groups[name], group.Name)
+			}
+		}
+	}
+
+	var diags diag.Diagnostics
+	for _, name := range names {
+		if len(groups[name]) < 2 {
+			continue
+		}
+		quoted := make([]string, 0, len(groups[name]))
+		for _, group := range groups[name] {
+			quoted = append(quoted, fmt.Sprintf("%q", group))
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: severity,
+			Summary:  "Alert defined in several groups.",
+			Detail:   fmt.Sprintf("Alert %q is defined in groups %s, rename it or merge its rules into a single group.", name, strings.Join(quoted, ", ")),
+		})
+	}
+	return diags
+}
+
+// defaultAnnotationLinkKeys are the annotations checked against
+// allowed_annotation_domains when annotation_link_keys is not set.
+var defaultAnnotationLinkKeys = []string{"runbook_url", "dashboard_url"}
+
+// checkAnnotationLinks fails on the alerts whose annotations of keys link to a
+// host which is neither one of domains nor a subdomain of one. Links whose
+// host can't be told, e.g. relative or templated ones, fail too.
+func checkAnnotationLinks(ruleNamespace rules.RuleNamespace, keys []string, domains []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, group := range ruleNamespace.Groups {
+		for _, rule := range group.Rules {
+			if rule.Alert.Value == "" {
+				continue
+			}
+			checked := make([]string, 0, len(keys))
+			for _, key := range keys {
+				if _, ok := rule.Annotations[key]; ok {
+					checked = append(checked, key)
+				}
+			}
+			sort.Strings(checked)
+			for _, key := range checked {
+				link := rule.Annotations[key]
+				if allowedLink(link, domains) {
+					continue
+				}
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Alert links to a domain which is not allowed.",
+					Detail:   fmt.Sprintf("Alert %q of group %q has annotation %s %q, whose host is not one of the `allowed_annotation_domains` nor a subdomain of one: %s.", rule.Alert.Value, group.Name, key, link, strings.Join(domains, ", ")),
+				})
+			}
+		}
+	}
+	return diags
+}
+
+// allowedLink tells whether the host of link is one of domains or a
+// subdomain of one.
+func allowedLink(link string, domains []string) bool {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Hostname() == "" || strings.Contains(u.Host, "{{") {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.Trim(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules a forbidden label combination applies to.
+const (
+	ruleTypeAll       = "all"
+	ruleTypeAlerting  = "alerting"
+	ruleTypeRecording = "recording"
+)
+
+// labelCombination is a set of label keys rules must not carry all together.
+type labelCombination struct {
+	labels []string
+	// ruleType is the type of the rules it applies to.
+	ruleType string
+}
+
+// expandLabelCombinations returns the forbidden_label_combinations blocks.
+func expandLabelCombinations(blocks []interface{}) []labelCombination {
+	combinations := make([]labelCombination, 0, len(blocks))
+	for _, block := range blocks {
+		block, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		combinations = append(combinations, labelCombination{
+			labels:   expandStringList(block["labels"].([]interface{})),
+			ruleType: block["rule_type"].(string),
+		})
+	}
+	return combinations
+}
+
+// checkForbiddenLabelCombinations fails on the rules carrying all the labels
+// of one of combinations.
+func checkForbiddenLabelCombinations(ruleNamespace rules.RuleNamespace, combinations []labelCombination) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, group := range ruleNamespace.Groups {
+		for _, rule := range group.Rules {
+			kind, ruleType := "Recording rule", ruleTypeRecording
+			if rule.Alert.Value != "" {
+				kind, ruleType = "Alert", ruleTypeAlerting
+			}
+			for _, combination := range combinations {
+				if combination.ruleType != ruleTypeAll && combination.ruleType != ruleType {
+					continue
+				}
+				carried := true
+				for _, label := range combination.labels {
+					if _, ok := rule.Labels[label]; !ok {
+						carried = false
+						break
+					}
+				}
+				if !carried {
+					continue
+				}
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Rule carries a forbidden combination of labels.",
+					Detail:   fmt.Sprintf("%s %q of group %q carries the labels %s, which `forbidden_label_combinations` forbids together.", kind, ruleName(rule), group.Name, strings.Join(combination.labels, ", ")),
+				})
+			}
+		}
+	}
+	return diags
+}
@@ -0,0 +1,263 @@
+package mimirtool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+func TestCheckAlertForDurations(t *testing.T) {
+	ruleNamespace := mustRuleNamespace(t, `groups:
+  - name: default_interval
+    rules:
+      - alert: TooShort
+        expr: up == 0
+        for: 30s
+      - alert: Immediate
+        expr: up == 0
+      - record: job:up:sum
+        expr: sum by (job) (up)
+  - name: long_interval
+    interval: 5m
+    rules:
+      - alert: ShorterThanInterval
+        expr: up == 0
+        for: 2m
+      - alert: LongEnough
+        expr: up == 0
+        for: 5m
+`)
+
+	diags := checkAlertForDurations(ruleNamespace)
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, `"TooShort"`) || !strings.Contains(diags[0].Detail, "1m0s") {
+		t.Errorf("expected the default interval to apply, got %s", diags[0].Detail)
+	}
+	if !strings.Contains(diags[1].Detail, `"ShorterThanInterval"`) || !strings.Contains(diags[1].Detail, `"long_interval"`) {
+		t.Errorf("unexpected warning: %s", diags[1].Detail)
+	}
+}
+
+func TestCheckDuplicateAlertNames(t *testing.T) {
+	ruleNamespace := mustRuleNamespace(t, `groups:
+  - name: first
+    rules:
+      - alert: Duplicated
+        expr: up == 0
+      - alert: Thresholds
+        expr: up == 0
+      - alert: Thresholds
+        expr: absent(up)
+      - record: job:up:sum
+        expr: sum by (job) (up)
+  - name: second
+    rules:
+      - alert: Duplicated
+        expr: up == 0
+      - record: job:up:sum
+        expr: sum by (job) (up)
+`)
+
+	diags := checkDuplicateAlertNames(ruleNamespace, diag.Warning)
+	if len(diags) != 1 || diags.HasError() {
+		t.Fatalf("expected 1 warning, got %v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, `"Duplicated"`) || !strings.Contains(diags[0].Detail, `"first", "second"`) {
+		t.Errorf("unexpected warning: %s", diags[0].Detail)
+	}
+	if !checkDuplicateAlertNames(ruleNamespace, diag.Error).HasError() {
+		t.Error("expected an error")
+	}
+}
+
+func TestCheckQueryModifiers(t *testing.T) {
+	ruleNamespace := mustRuleNamespace(t, `groups:
+  - name: modifiers
+    rules:
+      - record: job:requests:rate5m
+        expr: sum by (job) (rate(http_requests_total[5m] @ end()))
+      - record: job:requests:pinned
+        expr: sum by (job) (http_requests_total @ 1700000000)
+      - alert: FutureSamples
+        expr: up offset -5m == 0
+      - alert: WeekOverWeek
+        expr: sum(rate(http_requests_total[5m])) < 0.5 * sum(rate(http_requests_total[5m] offset 1w))
+  - name: delayed
+    query_offset: 10m
+    rules:
+      - record: job:up:next
+        expr: sum by (job) (up offset -5m)
+`)
+
+	diags := checkQueryModifiers(ruleNamespace)
+	if len(diags) != 3 {
+		t.Fatalf("expected 3 warnings, got %v", diags)
+	}
+	for i, want := range []string{"`@ start()` or `@ end()`", "`@ 2023-11-14T22:13:20Z`", "`offset -5m0s`"} {
+		if !strings.Contains(diags[i].Detail, want) {
+			t.Errorf("expected %s in warning %d, got %s", want, i, diags[i].Detail)
+		}
+	}
+}
+
+func TestCheckRecordingRuleCardinality(t *testing.T) {
+	ruleNamespace := mustRuleNamespace(t, `groups:
+  - name: cardinality
+    rules:
+      - record: instance:requests:rate5m
+        expr: rate(http_requests_total[5m])
+      - record: job:requests:rate5m
+        expr: sum by (job) (rate(http_requests_total[5m]))
+      - record: cluster:requests:rate5m
+        expr: sum without (instance, pod) (rate(http_requests_total[5m]))
+      - record: pod:memory:top
+        expr: topk(10, container_memory_working_set_bytes) / on (pod) group_left kube_pod_info
+      - record: job:errors:ratio
+        expr: job:errors:rate5m / job:requests:rate5m
+      - record: up:absent
+        expr: absent(up{job="api"})
+      - alert: Down
+        expr: up == 0
+`)
+
+	diags := checkRecordingRuleCardinality(ruleNamespace, nil)
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", diags)
+	}
+	for i, want := range []string{`"instance:requests:rate5m"`, `container_memory_working_set_bytes, kube_pod_info`} {
+		if !strings.Contains(diags[i].Detail, want) {
+			t.Errorf("expected %s in warning %d, got %s", want, i, diags[i].Detail)
+		}
+	}
+
+	diags = checkRecordingRuleCardinality(ruleNamespace, expandHighCardinalityMetrics([]interface{}{"container_.*"}))
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, `"pod:memory:top" of group "cardinality" keeps all the labels of container_memory_working_set_bytes,`) {
+		t.Fatalf("expected only the container metric to be reported, got %v", diags)
+	}
+}
+
+func TestCheckAnnotationLinks(t *testing.T) {
+	ruleNamespace := mustRuleNamespace(t, `groups:
+  - name: links
+    rules:
+      - alert: Internal
+        expr: up == 0
+        annotations:
+          runbook_url: https://wiki.corp.example/runbooks/up
+          dashboard_url: https://grafana.example.net:3000/d/up?var-job={{ $labels.job }}
+      - alert: External
+        expr: up == 0
+        annotations:
+          runbook_url: https://pastebin.example.org/up
+          summary: https://anywhere.example.com
+      - alert: Relative
+        expr: up == 0
+        annotations:
+          dashboard_url: /d/up
+      - alert: Templated
+        expr: up == 0
+        annotations:
+          runbook_url: https://{{ $labels.host }}/runbook
+      - record: job:up:sum
+        expr: sum by (job) (up)
+        labels:
+          runbook_url: https://pastebin.example.org/up
+`)
+
+	diags := checkAnnotationLinks(ruleNamespace, defaultAnnotationLinkKeys, []string{"corp.example", "Grafana.Example.NET"})
+	if len(diags) != 3 || !diags.HasError() {
+		t.Fatalf("expected 3 errors, got %v", diags)
+	}
+	for i, want := range []string{`Alert "External"`, `Alert "Relative"`, `Alert "Templated"`} {
+		if !strings.Contains(diags[i].Detail, want) {
+			t.Errorf("expected %s in error %d, got %s", want, i, diags[i].Detail)
+		}
+	}
+	if !strings.Contains(diags[0].Detail, `runbook_url "https://pastebin.example.org/up"`) {
+		t.Errorf("expected the offending link, got %s", diags[0].Detail)
+	}
+
+	if diags := checkAnnotationLinks(ruleNamespace, []string{"summary"}, []string{"example.com"}); len(diags) != 0 {
+		t.Errorf("expected only the configured keys to be checked, got %v", diags)
+	}
+	if allowedLink("https://corp.example.evil.org", []string{"corp.example"}) || allowedLink("https://evilcorp.example", []string{"corp.example"}) {
+		t.Error("expected lookalike hosts to be rejected")
+	}
+}
+
+func TestCheckForbiddenLabelCombinations(t *testing.T) {
+	ruleNamespace := mustRuleNamespace(t, `groups:
+  - name: ownership
+    rules:
+      - alert: BothOwners
+        expr: up == 0
+        labels:
+          team: storage
+          squad: blocks
+      - alert: OneOwner
+        expr: up == 0
+        labels:
+          team: storage
+      - record: job:up:sum
+        expr: sum by (job) (up)
+        labels:
+          team: storage
+          squad: blocks
+      - record: job:up:max
+        expr: max by (job) (up)
+        labels:
+          severity: page
+          team: storage
+`)
+
+	diags := checkForbiddenLabelCombinations(ruleNamespace, []labelCombination{
+		{labels: []string{"team", "squad"}, ruleType: ruleTypeAll},
+		{labels: []string{"severity", "team"}, ruleType: ruleTypeAlerting},
+	})
+	if len(diags) != 2 || !diags.HasError() {
+		t.Fatalf("expected 2 errors, got %v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, `Alert "BothOwners" of group "ownership" carries the labels team, squad`) {
+		t.Errorf("unexpected error: %s", diags[0].Detail)
+	}
+	if !strings.Contains(diags[1].Detail, `Recording rule "job:up:sum"`) {
+		t.Errorf("unexpected error: %s", diags[1].Detail)
+	}
+}
+
+func TestCheckAlertForMultiples(t *testing.T) {
+	ruleNamespace := mustRuleNamespace(t, `groups:
+  - name: default_interval
+    rules:
+      - alert: NotMultiple
+        expr: up == 0
+        for: 90s
+      - alert: Immediate
+        expr: up == 0
+      - record: job:up:sum
+        expr: sum by (job) (up)
+  - name: long_interval
+    interval: 2m
+    rules:
+      - alert: Multiple
+        expr: up == 0
+        for: 4m
+      - alert: ShorterThanInterval
+        expr: up == 0
+        for: 1m
+`)
+
+	diags := checkAlertForMultiples(ruleNamespace)
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, `"NotMultiple"`) || !strings.Contains(diags[0].Detail, "every 1m0s, so it fires after 2m0s") {
+		t.Errorf("expected the default interval to apply, got %s", diags[0].Detail)
+	}
+	if !strings.Contains(diags[1].Detail, `"ShorterThanInterval"`) || !strings.Contains(diags[1].Detail, "waits for 1m0s but the group is evaluated every 2m0s") {
+		t.Errorf("unexpected warning: %s", diags[1].Detail)
+	}
+}
@@ -0,0 +1,54 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+)
+
+// rulerLimitChecks are the ruler limits as set on the provider, the same for
+// all its tenants, so that the plan fails rather than an apply midway. 0 means
+// not checked.
+type rulerLimitChecks struct {
+	maxRulesPerRuleGroup      int
+	maxRuleGroupsPerNamespace int
+}
+
+// check returns an error naming the groups of ruleNamespace over the limits.
+func (l rulerLimitChecks) check(ruleNamespace rules.RuleNamespace) error {
+	var errs []error
+	if l.maxRuleGroupsPerNamespace > 0 && len(ruleNamespace.Groups) > l.maxRuleGroupsPerNamespace {
+		errs = append(errs, fmt.Errorf("the namespace has %d rule groups, more than the %d allowed by the provider `max_rule_groups_per_namespace`", len(ruleNamespace.Groups), l.maxRuleGroupsPerNamespace))
+	}
+	if l.maxRulesPerRuleGroup > 0 {
+		for _, group := range ruleNamespace.Groups {
+			if len(group.Rules) > l.maxRulesPerRuleGroup {
+				errs = append(errs, fmt.Errorf("rule group %q has %d rules, more than the %d allowed by the provider `max_rules_per_rule_group`", group.Name, len(group.Rules), l.maxRulesPerRuleGroup))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkNamespaceRulerLimits checks ruleNamespace, the content of the
+// mimirtool_ruler_namespace of d, against the ruler limits of the provider
+// as it gets uploaded: with auto_split_groups, after its groups are split.
+func checkNamespaceRulerLimits(ctx context.Context, c *client, d attributeGetter, ruleNamespace rules.RuleNamespace) error {
+	if c.rulerLimits == (rulerLimitChecks{}) {
+		return nil
+	}
+	if d.Get("auto_split_groups").(bool) {
+		limit, err := ruleGroupSplitLimit(withTenant(ctx, d.Get("tenant_id").(string)), c, d)
+		if err != nil {
+			return err
+		}
+		split, _, diags := splitRuleGroups(ruleNamespace, limit)
+		if diags.HasError() {
+			return fmt.Errorf("%s", diags[len(diags)-1].Detail)
+		}
+		ruleNamespace = split
+	}
+	return c.rulerLimits.check(ruleNamespace)
+}
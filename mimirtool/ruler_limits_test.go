@@ -0,0 +1,61 @@
+package mimirtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRulerLimitChecks(t *testing.T) {
+	ruleNamespace, err := parseRuleNamespace(`groups:
+  - name: small
+    rules:
+      - record: a
+        expr: up
+  - name: big
+    rules:
+      - record: b
+        expr: up
+      - record: c
+        expr: up
+      - record: d
+        expr: up
+`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (rulerLimitChecks{}).check(ruleNamespace); err != nil {
+		t.Errorf("expected no check without limits, got %v", err)
+	}
+	if err := (rulerLimitChecks{maxRulesPerRuleGroup: 3, maxRuleGroupsPerNamespace: 2}).check(ruleNamespace); err != nil {
+		t.Errorf("expected the limits to be met, got %v", err)
+	}
+
+	err = (rulerLimitChecks{maxRulesPerRuleGroup: 2, maxRuleGroupsPerNamespace: 1}).check(ruleNamespace)
+	if err == nil {
+		t.Fatal("expected the limits to be exceeded")
+	}
+	for _, want := range []string{`rule group "big" has 3 rules, more than the 2 allowed`, "the namespace has 2 rule groups, more than the 1 allowed"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), `"small"`) {
+		t.Errorf("expected only the groups over the limit to be reported, got %v", err)
+	}
+
+	// With auto_split_groups, the groups are checked as uploaded.
+	c := &client{rulerLimits: rulerLimitChecks{maxRulesPerRuleGroup: 2, maxRuleGroupsPerNamespace: 2}}
+	d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{"auto_split_groups": true, "max_rules_per_group": 2})
+	err = checkNamespaceRulerLimits(context.Background(), c, d, ruleNamespace)
+	if err == nil || !strings.Contains(err.Error(), "the namespace has 3 rule groups, more than the 2 allowed") || strings.Contains(err.Error(), "rules, more than") {
+		t.Errorf("expected the groups to be counted after the split, got %v", err)
+	}
+	c.rulerLimits.maxRuleGroupsPerNamespace = 3
+	if err := checkNamespaceRulerLimits(context.Background(), c, d, ruleNamespace); err != nil {
+		t.Errorf("expected the split groups to meet the limits, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package mimirtool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/exp/slices"
+)
+
+// checkServerNormalization reads the groups of namespace back once pushed and
+// fails on the ones whose canonical form differs from the one of the group
+// uploaded, that is the ones Mimir transformed on upload. Formatting
+// differences, e.g. of the expressions, are not reported.
+func checkServerNormalization(ctx context.Context, client mimirClientInterface, namespace string, pushed []rwrulefmt.RuleGroup) diag.Diagnostics {
+	remote, err := client.ListRules(ctx, namespace)
+	if err != nil {
+		return diag.Errorf("unable to read namespace %q back to check fail_on_server_normalization: %s", namespace, err)
+	}
+	var diags diag.Diagnostics
+	for _, group := range pushed {
+		uploaded := canonicalGroupYAML(group)
+		server := ""
+		if i := slices.IndexFunc(remote[namespace], func(g rwrulefmt.RuleGroup) bool { return g.Name == group.Name }); i >= 0 {
+			server = canonicalGroupYAML(remote[namespace][i])
+		}
+		if server == uploaded {
+			continue
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Grafana Mimir modified the uploaded rule group.",
+			Detail:   fmt.Sprintf("Group %q of namespace %q as read back from Grafana Mimir differs from the one uploaded, both in canonical form, and `fail_on_server_normalization` is set. The group stays uploaded as Mimir holds it.\n\n%s", group.Name, namespace, lineDiff(uploaded, server)),
+		})
+	}
+	return diags
+}
+
+// canonicalGroupYAML returns group in the canonical form of the state,
+// without modifying it.
+func canonicalGroupYAML(group rwrulefmt.RuleGroup) string {
+	return normalizeRuleNamespace(copyRuleNamespace(rules.RuleNamespace{Groups: []rwrulefmt.RuleGroup{group}}))
+}
+
+// lineDiff returns the lines of old and new, prefixed with "-" when removed,
+// "+" when added and a space when kept.
+func lineDiff(old, new string) string {
+	a := strings.Split(strings.TrimSuffix(old, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(new, "\n"), "\n")
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var diff strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&diff, "  %s\n", a[i])
+			i++
+			j++
+		// Removals come first, as in unified diffs.
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&diff, "- %s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(&diff, "+ %s\n", b[j])
+			j++
+		}
+	}
+	return diff.String()
+}
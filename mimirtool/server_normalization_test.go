@@ -0,0 +1,70 @@
+package mimirtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules"
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// normalizingMimirClient adds a label to the rules it stores, as a Mimir
+// transforming the groups on upload.
+type normalizingMimirClient struct {
+	*fakeMimirClient
+}
+
+func (n normalizingMimirClient) CreateRuleGroup(ctx context.Context, namespace string, rg rwrulefmt.RuleGroup) error {
+	group := copyRuleNamespace(rules.RuleNamespace{Groups: []rwrulefmt.RuleGroup{rg}}).Groups[0]
+	for i := range group.Rules {
+		if group.Rules[i].Labels == nil {
+			group.Rules[i].Labels = map[string]string{}
+		}
+		group.Rules[i].Labels["cluster"] = "eu-west"
+	}
+	return n.fakeMimirClient.CreateRuleGroup(ctx, namespace, group)
+}
+
+func TestFailOnServerNormalization(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		normalize bool
+	}{
+		{name: "kept as uploaded"},
+		{name: "normalized", normalize: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cli mimirClientInterface = newFakeMimirClient()
+			if tc.normalize {
+				cli = normalizingMimirClient{newFakeMimirClient()}
+			}
+			d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, map[string]interface{}{
+				"namespace":                    "demo",
+				"config_yaml":                  "groups:\n  - name: group\n    rules:\n      - record: job:up:sum\n        expr: sum by (job) (up)\n",
+				"fail_on_server_normalization": true,
+			})
+			diags := rulerNamespaceCreate(context.Background(), d, &client{cli: cli})
+			if !tc.normalize {
+				if diags.HasError() {
+					t.Fatal(diags)
+				}
+				return
+			}
+			if !diags.HasError() || !strings.Contains(diags[0].Detail, `Group "group" of namespace "demo"`) || !strings.Contains(diags[0].Detail, "\n+ ") || !strings.Contains(diags[0].Detail, "cluster: eu-west") {
+				t.Fatalf("expected the normalization to be reported with a diff, got %v", diags)
+			}
+			if !strings.Contains(d.Get("config_yaml").(string), "eu-west") {
+				t.Errorf("expected the state to hold the content of Mimir, got %s", d.Get("config_yaml"))
+			}
+		})
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff("a\nb\nc\n", "a\nc\nd\n")
+	if want := "  a\n- b\n  c\n+ d\n"; got != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
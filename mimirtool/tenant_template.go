@@ -0,0 +1,67 @@
+package mimirtool
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxTenantIDLength is the longest tenant ID Grafana Mimir accepts.
+const maxTenantIDLength = 150
+
+var (
+	// tenantTemplateVariableRegexp matches the {variable} references of
+	// tenant_id_template.
+	tenantTemplateVariableRegexp = regexp.MustCompile(`\{([^{}]*)\}`)
+	// tenantIDRegexp matches the characters Grafana Mimir accepts in tenant IDs.
+	tenantIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9!\-_.*'()]+$`)
+)
+
+// renderTenantIDTemplate returns the tenant ID of tmpl, whose {name}
+// references are replaced by the values of vars, and makes sure Grafana Mimir
+// accepts it.
+func renderTenantIDTemplate(tmpl string, vars map[string]string) (string, error) {
+	var errs []string
+	tenant := tenantTemplateVariableRegexp.ReplaceAllStringFunc(tmpl, func(ref string) string {
+		name := ref[1 : len(ref)-1]
+		value, ok := vars[name]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Sprintf("unknown variable %q", name))
+		case value == "":
+			errs = append(errs, fmt.Sprintf("variable %q is empty", name))
+		}
+		return value
+	})
+	if len(errs) > 0 {
+		return "", fmt.Errorf("tenant_id_template: %s", strings.Join(errs, ", "))
+	}
+	if err := validateTenantID(tenant); err != nil {
+		return "", fmt.Errorf("tenant_id_template: %w", err)
+	}
+	return tenant, nil
+}
+
+// validateTenantID returns an error when Grafana Mimir would refuse tenant.
+func validateTenantID(tenant string) error {
+	switch {
+	case tenant == "":
+		return fmt.Errorf("the tenant ID is empty")
+	case len(tenant) > maxTenantIDLength:
+		return fmt.Errorf("the tenant ID %q is longer than %d characters", tenant, maxTenantIDLength)
+	case tenant == "." || tenant == "..":
+		return fmt.Errorf("the tenant ID %q is not allowed", tenant)
+	case !tenantIDRegexp.MatchString(tenant):
+		return fmt.Errorf("the tenant ID %q holds characters other than letters, digits and !-_.*'()", tenant)
+	}
+	return nil
+}
+
+// tenantID returns the tenant_id of the provider, or the one rendered from
+// tenant_id_template when unset.
+func tenantID(tenant string, tmpl string, environment string) (string, error) {
+	if tenant != "" || tmpl == "" {
+		return tenant, nil
+	}
+	return renderTenantIDTemplate(tmpl, map[string]string{"env": environment})
+}
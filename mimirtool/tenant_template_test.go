@@ -0,0 +1,61 @@
+package mimirtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestRenderTenantIDTemplate(t *testing.T) {
+	vars := map[string]string{"env": "staging"}
+	for _, tc := range []struct {
+		tmpl     string
+		expected string
+		err      string
+	}{
+		{"{env}-metrics", "staging-metrics", ""},
+		{"metrics", "metrics", ""},
+		{"{region}-metrics", "", `unknown variable "region"`},
+		{"{env}/metrics", "", "holds characters other than"},
+		{"{}", "", `unknown variable ""`},
+		{strings.Repeat("x", 151), "", "longer than 150 characters"},
+	} {
+		tenant, err := renderTenantIDTemplate(tc.tmpl, vars)
+		if tc.err == "" && (err != nil || tenant != tc.expected) {
+			t.Errorf("%q: expected %q, got %q, %v", tc.tmpl, tc.expected, tenant, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%q: expected an error containing %q, got %v", tc.tmpl, tc.err, err)
+		}
+	}
+	if _, err := renderTenantIDTemplate("{env}-metrics", map[string]string{"env": ""}); err == nil || !strings.Contains(err.Error(), `variable "env" is empty`) {
+		t.Errorf("expected an empty variable error, got %v", err)
+	}
+}
+
+func TestProviderTenantIDTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		config   map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"tenant_id_template": "{env}-metrics", "environment": "prod"}, "prod-metrics"},
+		{map[string]interface{}{"tenant_id": "explicit", "tenant_id_template": "{env}-metrics", "environment": "prod"}, "explicit"},
+	} {
+		tc.config["address"] = "http://mimir.invalid"
+		p := newProvider("dev", nil)()
+		if diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(tc.config)); diags.HasError() {
+			t.Fatal(diags)
+		}
+		if tenant := p.Meta().(*client).config.ID; tenant != tc.expected {
+			t.Errorf("expected the tenant %q, got %q", tc.expected, tenant)
+		}
+	}
+
+	p := newProvider("dev", nil)()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{"address": "http://mimir.invalid", "tenant_id_template": "{env}-metrics"})
+	if diags := p.Configure(context.Background(), config); !diags.HasError() {
+		t.Error("expected a template without environment to be refused")
+	}
+}
@@ -0,0 +1,138 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kinds of the calls to Mimir, which have their own timeouts.
+const (
+	callRuler        = "ruler"
+	callAlertmanager = "alertmanager"
+)
+
+// defaultRequestTimeout bounds each attempt of a call when http_timeout is
+// unset.
+const defaultRequestTimeout = 30 * time.Second
+
+// callTimeouts bound the calls to Mimir, their retries included. Zero means no
+// bound.
+type callTimeouts struct {
+	global time.Duration
+	// ruler and alertmanager override global for the calls of their kind when
+	// set.
+	ruler        time.Duration
+	alertmanager time.Duration
+	// request bounds each attempt of a call, when set.
+	request time.Duration
+	// requestDefault tells that request is defaultRequestTimeout, http_timeout
+	// being unset.
+	requestDefault bool
+}
+
+// newCallTimeouts returns the timeouts of the settings, an empty one being
+// unset. An unset request timeout defaults to defaultRequestTimeout.
+func newCallTimeouts(global, ruler, alertmanager, request string) (callTimeouts, error) {
+	var t callTimeouts
+	for _, setting := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"timeout", global, &t.global},
+		{"ruler_timeout", ruler, &t.ruler},
+		{"alertmanager_timeout", alertmanager, &t.alertmanager},
+		{"http_timeout", request, &t.request},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(setting.value)
+		if err != nil {
+			return t, fmt.Errorf("invalid %s: %w", setting.name, err)
+		}
+		*setting.dst = d
+	}
+	if request == "" {
+		t.request, t.requestDefault = defaultRequestTimeout, true
+	}
+	return t, nil
+}
+
+// callKind returns the kind of the calls made for resource, a resource or data
+// source type.
+func callKind(resource string) string {
+	switch {
+	case strings.HasPrefix(resource, "mimirtool_alertmanager"):
+		return callAlertmanager
+	case strings.HasPrefix(resource, "mimirtool_ruler"):
+		return callRuler
+	}
+	return ""
+}
+
+// of returns the timeout of the calls of kind.
+func (t callTimeouts) of(kind string) time.Duration {
+	switch {
+	case kind == callRuler && t.ruler > 0:
+		return t.ruler
+	case kind == callAlertmanager && t.alertmanager > 0:
+		return t.alertmanager
+	}
+	return t.global
+}
+
+// context returns ctx along with the deadline of the calls of kind.
+func (t callTimeouts) context(ctx context.Context, kind string) (context.Context, context.CancelFunc) {
+	if timeout := t.of(kind); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// describe turns err, the error of the call operation of kind made with ctx,
+// into an actionable one when the call ran out of time.
+func (t callTimeouts) describe(ctx context.Context, kind string, operation string, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) || t.of(kind) == 0 {
+		return err
+	}
+	setting := "timeout"
+	if kind != "" && t.of(kind) != t.global {
+		setting = kind + "_timeout"
+	}
+	return fmt.Errorf("%s did not complete within %s, see the provider `%s`: %w", operation, t.of(kind), setting, err)
+}
+
+// attempt returns the timeout of a single attempt of a call of kind. The
+// default one doesn't apply to the kinds whose own timeout is set, so that
+// it doesn't cut off the calls ruler_timeout or alertmanager_timeout allow to
+// last longer: their attempts are only bounded by the deadline of the call.
+func (t callTimeouts) attempt(kind string) time.Duration {
+	if t.requestDefault && ((kind == callRuler && t.ruler > 0) || (kind == callAlertmanager && t.alertmanager > 0)) {
+		return 0
+	}
+	return t.request
+}
+
+// attemptContext returns ctx along with the deadline of a single attempt of a
+// call of kind.
+func (t callTimeouts) attemptContext(ctx context.Context, kind string) (context.Context, context.CancelFunc) {
+	if timeout := t.attempt(kind); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// describeAttempt turns err, the error of an attempt of the call operation of
+// kind made with attemptCtx, derived from ctx, into an actionable one when the
+// attempt rather than the whole call ran out of time.
+func (t callTimeouts) describeAttempt(ctx, attemptCtx context.Context, kind string, operation string, err error) error {
+	timeout := t.attempt(kind)
+	if err == nil || timeout == 0 || ctx.Err() != nil || !errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%s got no response within %s, see the provider `http_timeout`: %w", operation, timeout, err)
+}
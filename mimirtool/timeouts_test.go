@@ -0,0 +1,85 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowMimirClient blocks the alertmanager uploads until their context is done.
+type slowMimirClient struct {
+	*fakeMimirClient
+}
+
+func (c slowMimirClient) CreateAlertmanagerConfig(ctx context.Context, cfg string, templates map[string]string) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestCallTimeouts(t *testing.T) {
+	timeouts, err := newCallTimeouts("30s", "", "5m", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeouts.of(callRuler) != 30*time.Second || timeouts.of(callAlertmanager) != 5*time.Minute || timeouts.of("") != 30*time.Second {
+		t.Fatalf("unexpected timeouts %+v", timeouts)
+	}
+	// The default request timeout gives way to alertmanager_timeout, not to
+	// an explicit http_timeout.
+	if timeouts.attempt(callAlertmanager) != 0 || timeouts.attempt(callRuler) != defaultRequestTimeout {
+		t.Fatalf("expected the default request timeout to only bound the ruler calls, got %+v", timeouts)
+	}
+	if explicit, _ := newCallTimeouts("30s", "", "5m", "1m"); explicit.attempt(callAlertmanager) != time.Minute {
+		t.Fatalf("expected the explicit request timeout to bound the alertmanager calls, got %+v", explicit)
+	}
+	if _, err := newCallTimeouts("30s", "soon", "", ""); err == nil || !strings.Contains(err.Error(), "ruler_timeout") {
+		t.Fatalf("expected an invalid ruler_timeout, got %v", err)
+	}
+
+	for resource, kind := range map[string]string{
+		"mimirtool_ruler_namespace":              callRuler,
+		"mimirtool_ruler_shards":                 callRuler,
+		"mimirtool_alertmanager":                 callAlertmanager,
+		"mimirtool_alertmanager_config_versions": callAlertmanager,
+		"mimirtool_recording_rule_outputs":       "",
+	} {
+		if got := callKind(resource); got != kind {
+			t.Errorf("%s: expected the %q kind, got %q", resource, kind, got)
+		}
+	}
+}
+
+func TestAPIClientTimeouts(t *testing.T) {
+	cli := newAPIClient(slowMimirClient{newFakeMimirClient()}, apiClientOptions{
+		retry:    retryPolicy{maxAttempts: 1},
+		timeouts: callTimeouts{ruler: time.Hour, alertmanager: 10 * time.Millisecond},
+	})
+
+	err := cli.CreateAlertmanagerConfig(context.Background(), "route: {}", nil)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "`alertmanager_timeout`") {
+		t.Fatalf("expected the alertmanager call to time out, got %v", err)
+	}
+
+	// The ruler calls get their own deadline.
+	if _, err := cli.ListRules(context.Background(), "demo"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestAPIClientRequestTimeout(t *testing.T) {
+	cli := newAPIClient(slowMimirClient{newFakeMimirClient()}, apiClientOptions{
+		retry:    retryPolicy{maxAttempts: 1},
+		timeouts: callTimeouts{global: time.Hour, request: 10 * time.Millisecond},
+	})
+
+	err := cli.CreateAlertmanagerConfig(context.Background(), "route: {}", nil)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "`http_timeout`") {
+		t.Fatalf("expected the request to time out, got %v", err)
+	}
+
+	if _, err := newCallTimeouts("", "", "", "never"); err == nil || !strings.Contains(err.Error(), "http_timeout") {
+		t.Fatalf("expected an invalid http_timeout, got %v", err)
+	}
+}
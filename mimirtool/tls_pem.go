@@ -0,0 +1,73 @@
+package mimirtool
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// tlsPEM is the TLS material set inline by ca_cert_pem, tls_cert_pem and
+// tls_key_pem. dskit only loads files, so the material is parsed when the
+// provider is configured and set on the transport by newTransport instead.
+type tlsPEM struct {
+	// caChain is empty when ca_cert_pem is unset.
+	caChain []*x509.Certificate
+	// certChain and certificate are empty when tls_cert_pem is unset.
+	certChain   []*x509.Certificate
+	certificate *tls.Certificate
+}
+
+// parseTLSPEM returns the TLS material of the PEM attributes, or nil when
+// none is set. The errors name the attribute but never quote its content.
+func parseTLSPEM(caPEM string, certPEM string, keyPEM string) (*tlsPEM, error) {
+	if caPEM == "" && certPEM == "" && keyPEM == "" {
+		return nil, nil
+	}
+	p := &tlsPEM{}
+	if caPEM != "" {
+		chain, err := parseCertificates([]byte(caPEM))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ca_cert_pem: %w", err)
+		}
+		p.caChain = chain
+	}
+	if certPEM != "" || keyPEM != "" {
+		chain, err := parseCertificates([]byte(certPEM))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tls_cert_pem: %w", err)
+		}
+		if block, _ := pem.Decode([]byte(keyPEM)); block == nil {
+			return nil, errors.New("invalid tls_key_pem: no PEM block found")
+		}
+		certificate, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tls_key_pem: %w", err)
+		}
+		p.certChain = chain
+		p.certificate = &certificate
+	}
+	return p, nil
+}
+
+// apply sets the material on the TLS config of t, on top of the settings
+// dskit loaded.
+func (p *tlsPEM) apply(t *http.Transport) {
+	config := &tls.Config{}
+	if t.TLSClientConfig != nil {
+		config = t.TLSClientConfig.Clone()
+	}
+	if len(p.caChain) > 0 {
+		pool := x509.NewCertPool()
+		for _, cert := range p.caChain {
+			pool.AddCert(cert)
+		}
+		config.RootCAs = pool
+	}
+	if p.certificate != nil {
+		config.Certificates = []tls.Certificate{*p.certificate}
+	}
+	t.TLSClientConfig = config
+}
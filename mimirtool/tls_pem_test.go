@@ -0,0 +1,62 @@
+package mimirtool
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseTLSPEM(t *testing.T) {
+	dir := t.TempDir()
+	read := func(path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	certPath, keyPath := writeTestKeyPair(t, dir, "client")
+	caPath, otherKeyPath := writeTestKeyPair(t, dir, "ca")
+	cert, key, ca, otherKey := read(certPath), read(keyPath), read(caPath), read(otherKeyPath)
+
+	if p, err := parseTLSPEM("", "", ""); p != nil || err != nil {
+		t.Fatalf("expected no material, got %v, %v", p, err)
+	}
+
+	p, err := parseTLSPEM(ca, cert, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.caChain) != 1 || len(p.certChain) != 1 || p.certChain[0].Subject.CommonName != "client" {
+		t.Fatalf("unexpected material %+v", p)
+	}
+	base := http.DefaultTransport.(*http.Transport).TLSClientConfig
+	transport := newTransport(http.DefaultTransport, clientConfig{tlsPEM: p})
+	if config := transport.TLSClientConfig; config == nil || config.RootCAs == nil || len(config.Certificates) != 1 {
+		t.Fatalf("expected the material on the transport, got %+v", config)
+	}
+	if config := http.DefaultTransport.(*http.Transport).TLSClientConfig; config != base || (config != nil && config.RootCAs != nil) {
+		t.Fatal("the default transport was modified")
+	}
+
+	for _, tc := range []struct {
+		ca, cert, key string
+		attribute     string
+	}{
+		{"not a certificate", "", "", "ca_cert_pem"},
+		{"", "not a certificate", key, "tls_cert_pem"},
+		{"", cert, "not a key", "tls_key_pem"},
+		{"", cert, otherKey, "tls_key_pem"},
+	} {
+		_, err := parseTLSPEM(tc.ca, tc.cert, tc.key)
+		if err == nil || !strings.Contains(err.Error(), "invalid "+tc.attribute) {
+			t.Errorf("expected %s to be refused, got %v", tc.attribute, err)
+			continue
+		}
+		if strings.Contains(err.Error(), "PRIVATE") || strings.Contains(err.Error(), "not a") {
+			t.Errorf("the error quotes the PEM content: %s", err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package mimirtool
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before its expiry a token gets refreshed.
+const tokenRefreshMargin = time.Minute
+
+// jwtExpiry returns the expiry of token when it is a JWT with an exp claim.
+// The signature is not checked, the expiry is only used to refresh the token
+// in time and to explain authentication failures.
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(*claims.Exp), 0), true
+}
+
+// tokenSource provides the bearer token of requests.
+type tokenSource interface {
+	Token() (string, error)
+}
+
+// cachedTokenSource caches the token returned by fetch and fetches a new one
+// when it is about to expire, so that long applies outlive short-lived
+// tokens. Tokens without a known expiry are fetched once.
+type cachedTokenSource struct {
+	fetch func() (token string, expiry time.Time, err error)
+	// now is time.Now, overridden by tests.
+	now func() time.Time
+	// leeway refreshes the tokens that much earlier, as the clock of the
+	// issuer may be ahead of the local one.
+	leeway time.Duration
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	// fetching is the fetch in progress, shared by the callers needing a
+	// token meanwhile. It runs outside of mu.
+	fetching *tokenFetch
+}
+
+// tokenFetch is a fetch of a token, whose result is set when done is closed.
+type tokenFetch struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// Token returns the current token, refreshing it if it expires within
+// tokenRefreshMargin and the leeway.
+func (s *cachedTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	if s.token != "" && (s.expiry.IsZero() || s.now().Before(s.expiry.Add(-tokenRefreshMargin-s.leeway))) {
+		defer s.mu.Unlock()
+		return s.token, nil
+	}
+	if f := s.fetching; f != nil {
+		s.mu.Unlock()
+		<-f.done
+		return f.token, f.err
+	}
+	f := &tokenFetch{done: make(chan struct{})}
+	s.fetching = f
+	s.mu.Unlock()
+
+	token, expiry, err := s.fetch()
+	s.mu.Lock()
+	if err == nil {
+		s.token, s.expiry = token, expiry
+	}
+	s.fetching = nil
+	s.mu.Unlock()
+	f.token, f.err = token, err
+	close(f.done)
+	return token, err
+}
+
+// invalidate makes the next call to Token fetch a new token.
+func (s *cachedTokenSource) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+// newFileTokenSource reads the token from a file, its expiry is the one of the
+// JWT it holds.
+func newFileTokenSource(path string) *cachedTokenSource {
+	return &cachedTokenSource{now: time.Now, fetch: func() (string, time.Time, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to read the auth token file: %w", err)
+		}
+		token := strings.TrimSpace(string(content))
+		if token == "" {
+			return "", time.Time{}, fmt.Errorf("the auth token file %s is empty", path)
+		}
+		expiry, _ := jwtExpiry(token)
+		return token, expiry, nil
+	}}
+}
+
+// credentialCommandTimeout bounds the runs of the credential command.
+const credentialCommandTimeout = time.Minute
+
+// newCommandTokenSource runs a command for the token, killing it after
+// timeout. It prints either the
+// token or a JSON object with the token and its RFC 3339 expiry, the expiry of
+// a JWT is used when none is given. The output is never logged, nor is the
+// standard error, which may hold the token too.
+func newCommandTokenSource(args []string, timeout time.Duration) *cachedTokenSource {
+	return &cachedTokenSource{now: time.Now, fetch: func() (string, time.Time, error) {
+		// The run is shared by the requests waiting for a token, it is not
+		// bound to any of them.
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		// Don't wait for the output of the processes the command may have
+		// started once it is killed.
+		cmd.WaitDelay = time.Second
+		out, err := cmd.Output()
+		if ctx.Err() != nil {
+			return "", time.Time{}, fmt.Errorf("credential command %s did not complete within %s", args[0], timeout)
+		} else if err != nil {
+			return "", time.Time{}, fmt.Errorf("credential command %s failed: %w", args[0], err)
+		}
+		out = bytes.TrimSpace(out)
+
+		var res struct {
+			Token  string    `json:"token"`
+			Expiry time.Time `json:"expiry"`
+		}
+		if len(out) > 0 && out[0] == '{' {
+			if err := json.Unmarshal(out, &res); err != nil {
+				// The output may hold the token, don't include it.
+				return "", time.Time{}, fmt.Errorf("credential command %s printed invalid JSON", args[0])
+			}
+		} else {
+			res.Token = string(out)
+		}
+		if res.Token == "" {
+			return "", time.Time{}, fmt.Errorf("credential command %s printed no token", args[0])
+		}
+		if res.Expiry.IsZero() {
+			res.Expiry, _ = jwtExpiry(res.Token)
+		}
+		return res.Token, res.Expiry, nil
+	}}
+}
+
+// tokenTransport authenticates requests with the bearer token of source.
+type tokenTransport struct {
+	base   http.RoundTripper
+	source tokenSource
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,111 @@
+package mimirtool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCommandTokenSource(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "calls")
+	now := time.Now()
+	expiry := now.Add(2 * time.Minute).UTC().Format(time.RFC3339)
+	// Prints a new token on every call, counting the calls.
+	script := fmt.Sprintf(`echo x >> %s; printf '{"token": "token-%%s", "expiry": "%s"}' $(wc -l < %s | tr -d ' ')`, counter, expiry, counter)
+
+	source := newCommandTokenSource([]string{"sh", "-c", script}, time.Minute)
+	source.now = func() time.Time { return now }
+
+	for _, want := range []string{"token-1", "token-1"} {
+		if token, err := source.Token(); err != nil || token != want {
+			t.Fatalf("expected %q, got %q (%v)", want, token, err)
+		}
+	}
+	// Within a minute of its expiry, the token gets refreshed.
+	now = now.Add(90 * time.Second)
+	if token, err := source.Token(); err != nil || token != "token-2" {
+		t.Fatalf("expected the token to be refreshed, got %q (%v)", token, err)
+	}
+}
+
+func TestCommandTokenSourceErrorHidesOutput(t *testing.T) {
+	source := newCommandTokenSource([]string{"sh", "-c", `printf '{"token": "s3cr3t"'`}, time.Minute)
+	_, err := source.Token()
+	if err == nil || strings.Contains(err.Error(), "s3cr3t") {
+		t.Fatalf("expected an error without the command output, got: %v", err)
+	}
+}
+
+func TestCommandTokenSourceErrorHidesStderr(t *testing.T) {
+	source := newCommandTokenSource([]string{"sh", "-c", `echo s3cr3t >&2; exit 1`}, time.Minute)
+	_, err := source.Token()
+	if err == nil || strings.Contains(err.Error(), "s3cr3t") {
+		t.Fatalf("expected an error without the command standard error, got: %v", err)
+	}
+}
+
+func TestCommandTokenSourceTimeout(t *testing.T) {
+	source := newCommandTokenSource([]string{"sh", "-c", "sleep 30"}, 100*time.Millisecond)
+	start := time.Now()
+	_, err := source.Token()
+	if err == nil || !strings.Contains(err.Error(), "did not complete within 100ms") {
+		t.Fatalf("expected the command to time out, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the command to be killed, it ran for %s", elapsed)
+	}
+}
+
+func TestCachedTokenSourceSingleFlight(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	source := &cachedTokenSource{now: time.Now, fetch: func() (string, time.Time, error) {
+		calls.Add(1)
+		<-release
+		return "token", time.Time{}, nil
+	}}
+
+	var wg sync.WaitGroup
+	tokens := make(chan string, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, _ := source.Token()
+			tokens <- token
+		}()
+	}
+	// The lock is not held during the fetch.
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	source.invalidate()
+	close(release)
+	wg.Wait()
+	close(tokens)
+	for token := range tokens {
+		if token != "token" {
+			t.Errorf("expected the fetched token, got %q", token)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected the callers to share a single fetch, got %d", n)
+	}
+}
+
+func TestCommandTokenSourcePlainToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("plain-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	source := newCommandTokenSource([]string{"cat", path}, time.Minute)
+	if token, err := source.Token(); err != nil || token != "plain-token" {
+		t.Fatalf("expected the plain token, got %q (%v)", token, err)
+	}
+}
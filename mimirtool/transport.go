@@ -0,0 +1,26 @@
+package mimirtool
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// newTransport returns the HTTP transport used to reach Mimir. It is derived
+// from the one mimirtool configured, which carries the TLS settings, with the
+// inline PEM material on top.
+func newTransport(base http.RoundTripper, cfg clientConfig) *http.Transport {
+	t, ok := base.(*http.Transport)
+	if !ok || t == nil {
+		t = http.DefaultTransport.(*http.Transport)
+	}
+	t = t.Clone()
+	t.DialContext = (&net.Dialer{
+		Timeout:   cfg.dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	if cfg.tlsPEM != nil {
+		cfg.tlsPEM.apply(t)
+	}
+	return t
+}
@@ -0,0 +1,79 @@
+package mimirtool
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewTransportDialTimeout(t *testing.T) {
+	tr := newTransport(nil, clientConfig{dialTimeout: 50 * time.Millisecond})
+	if tr == http.DefaultTransport {
+		t.Fatal("the default transport must not be modified")
+	}
+
+	start := time.Now()
+	// 10.255.255.1 is not routable, connecting to it can only time out.
+	conn, err := tr.DialContext(context.Background(), "tcp", "10.255.255.1:80")
+	if err == nil {
+		conn.Close()
+		t.Skip("unexpectedly connected to a non routable address")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("dial should have failed after about 50ms, took %s", elapsed)
+	}
+}
+
+func testJWT(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+	return "eyJhbGciOiJub25lIn0." + payload + ".sig"
+}
+
+func TestTokenTransportRefreshesExpiringToken(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "token")
+	first, second := testJWT(now.Add(2*time.Minute)), testJWT(now.Add(time.Hour))
+	if err := os.WriteFile(path, []byte(first+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	source := newFileTokenSource(path)
+	source.now = func() time.Time { return now }
+	client := &http.Client{Transport: &tokenTransport{base: http.DefaultTransport, source: source}}
+	get := func() {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	get()
+	// The token got refreshed by its issuer, it is only read once the one in
+	// use is about to expire.
+	if err := os.WriteFile(path, []byte(second), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	get()
+	now = now.Add(90 * time.Second)
+	get()
+
+	want := []string{"Bearer " + first, "Bearer " + first, "Bearer " + second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("request %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
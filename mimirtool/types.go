@@ -2,18 +2,282 @@ package mimirtool
 
 import (
 	context "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
 
+	mimirtool "github.com/grafana/mimir/pkg/mimirtool/client"
 	rwrulefmt "github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
 )
 
+// clientConfig holds the settings used to build a Mimir client.
+type clientConfig struct {
+	mimirtool.Config
+
+	dialTimeout time.Duration
+	// tlsPEM is the TLS material set inline, in place of the files of TLS.
+	tlsPEM *tlsPEM
+	// clockSkewTolerance is how far apart the clocks of the runner and of the
+	// token issuer may be.
+	clockSkewTolerance time.Duration
+	// authTokenFile is read for the bearer token, instead of AuthToken.
+	authTokenFile string
+	// credentialCommand is run for the bearer token, instead of AuthToken.
+	credentialCommand []string
+	// prometheusHTTPPrefix prefixes the Prometheus compatible API paths.
+	prometheusHTTPPrefix string
+	// fallbackAddresses are tried in order when Address cannot be reached.
+	fallbackAddresses []string
+	// maxRequestBytes and maxResponseBytes bound the bodies sent to and
+	// received from Mimir, 0 means no limit.
+	maxRequestBytes  int64
+	maxResponseBytes int64
+	// logRequestBodies logs the bodies of the writes at trace level.
+	logRequestBodies bool
+	// tenantCredentials replace the credentials above for the clients of the
+	// tenants they are set for.
+	tenantCredentials map[string]tenantCredentials
+}
+
+// tenantCredentials are the credentials of a tenant, either basic auth or a
+// bearer token.
+type tenantCredentials struct {
+	user      string
+	key       string
+	authToken string
+}
+
+// forTenant returns the configuration of the clients of tenant: its
+// credentials of tenant_credentials when set, the provider ones otherwise.
+func (c clientConfig) forTenant(tenant string) clientConfig {
+	c.ID = tenant
+	if credentials, ok := c.tenantCredentials[tenant]; ok {
+		c.User, c.Key, c.AuthToken = credentials.user, credentials.key, credentials.authToken
+		c.authTokenFile, c.credentialCommand = "", nil
+	}
+	return c
+}
+
+// tokenExpiry returns the expiry of the static auth token, zero when it is not
+// known.
+func (c clientConfig) tokenExpiry() time.Time {
+	expiry, _ := jwtExpiry(c.AuthToken)
+	return expiry
+}
+
+// clientFactory builds the Mimir client of a configuration. Tests inject one
+// to serve each tenant from its own fake.
+type clientFactory func(cfg clientConfig) (mimirClientInterface, error)
+
+// defaultClientFactory builds the mimirtool client of cfg.
+func defaultClientFactory(cfg clientConfig) (mimirClientInterface, error) {
+	cli, err := getDefaultMimirClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cli, nil
+}
+
 type client struct {
-	cli mimirClientInterface
+	config                   clientConfig
+	validateRuleDependencies bool
+	verifyTenant             bool
+	idScheme                 string
+	fastRefresh              bool
+	rollbackOnFailure        bool
+	warnDeprecated           bool
+	// retry is the retry policy of the operations whose resource doesn't
+	// override it.
+	retry retryPolicy
+	// timeouts bound the calls to Mimir.
+	timeouts callTimeouts
+	// suppressAPIWarnings drops the warnings returned by Mimir instead of
+	// reporting them.
+	suppressAPIWarnings bool
+	// retryDNSFailures runs the operations of the resources again when they
+	// fail to resolve the host of Mimir.
+	retryDNSFailures bool
+	// namespaceNamePattern is the pattern the whole name of the namespaces
+	// must match, nil when not enforced.
+	namespaceNamePattern *regexp.Regexp
+	// rulerLimits are checked against the rule groups when planning.
+	rulerLimits rulerLimitChecks
+	// sensitivePatterns are redacted from the diagnostics: the default ones
+	// along with the ones of sensitive_patterns.
+	sensitivePatterns []*regexp.Regexp
+
+	// writes dispatches the write calls of all the tenants, so that
+	// max_concurrent_operations and max_writes_per_second bound the provider
+	// instance as a whole.
+	writes *writeQueue
+	// stats counts the calls made to Mimir by this provider instance.
+	stats apiStats
+	// namespaceWrites orders the writes of the namespaces along their
+	// depends_on_namespaces.
+	namespaceWrites namespaceWrites
+	// namespaces memoizes the namespaces parsed by the operations of this
+	// provider instance.
+	namespaces *namespaceCache
+
+	// factory builds the clients, defaultClientFactory when nil.
+	factory clientFactory
+
+	// cli is built on first use by mimirClient, unless it has been injected.
+	once sync.Once
+	cli  mimirClientInterface
+	err  error
+	// tenantClients caches the clients of the tenants other than the provider
+	// one, built by mimirClientForTenant.
+	tenantMu      sync.Mutex
+	tenantClients map[string]mimirClientInterface
+	// httpClients caches the HTTP clients of the tenants, the provider one
+	// included, used for the endpoints the mimirtool client doesn't support.
+	// Guarded by tenantMu.
+	httpClients map[string]*http.Client
+	// splitLimits caches the ruler_max_rules_per_rule_group limit of the
+	// tenants, read by auto_split_groups. Guarded by tenantMu.
+	splitLimits map[string]int
+}
+
+// errNoAddress reports a client needed while no address is configured.
+var errNoAddress = errors.New("no Grafana Mimir address configured, set the provider `address` or the `MIMIRTOOL_ADDRESS` or `MIMIR_ADDRESS` environment variable")
+
+// mimirClient returns the Mimir client, building it on first use. resource
+// names the resource which needs it so that a missing address is reported
+// against the operation that actually required one.
+func (c *client) mimirClient(resource string) (mimirClientInterface, error) {
+	c.once.Do(func() {
+		cfg := c.config.forTenant(c.config.ID)
+		if c.cli == nil {
+			if cfg.Address == "" {
+				c.err = errNoAddress
+				return
+			}
+			if c.factory != nil {
+				c.cli, c.err = c.factory(cfg)
+				if c.err != nil {
+					return
+				}
+			} else {
+				var cli *mimirtool.MimirClient
+				cli, c.err = getDefaultMimirClient(cfg)
+				if c.err != nil {
+					return
+				}
+				c.cli = cli
+				c.cacheHTTPClient(c.config.ID, &cli.Client)
+			}
+		}
+		c.cli = c.withOptions(c.cli, cfg)
+	})
+	if c.err != nil {
+		return nil, fmt.Errorf("%s: %w", resource, c.err)
+	}
+	return c.cli, nil
+}
+
+// mimirClientForTenant returns the client of tenant, built on first use by the
+// factory from the provider configuration and cached. The provider tenant is
+// served by mimirClient.
+func (c *client) mimirClientForTenant(resource string, tenant string) (mimirClientInterface, error) {
+	if tenant == "" || tenant == c.config.ID {
+		return c.mimirClient(resource)
+	}
+	c.tenantMu.Lock()
+	defer c.tenantMu.Unlock()
+	if cli, ok := c.tenantClients[tenant]; ok {
+		return cli, nil
+	}
+
+	if c.config.Address == "" {
+		return nil, fmt.Errorf("%s: %w", resource, errNoAddress)
+	}
+	cfg := c.config.forTenant(tenant)
+	factory := c.factory
+	if factory == nil {
+		factory = defaultClientFactory
+	}
+	cli, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: tenant %q: %w", resource, tenant, err)
+	}
+	if mc, ok := cli.(*mimirtool.MimirClient); ok {
+		c.setHTTPClient(tenant, &mc.Client)
+	}
+	cli = c.withOptions(cli, cfg)
+	if c.tenantClients == nil {
+		c.tenantClients = make(map[string]mimirClientInterface)
+	}
+	c.tenantClients[tenant] = cli
+	return cli, nil
+}
+
+// httpClient returns the HTTP client of tenant, the provider one when empty.
+// It is built from the configuration of the tenant, its credentials included,
+// on first use and cached along with its Mimir client.
+func (c *client) httpClient(resource string, tenant string) (*http.Client, error) {
+	if tenant == "" {
+		tenant = c.config.ID
+	}
+	c.tenantMu.Lock()
+	defer c.tenantMu.Unlock()
+	if httpClient, ok := c.httpClients[tenant]; ok {
+		return httpClient, nil
+	}
+	cfg := c.config.forTenant(tenant)
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("%s: %w", resource, errNoAddress)
+	}
+	cli, err := getDefaultMimirClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: tenant %q: %w", resource, tenant, err)
+	}
+	c.setHTTPClient(tenant, &cli.Client)
+	return &cli.Client, nil
+}
+
+// cacheHTTPClient records httpClient as the one of tenant, unless one was
+// already built.
+func (c *client) cacheHTTPClient(tenant string, httpClient *http.Client) {
+	c.tenantMu.Lock()
+	defer c.tenantMu.Unlock()
+	c.setHTTPClient(tenant, httpClient)
+}
+
+// setHTTPClient is cacheHTTPClient for callers holding tenantMu.
+func (c *client) setHTTPClient(tenant string, httpClient *http.Client) {
+	if _, ok := c.httpClients[tenant]; ok {
+		return
+	}
+	if c.httpClients == nil {
+		c.httpClients = make(map[string]*http.Client)
+	}
+	c.httpClients[tenant] = httpClient
+}
+
+// withOptions wraps cli, the client of cfg, with the provider settings.
+func (c *client) withOptions(cli mimirClientInterface, cfg clientConfig) mimirClientInterface {
+	return newAPIClient(cli, apiClientOptions{
+		writes:          c.writes,
+		tenant:          cfg.ID,
+		auth:            authMechanism(cfg),
+		tokenExpiry:     cfg.tokenExpiry(),
+		verifyTenant:    c.verifyTenant,
+		retry:           c.retry,
+		timeouts:        c.timeouts,
+		maxRequestBytes: cfg.maxRequestBytes,
+		stats:           &c.stats,
+	})
 }
 
 type mimirClientInterface interface {
 	// Ruler
 	DeleteRuleGroup(ctx context.Context, namespace string, groupName string) error
 	ListRules(ctx context.Context, namespace string) (map[string][]rwrulefmt.RuleGroup, error)
+	GetRuleGroup(ctx context.Context, namespace string, groupName string) (*rwrulefmt.RuleGroup, error)
 	DeleteNamespace(ctx context.Context, namespace string) error
 	CreateRuleGroup(ctx context.Context, namespace string, rg rwrulefmt.RuleGroup) error
 	// Alertmanager
@@ -0,0 +1,177 @@
+package mimirtool
+
+import (
+	"sort"
+
+	"github.com/grafana/mimir/pkg/mimirtool/rules/rwrulefmt"
+	"gopkg.in/yaml.v3"
+)
+
+// knownGroupFields are the rule group fields the provider understands. The
+// other ones, e.g. added by a Mimir version newer than the provider, are
+// carried as opaque values so that they survive a read-modify-write cycle.
+var knownGroupFields = map[string]bool{
+	"name":                              true,
+	"interval":                          true,
+	"evaluation_delay":                  true,
+	"query_offset":                      true,
+	"limit":                             true,
+	"rules":                             true,
+	"source_tenants":                    true,
+	"align_evaluation_time_on_interval": true,
+	"remote_write":                      true,
+}
+
+// groupFields are the unknown fields of a group as key and value nodes in turn,
+// the way they are stored in a mapping node.
+type groupFields []*yaml.Node
+
+// unknownGroupFields returns the unknown fields of the groups listed under key
+// in configYAML, by group name. Only groups with unknown fields are returned.
+func unknownGroupFields(configYAML string, key string) map[string]groupFields {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(configYAML), &doc); err != nil {
+		return nil
+	}
+	return documentGroupFields(&doc, key)
+}
+
+// documentGroupFields is unknownGroupFields for a parsed document.
+func documentGroupFields(doc *yaml.Node, key string) map[string]groupFields {
+	groups := mappingValue(doc, key)
+	if groups == nil || groups.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	res := make(map[string]groupFields)
+	for _, group := range groups.Content {
+		if group.Kind != yaml.MappingNode {
+			continue
+		}
+		var fields groupFields
+		for i := 0; i+1 < len(group.Content); i += 2 {
+			if !knownGroupFields[group.Content[i].Value] {
+				fields = append(fields, group.Content[i], group.Content[i+1])
+			}
+		}
+		if name := mappingValue(group, "name"); name != nil && len(fields) > 0 {
+			res[name.Value] = fields
+		}
+	}
+	return res
+}
+
+// withUnknownGroupFields adds fields to the groups listed under "groups" in
+// configYAML, sorted by key so that the result is canonical.
+func withUnknownGroupFields(configYAML string, fields map[string]groupFields) string {
+	if len(fields) == 0 {
+		return configYAML
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(configYAML), &doc); err != nil {
+		return configYAML
+	}
+	groups := mappingValue(&doc, "groups")
+	if groups == nil || groups.Kind != yaml.SequenceNode {
+		return configYAML
+	}
+	for _, group := range groups.Content {
+		if name := mappingValue(group, "name"); name != nil {
+			group.Content = append(group.Content, sortedFields(fields[name.Value])...)
+		}
+	}
+	res, err := yaml.Marshal(&doc)
+	if err != nil {
+		return configYAML
+	}
+	return string(res)
+}
+
+// withoutUnknownGroupFields removes the unknown fields of the groups listed
+// under "groups" in configYAML.
+func withoutUnknownGroupFields(configYAML string) string {
+	if len(parseNamespace(configYAML).groupFields()) == 0 {
+		return configYAML
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(configYAML), &doc); err != nil {
+		return configYAML
+	}
+	for _, group := range mappingValue(&doc, "groups").Content {
+		if group.Kind != yaml.MappingNode {
+			continue
+		}
+		var content []*yaml.Node
+		for i := 0; i+1 < len(group.Content); i += 2 {
+			if knownGroupFields[group.Content[i].Value] {
+				content = append(content, group.Content[i], group.Content[i+1])
+			}
+		}
+		group.Content = content
+	}
+	res, err := yaml.Marshal(&doc)
+	if err != nil {
+		return configYAML
+	}
+	return string(res)
+}
+
+// groupYAMLWithFields serializes group along with its unknown fields.
+func groupYAMLWithFields(group rwrulefmt.RuleGroup, fields groupFields) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(group); err != nil {
+		return nil, err
+	}
+	node.Content = append(node.Content, sortedFields(fields)...)
+	return yaml.Marshal(&node)
+}
+
+// unknownGroupFieldsEqual compares the unknown fields of two namespaces.
+func unknownGroupFieldsEqual(a, b map[string]groupFields) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, fields := range a {
+		other, ok := b[name]
+		if !ok {
+			return false
+		}
+		x, errX := yaml.Marshal(&yaml.Node{Kind: yaml.MappingNode, Content: sortedFields(fields)})
+		y, errY := yaml.Marshal(&yaml.Node{Kind: yaml.MappingNode, Content: sortedFields(other)})
+		if errX != nil || errY != nil || string(x) != string(y) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedFields(fields groupFields) groupFields {
+	type pair struct{ key, value *yaml.Node }
+	pairs := make([]pair, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		pairs = append(pairs, pair{fields[i], fields[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].key.Value < pairs[j].key.Value })
+	res := make(groupFields, 0, len(fields))
+	for _, p := range pairs {
+		res = append(res, p.key, p.value)
+	}
+	return res
+}
+
+// mappingValue returns the value of key in the mapping node, or in the mapping
+// at the root of the document node.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package mimirtool
+
+import (
+	"strings"
+	"testing"
+)
+
+const testUnknownFieldsYAML = `groups:
+  - name: demo
+    future_field:
+      enabled: true
+    rules:
+      - record: job:up:sum
+        expr: sum by (job) (up)
+`
+
+func TestUnknownGroupFieldsRoundTrip(t *testing.T) {
+	normalized := normalizeNamespaceYAML(testUnknownFieldsYAML)
+	if !strings.Contains(normalized, "future_field:") || !strings.Contains(normalized, "enabled: true") {
+		t.Fatalf("the unknown field was lost by the normalization:\n%s", normalized)
+	}
+	if normalizeNamespaceYAML(normalized) != normalized {
+		t.Fatalf("the normalization is not stable:\n%s", normalized)
+	}
+
+	// The parser rejecting unknown fields never sees them.
+	if stripped := withoutUnknownGroupFields(testUnknownFieldsYAML); strings.Contains(stripped, "future_field") {
+		t.Fatalf("the unknown field was not stripped:\n%s", stripped)
+	}
+
+	// The unknown fields take part in the diff as opaque values.
+	if !diffNamespaceYAML("", normalized, testUnknownFieldsYAML, nil) {
+		t.Fatal("expected no difference between the source and its normalized form")
+	}
+	changed := strings.Replace(testUnknownFieldsYAML, "enabled: true", "enabled: false", 1)
+	if diffNamespaceYAML("", normalized, changed, nil) {
+		t.Fatal("expected a change of the unknown field to be a difference")
+	}
+	removed := strings.Replace(testUnknownFieldsYAML, "    future_field:\n      enabled: true\n", "", 1)
+	if diffNamespaceYAML("", normalized, removed, nil) {
+		t.Fatal("expected removing the unknown field to be a difference")
+	}
+}
+
+func TestUnknownGroupFieldsFromRemote(t *testing.T) {
+	// The ruler configuration API lists the groups under the namespace name.
+	remote := `demo:
+    - name: demo
+      future_field:
+        enabled: true
+      rules: []
+`
+	fields := unknownGroupFields(remote, "demo")
+	if len(fields["demo"]) != 2 {
+		t.Fatalf("expected the unknown field of the remote group, got %v", fields)
+	}
+	merged := withUnknownGroupFields("groups:\n    - name: demo\n      rules: []\n", fields)
+	if !unknownGroupFieldsEqual(unknownGroupFields(merged, "groups"), unknownGroupFields(testUnknownFieldsYAML, "groups")) {
+		t.Fatalf("expected the remote field to be merged:\n%s", merged)
+	}
+}
@@ -0,0 +1,107 @@
+package mimirtool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// validator is a check run by the provider on a resource, name is stable as
+// users reference it in skip_validation.
+type validator struct {
+	name        string
+	description string
+}
+
+const (
+	validatorPromQL              = "promql"
+	validatorRecordingRules      = "recording_rules"
+	validatorForDurations        = "for_durations"
+	validatorForMultiples        = "for_multiples"
+	validatorQueryModifiers      = "query_modifiers"
+	validatorCardinality         = "recording_rule_cardinality"
+	validatorRuleDependencies    = "rule_dependencies"
+	validatorDeprecations        = "deprecations"
+	validatorAlertNames          = "alert_names"
+	validatorRootRoute           = "root_route"
+	validatorReceiverCredentials = "receiver_credentials"
+	validatorConfigSize          = "config_size"
+)
+
+var rulerNamespaceValidators = []validator{
+	{validatorPromQL, "rules are valid as told by the mimirtool parser: their expressions and templates parse and the group names are unique"},
+	{validatorRecordingRules, "recording rules names follow the best practices, see `strict_recording_rule_check`"},
+	{validatorForDurations, "alerts `for` is not shorter than their group interval, run with `extended_validation`"},
+	{validatorForMultiples, "alerts `for` is a multiple of their group interval, run with `require_for_multiple_of_interval`"},
+	{validatorQueryModifiers, "rules don't use `@` or `offset` modifiers which misbehave in rule evaluation, run with `extended_validation`"},
+	{validatorCardinality, "recording rules aggregate the labels of the metrics they read, run with `check_recording_rule_cardinality`"},
+	{validatorRuleDependencies, "alerts don't reference undefined recording rules, run with the provider `validate_rule_dependencies`"},
+	{validatorDeprecations, "rules don't use deprecated constructs, run with the provider `warn_deprecated`"},
+	{validatorAlertNames, "alert names are unique across the groups of the namespace, see `duplicate_alert_names`"},
+}
+
+var alertmanagerValidators = []validator{
+	{validatorRootRoute, "the root route sends to a receiver defined in `receivers`"},
+	{validatorReceiverCredentials, "receivers integrations have the credentials they need, reported by `planned_warnings` when planning"},
+	{validatorConfigSize, "the configuration fits the tenant `alertmanager_max_config_size_bytes` limit, when the limits API is available"},
+}
+
+// validationSchema returns the attributes controlling the validators of a
+// resource, their documentation lists the validators.
+func validationSchema(validators []validator) map[string]*schema.Schema {
+	names := make([]string, 0, len(validators))
+	descriptions := make([]string, 0, len(validators))
+	for _, v := range validators {
+		names = append(names, v.name)
+		descriptions = append(descriptions, fmt.Sprintf("`%s` (%s)", v.name, v.description))
+	}
+
+	return map[string]*schema.Schema{
+		"validate": {
+			Description: "Run the provider-side validators, `false` disables all of them. The configuration is still decoded, which rejects the unknown fields.",
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+		},
+		"skip_validation": {
+			Description: "Names of the validators to skip, e.g. when a newer Grafana Mimir accepts what they reject: " + strings.Join(descriptions, ", ") + ".",
+			Type:        schema.TypeList,
+			Optional:    true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+				ValidateDiagFunc: func(value any, k cty.Path) diag.Diagnostics {
+					for _, name := range names {
+						if value.(string) == name {
+							return nil
+						}
+					}
+					return diag.Diagnostics{
+						diag.Diagnostic{
+							Severity:      diag.Warning,
+							Summary:       "Unknown validator.",
+							Detail:        fmt.Sprintf("%q is not a validator of this resource, expected one of: %s.", value, strings.Join(names, ", ")),
+							AttributePath: k,
+						},
+					}
+				},
+			},
+		},
+	}
+}
+
+// validatorEnabled tells whether the validator name should run on the
+// resource of d.
+func validatorEnabled(d attributeGetter, name string) bool {
+	if !d.Get("validate").(bool) {
+		return false
+	}
+	for _, skipped := range d.Get("skip_validation").([]interface{}) {
+		if skipped == name {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package mimirtool
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestValidatorEnabled(t *testing.T) {
+	for _, tc := range []struct {
+		config map[string]interface{}
+		want   bool
+	}{
+		{map[string]interface{}{}, true},
+		{map[string]interface{}{"skip_validation": []interface{}{validatorRuleDependencies}}, true},
+		{map[string]interface{}{"skip_validation": []interface{}{validatorForDurations}}, false},
+		{map[string]interface{}{"validate": false}, false},
+	} {
+		tc.config["namespace"] = "demo"
+		tc.config["config_yaml"] = "groups: []"
+		d := schema.TestResourceDataRaw(t, resourceRulerNamespace().Schema, tc.config)
+		if got := validatorEnabled(d, validatorForDurations); got != tc.want {
+			t.Errorf("%v: expected %v, got %v", tc.config, tc.want, got)
+		}
+	}
+}
+
+func TestSkipValidationUnknownName(t *testing.T) {
+	validate := validationSchema(rulerNamespaceValidators)["skip_validation"].Elem.(*schema.Schema).ValidateDiagFunc
+	if diags := validate(validatorForDurations, cty.Path{}); len(diags) != 0 {
+		t.Fatalf("expected no warning for a known validator, got %v", diags)
+	}
+	if diags := validate("promql_typo", cty.Path{}); len(diags) != 1 {
+		t.Fatalf("expected a warning for an unknown validator, got %v", diags)
+	}
+}
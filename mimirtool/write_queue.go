@@ -0,0 +1,117 @@
+package mimirtool
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// writeQueue dispatches the write calls of every resource of a provider
+// instance through a single pool of workers, paced at a maximum rate, so that
+// large applies present a steady load to Mimir rather than bursts.
+type writeQueue struct {
+	// jobs feeds the workers, nil when the concurrency is not capped in which
+	// case writes are run by their caller once paced.
+	jobs chan *writeJob
+
+	// interval is the minimum delay between two writes, 0 means unlimited.
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+// writeJob is a write waiting in the queue, its result is sent back to the
+// resource which submitted it through done.
+type writeJob struct {
+	ctx       context.Context
+	operation string
+	f         func() error
+	queued    time.Time
+	done      chan error
+}
+
+// newWriteQueue starts a queue running at most workers writes at the same
+// time and at most writesPerSecond writes per second, 0 meaning no limit.
+func newWriteQueue(workers int, writesPerSecond int) *writeQueue {
+	q := &writeQueue{}
+	if writesPerSecond > 0 {
+		q.interval = time.Second / time.Duration(writesPerSecond)
+	}
+	if workers > 0 {
+		q.jobs = make(chan *writeJob)
+		for i := 0; i < workers; i++ {
+			go q.work()
+		}
+	}
+	return q
+}
+
+// do runs f through the queue and returns its error.
+func (q *writeQueue) do(ctx context.Context, operation string, f func() error) error {
+	if q.jobs == nil {
+		if err := q.pace(ctx); err != nil {
+			return err
+		}
+		return f()
+	}
+
+	job := &writeJob{ctx: ctx, operation: operation, f: f, queued: time.Now(), done: make(chan error, 1)}
+	select {
+	case q.jobs <- job:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-job.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *writeQueue) work() {
+	for job := range q.jobs {
+		if err := job.ctx.Err(); err != nil {
+			job.done <- err
+			continue
+		}
+		if err := q.pace(job.ctx); err != nil {
+			job.done <- err
+			continue
+		}
+		tflog.Debug(job.ctx, "Dispatching Mimir write", map[string]interface{}{
+			"operation": job.operation,
+			"wait":      time.Since(job.queued).String(),
+		})
+		job.done <- job.f()
+	}
+}
+
+// pace blocks until the next write is allowed by the rate limit.
+func (q *writeQueue) pace(ctx context.Context) error {
+	if q.interval == 0 {
+		return nil
+	}
+	q.mu.Lock()
+	now := time.Now()
+	if q.next.Before(now) {
+		q.next = now
+	}
+	wait := q.next.Sub(now)
+	q.next = q.next.Add(q.interval)
+	q.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,73 @@
+package mimirtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteQueuePacesWrites(t *testing.T) {
+	q := newWriteQueue(2, 50)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := q.do(context.Background(), "test", func() error { return nil }); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The first write is immediate, the 4 others are spaced by 20ms.
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("expected writes to be paced, took %s", elapsed)
+	}
+}
+
+func TestWriteQueueErrorAttribution(t *testing.T) {
+	q := newWriteQueue(2, 0)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = q.do(context.Background(), "test", func() error {
+				return fmt.Errorf("write %d", i)
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil || err.Error() != fmt.Sprintf("write %d", i) {
+			t.Fatalf("write %d got the error of another write: %v", i, err)
+		}
+	}
+}
+
+func TestWriteQueueCanceled(t *testing.T) {
+	q := newWriteQueue(1, 0)
+	started, block := make(chan struct{}), make(chan struct{})
+	go q.do(context.Background(), "test", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	defer close(block)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.do(ctx, "test", func() error { return nil }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the queued write to be canceled, got: %v", err)
+	}
+}
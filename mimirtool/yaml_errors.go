@@ -0,0 +1,214 @@
+package mimirtool
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// documentSeparator matches the lines starting a YAML document.
+	documentSeparator = regexp.MustCompile(`^---(\s|$)`)
+	// nodePosition matches the line:column prefix of the rule validation
+	// errors, an alert label clash is prefixed by both nodes.
+	nodePosition = regexp.MustCompile(`^(\d+):(\d+): (\d+:\d+: )?`)
+	// yamlLine matches the line prefix of the YAML syntax and decoding errors.
+	yamlLine = regexp.MustCompile(`^(yaml: )?line (\d+): (column (\d+): )?`)
+	// unknownField matches the decoding errors of fields the parser rejects.
+	unknownField = regexp.MustCompile(`^field (\S+) not found`)
+	// ruleError matches the rule validation errors, whose rules are indexed
+	// from 0.
+	ruleError = regexp.MustCompile(`^group ("(?:[^"\\]|\\.)*"), rule (\d+)`)
+	// repeatedGroup matches the error of a group name used twice.
+	repeatedGroup = regexp.MustCompile(`^groupname: "(.*)" is repeated`)
+)
+
+// yamlSource locates the errors of parsing a configuration made of one or
+// several concatenated YAML documents, named fragments.
+type yamlSource struct {
+	lines []string
+	// rewritten tells whether the errors refer to a rewritten configuration,
+	// whose positions don't match the source.
+	rewritten bool
+	// documents are the parsed documents, up to the first invalid one.
+	documents []*yaml.Node
+	// separators are the lines starting the fragments after the first one.
+	separators []int
+}
+
+func newYAMLSource(configYAML string) *yamlSource {
+	s := &yamlSource{lines: strings.Split(configYAML, "\n")}
+	content := false
+	for i, line := range s.lines {
+		if documentSeparator.MatchString(line) {
+			// A leading separator doesn't start another fragment.
+			if content {
+				s.separators = append(s.separators, i+1)
+			}
+			continue
+		}
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			content = true
+		}
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(configYAML))
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			break
+		}
+		s.documents = append(s.documents, &doc)
+	}
+	return s
+}
+
+// namespaceParseError reports errs, the errors of parsing parsed, the form of
+// configYAML given to the parser, each one with its line and column in
+// configYAML, its fragment when there are several, and an excerpt of the
+// offending line.
+func namespaceParseError(configYAML, parsed string, errs []error) error {
+	s := newYAMLSource(configYAML)
+	s.rewritten = parsed != configYAML
+	var msgs []string
+	for _, err := range errs {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			for _, msg := range typeErr.Errors {
+				msgs = append(msgs, s.describe(msg))
+			}
+			continue
+		}
+		msgs = append(msgs, s.describe(err.Error()))
+	}
+	return fmt.Errorf("failed to parse namespace definition:\n%s", strings.Join(msgs, "\n"))
+}
+
+// ruleGroupsError reports the first fragment which is valid YAML but not a
+// definition of rule groups, e.g. an Alertmanager configuration, whose errors
+// would otherwise be about its fields. Empty fragments are left to the parser.
+func (s *yamlSource) ruleGroupsError() error {
+	for i, doc := range s.documents {
+		if len(doc.Content) == 0 || mappingValue(doc, "groups") != nil {
+			continue
+		}
+		root := doc.Content[0]
+		got := "a scalar"
+		switch root.Kind {
+		case yaml.SequenceNode:
+			got = "a list"
+		case yaml.MappingNode:
+			var keys []string
+			for j := 0; j < len(root.Content); j += 2 {
+				keys = append(keys, root.Content[j].Value)
+			}
+			got = "the keys " + strings.Join(keys, ", ")
+			if len(keys) == 0 {
+				got = "an empty mapping"
+			}
+		}
+		where := fmt.Sprintf("line %d", root.Line)
+		if len(s.separators) > 0 {
+			where += fmt.Sprintf(" of fragment %d", i+1)
+		}
+		return fmt.Errorf("failed to parse namespace definition:\n%s: not a definition of rule groups, expected a mapping with a `groups` list, got %s", where, got)
+	}
+	return nil
+}
+
+// describe prefixes msg with its position and follows it with an excerpt of
+// its line, when it can be located.
+func (s *yamlSource) describe(msg string) string {
+	line, column, msg := s.position(strings.TrimSpace(msg))
+	if line <= 0 || line > len(s.lines) {
+		return msg
+	}
+	where := fmt.Sprintf("line %d", line)
+	if column > 0 {
+		where += fmt.Sprintf(", column %d", column)
+	}
+	if len(s.separators) > 0 {
+		where += fmt.Sprintf(" of fragment %d", s.fragment(line))
+	}
+
+	gutter := strconv.Itoa(line)
+	excerpt := fmt.Sprintf("  %s | %s", gutter, s.lines[line-1])
+	if column > 0 {
+		excerpt += fmt.Sprintf("\n  %s | %s^", strings.Repeat(" ", len(gutter)), strings.Repeat(" ", column-1))
+	}
+	return where + ": " + msg + "\n" + excerpt
+}
+
+// position returns the 1-based line and column msg refers to, zero when they
+// are not known, and msg without the position it was prefixed with.
+func (s *yamlSource) position(msg string) (int, int, string) {
+	// The positions in a rewritten configuration are dropped, the rules are
+	// located by their group and index instead.
+	if m := nodePosition.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		column, _ := strconv.Atoi(m[2])
+		msg = msg[len(m[0]):]
+		if !s.rewritten {
+			return line, column, msg
+		}
+	}
+	if m := yamlLine.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[4])
+		msg = msg[len(m[0]):]
+		if s.rewritten {
+			return 0, 0, msg
+		}
+		if f := unknownField.FindStringSubmatch(msg); f != nil && column == 0 && line <= len(s.lines) {
+			column = strings.Index(s.lines[line-1], f[1]) + 1
+		}
+		return line, column, msg
+	}
+	if m := ruleError.FindStringSubmatch(msg); m != nil {
+		group, _ := strconv.Unquote(m[1])
+		index, _ := strconv.Atoi(m[2])
+		for _, node := range s.groupNodes(group) {
+			if rules := mappingValue(node, "rules"); rules != nil && rules.Kind == yaml.SequenceNode && index < len(rules.Content) {
+				return rules.Content[index].Line, rules.Content[index].Column, msg
+			}
+		}
+	}
+	if m := repeatedGroup.FindStringSubmatch(msg); m != nil {
+		if nodes := s.groupNodes(m[1]); len(nodes) > 1 {
+			return nodes[1].Line, nodes[1].Column, msg
+		}
+	}
+	return 0, 0, msg
+}
+
+// groupNodes returns the nodes of the groups named name, in all fragments.
+func (s *yamlSource) groupNodes(name string) []*yaml.Node {
+	var nodes []*yaml.Node
+	for _, doc := range s.documents {
+		groups := mappingValue(doc, "groups")
+		if groups == nil || groups.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, group := range groups.Content {
+			if value := mappingValue(group, "name"); value != nil && value.Value == name {
+				nodes = append(nodes, group)
+			}
+		}
+	}
+	return nodes
+}
+
+// fragment returns the 1-based index of the fragment holding line.
+func (s *yamlSource) fragment(line int) int {
+	index := 1
+	for _, start := range s.separators {
+		if start < line {
+			index++
+		}
+	}
+	return index
+}
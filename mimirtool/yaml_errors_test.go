@@ -0,0 +1,66 @@
+package mimirtool
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNamespaceParseError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		configYAML string
+		errs       []error
+		expected   []string
+	}{
+		"unknown field": {
+			configYAML: "groups:\n  - name: a\n    rules:\n      - alert: A\n        expression: up\n",
+			expected: []string{
+				"line 5, column 9: field expression not found",
+				"  5 |         expression: up\n    |         ^",
+			},
+		},
+		"syntax error in a fragment": {
+			configYAML: "groups:\n  - name: a\n    rules: []\n---\ngroups:\n  - name: b\n    rules: []\n   interval: 1m\n",
+			expected:   []string{" of fragment 2: did not find expected"},
+		},
+		"repeated group": {
+			configYAML: "groups:\n  - name: a\n    rules: []\n  - name: a\n    rules: []\n",
+			expected:   []string{`line 4, column 5: groupname: "a" is repeated`},
+		},
+		"not rule groups": {
+			configYAML: "route:\n  receiver: default\nreceivers:\n  - name: default\n",
+			expected:   []string{"line 1: not a definition of rule groups", "got the keys route, receivers"},
+		},
+		"list in a fragment": {
+			configYAML: "groups:\n  - name: a\n    rules: []\n---\n- name: b\n",
+			expected:   []string{"line 5 of fragment 2: not a definition of rule groups", "got a list"},
+		},
+		"rule without position": {
+			configYAML: "groups:\n  - name: a\n    rules:\n      - record: r\n        expr: up\n      - alert: A\n        expr: up ==\n",
+			errs:       []error{errors.New(`group "a", rule 1, "A": could not parse expression`)},
+			expected:   []string{"line 6, column 9: group \"a\", rule 1", "  6 |       - alert: A"},
+		},
+		"rewritten configuration": {
+			configYAML: "groups:\n  - name: a\n    rules:\n      - alert: A\n        expr: up ==\n",
+			errs:       []error{errors.New(`9:15: group "a", rule 0, "A": could not parse expression`)},
+			expected:   []string{"line 4, column 9: group \"a\", rule 0"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var err error
+			if tc.errs == nil {
+				_, err = parseRuleNamespace(tc.configYAML, true)
+			} else {
+				err = namespaceParseError(tc.configYAML, tc.configYAML+"# rewritten", tc.errs)
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected %q in:\n%s", expected, err)
+				}
+			}
+		})
+	}
+}
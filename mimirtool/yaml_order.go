@@ -0,0 +1,104 @@
+package mimirtool
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// orderYAMLLike re-orders the mapping keys of value to follow the order they
+// have in reference, walking both documents in parallel. Keys unknown to
+// reference keep their order after the known ones. value is returned as is
+// when either document cannot be parsed, e.g. reference is empty after an
+// import.
+func orderYAMLLike(value, reference string) string {
+	var valueNode, referenceNode yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &valueNode); err != nil || valueNode.Kind == 0 {
+		return value
+	}
+	if err := yaml.Unmarshal([]byte(reference), &referenceNode); err != nil || referenceNode.Kind == 0 {
+		return value
+	}
+	orderNodeLike(&valueNode, &referenceNode)
+
+	ordered, err := yaml.Marshal(&valueNode)
+	if err != nil {
+		return value
+	}
+	return string(ordered)
+}
+
+func orderNodeLike(value, reference *yaml.Node) {
+	if value.Kind != reference.Kind {
+		return
+	}
+	switch value.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for i := 0; i < len(value.Content) && i < len(reference.Content); i++ {
+			orderNodeLike(value.Content[i], reference.Content[i])
+		}
+	case yaml.MappingNode:
+		// Content holds the keys and values of a mapping in turn.
+		var ordered []*yaml.Node
+		used := make(map[int]bool)
+		for i := 0; i+1 < len(reference.Content); i += 2 {
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				if !used[j] && value.Content[j].Value == reference.Content[i].Value {
+					orderNodeLike(value.Content[j+1], reference.Content[i+1])
+					ordered = append(ordered, value.Content[j], value.Content[j+1])
+					used[j] = true
+					break
+				}
+			}
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			if !used[j] {
+				ordered = append(ordered, value.Content[j], value.Content[j+1])
+			}
+		}
+		value.Content = ordered
+	}
+}
+
+// orderGroupsLike re-orders the rule groups of the namespace value to follow
+// the order of the groups of the same name in reference. Groups unknown to
+// reference keep their order after the known ones. value is returned as is
+// when either document cannot be parsed.
+func orderGroupsLike(value, reference string) string {
+	var valueNode, referenceNode yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &valueNode); err != nil {
+		return value
+	}
+	if err := yaml.Unmarshal([]byte(reference), &referenceNode); err != nil {
+		return value
+	}
+	groups, referenceGroups := mappingValue(&valueNode, "groups"), mappingValue(&referenceNode, "groups")
+	if groups == nil || referenceGroups == nil || groups.Kind != yaml.SequenceNode || referenceGroups.Kind != yaml.SequenceNode {
+		return value
+	}
+	var ordered []*yaml.Node
+	used := make(map[int]bool)
+	for _, referenceGroup := range referenceGroups.Content {
+		name := mappingValue(referenceGroup, "name")
+		if name == nil {
+			continue
+		}
+		for i, group := range groups.Content {
+			if groupName := mappingValue(group, "name"); !used[i] && groupName != nil && groupName.Value == name.Value {
+				ordered = append(ordered, group)
+				used[i] = true
+				break
+			}
+		}
+	}
+	for i, group := range groups.Content {
+		if !used[i] {
+			ordered = append(ordered, group)
+		}
+	}
+	groups.Content = ordered
+
+	reordered, err := yaml.Marshal(&valueNode)
+	if err != nil {
+		return value
+	}
+	return string(reordered)
+}
@@ -0,0 +1,40 @@
+package mimirtool
+
+import (
+	"testing"
+)
+
+func TestOrderYAMLLike(t *testing.T) {
+	value := `groups:
+    - name: demo
+      rules:
+        - record: job:up:sum
+          expr: sum by (job) (up)
+          labels:
+            team: infra
+`
+	reference := `groups:
+  - name: demo
+    rules:
+      - expr: sum by (job) (up)
+        labels:
+          team: infra
+        record: job:up:sum
+`
+	expected := `groups:
+    - name: demo
+      rules:
+        - expr: sum by (job) (up)
+          labels:
+            team: infra
+          record: job:up:sum
+`
+	if got := orderYAMLLike(value, reference); got != expected {
+		t.Fatalf("unexpected ordering, got:\n%s", got)
+	}
+
+	// Without reference, e.g. after an import, the value is kept as is.
+	if got := orderYAMLLike(value, ""); got != value {
+		t.Fatalf("expected the value to be kept, got:\n%s", got)
+	}
+}